@@ -0,0 +1,9 @@
+// Package docs 内嵌手动维护的 Swagger 文档，使服务二进制不依赖工作目录中的 docs/ 目录
+package docs
+
+import "embed"
+
+// FS 内嵌的 Swagger 文档
+//
+//go:embed swagger.json swagger.yaml
+var FS embed.FS
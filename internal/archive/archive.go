@@ -0,0 +1,37 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"tgo-rtc-server/internal/config"
+)
+
+// Store 归档文件存储
+// 实现需并发安全；Put 返回 nil 时数据必须已持久化，调用方随后会删除数据库中的原始记录
+type Store interface {
+	// Name 存储名称，用于日志
+	Name() string
+	// Put 写入一个归档文件，key 为相对路径（使用 / 分隔）
+	Put(ctx context.Context, key string, data []byte) error
+}
+
+// NewStore 根据配置创建归档文件存储：配置了 S3 地址时写入 S3 兼容存储，否则写入本地目录
+func NewStore(cfg *config.Config) (Store, error) {
+	if cfg.ArchiveS3Endpoint == "" {
+		return NewLocalStore(cfg.ArchiveDir), nil
+	}
+	if cfg.ArchiveS3Bucket == "" || cfg.ArchiveS3AccessKey == "" || cfg.ArchiveS3SecretKey == "" {
+		return nil, fmt.Errorf("归档 S3 配置不完整: 需要 ARCHIVE_S3_BUCKET、ARCHIVE_S3_ACCESS_KEY、ARCHIVE_S3_SECRET_KEY")
+	}
+	client := &http.Client{Timeout: 60 * time.Second}
+	return NewS3Store(S3Config{
+		Endpoint:  cfg.ArchiveS3Endpoint,
+		Bucket:    cfg.ArchiveS3Bucket,
+		Region:    cfg.ArchiveS3Region,
+		AccessKey: cfg.ArchiveS3AccessKey,
+		SecretKey: cfg.ArchiveS3SecretKey,
+	}, client)
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"os"
 	"strconv"
@@ -17,16 +20,25 @@ type WebhookEndpoint struct {
 // Config 应用配置
 type Config struct {
 	// 服务配置
-	Port     string
-	Env      string
-	LogLevel string
+	Port            string
+	Env             string
+	LogLevel        string
+	ShutdownTimeout int      // 优雅关闭超时时间（秒），默认 30 秒
+	TrustedProxies  []string // 受信任的反向代理 IP/CIDR，仅信任来自这些地址的 X-Forwarded-For，默认不信任任何代理
 
 	// 数据库配置
+	DBDriver   string // 数据库驱动: mysql（默认）, postgres, sqlite
 	DBHost     string
-	DBPort     string
+	DBPort     string // 默认 mysql 3306，postgres 5432
 	DBUser     string
 	DBPassword string
 	DBName     string
+	DBSSLMode  string // PostgreSQL sslmode，默认 disable
+	DBPath     string // SQLite 数据库文件路径，默认 data/tgo_rtc.db（:memory: 为内存数据库）
+
+	DBAutoMigrate bool   // 服务启动时是否自动执行迁移，默认 true；关闭后需通过 migrate 子命令执行
+	MigrationsDir string // 迁移脚本目录（其下按驱动分子目录），为空时使用编译进二进制的脚本，用于开发调试
+	DocsDir       string // Swagger 文档目录，为空时使用编译进二进制的文档，用于开发调试
 
 	// Redis 配置
 	RedisHost     string
@@ -52,6 +64,95 @@ type Config struct {
 	BusinessWebhookLogRetentionDays   int  // 日志保留天数，默认 7 天
 	BusinessWebhookLogCleanupEnabled  bool // 是否启用日志自动清理
 	BusinessWebhookLogCleanupInterval int  // 日志清理间隔（秒），默认 86400（1 天）
+
+	// 邀请链接配置
+	InviteLinkSecret     string // 邀请码签名密钥，默认为 HMAC-SHA256(LIVEKIT_API_SECRET, "invite-link")
+	InviteLinkBaseURL    string // 邀请链接前缀，如 https://meet.example.com/join?code=
+	InviteLinkDefaultTTL int    // 邀请链接默认有效期（秒），默认 86400（1 天）
+
+	// 客户端实时事件推送配置（SSE）
+	EventStreamEnabled   bool   // 是否启用客户端实时事件推送
+	EventStreamSecret    string // 事件流 Token 签名密钥，默认为 HMAC-SHA256(LIVEKIT_API_SECRET, "event-stream")
+	EventStreamIssuerKey string // 业务服务端签发事件流 Token 的共享密钥（X-Server-Key 请求头），为空时拒绝签发
+	EventStreamTokenTTL  int    // 事件流 Token 默认有效期（秒），默认 86400（1 天）
+	EventStreamHeartbeat int    // 心跳间隔（秒），默认 25 秒
+
+	// 移动端推送配置（未配置的通道不启用）
+	PushTimeout           int    // 推送请求超时时间（秒），默认 10 秒
+	APNsEndpoint          string // APNs 地址，默认 https://api.push.apple.com
+	APNsKeyID             string // APNs .p8 密钥 ID
+	APNsTeamID            string // Apple 开发者团队 ID
+	APNsBundleID          string // 应用 Bundle ID（VoIP topic 为 <BundleID>.voip）
+	APNsPrivateKeyFile    string // APNs .p8 私钥文件路径
+	FCMEndpoint           string // FCM 地址，默认 https://fcm.googleapis.com
+	FCMProjectID          string // Firebase 项目 ID，默认使用服务账号中的 project_id
+	FCMServiceAccountFile string // Firebase 服务账号 JSON 文件路径
+	HMSEndpoint           string // 华为推送地址，默认 https://push-api.cloud.huawei.com
+	HMSTokenURL           string // 华为 OAuth 地址，默认 https://oauth-login.cloud.huawei.com/oauth2/v3/token
+	HMSAppID              string // 华为应用 ID
+	HMSClientSecret       string // 华为应用密钥
+
+	// 就绪检查配置（/readyz）
+	HealthCheckTimeout  int  // 就绪检查超时时间（秒），默认 3 秒
+	HealthCheckLiveKit  bool // 是否检查 LiveKit API 可达性
+	HealthCheckWebhooks bool // 是否检查业务 webhook 端点可达性（不可达时仅降级，不影响就绪）
+
+	// 链路追踪配置（OpenTelemetry）
+	TracingEnabled     bool    // 是否启用链路追踪
+	TracingExporter    string  // 导出器: otlp（默认）, stdout
+	TracingEndpoint    string  // OTLP/HTTP 地址，如 http://otel-collector:4318，为空时使用 OTEL_EXPORTER_OTLP_ENDPOINT
+	TracingServiceName string  // 上报的服务名，默认 tgo-rtc-server
+	TracingSampleRatio float64 // 采样率（0~1），默认 1（全部采样）
+
+	// 频率限制配置（基于 Redis 固定窗口，各配额为 0 表示不限制）
+	RateLimitEnabled         bool   // 是否启用频率限制，默认启用
+	RateLimitWindow          int    // 创建/邀请/加入的统计窗口（秒），默认 60 秒
+	RateLimitTenantHeader    string // 租户标识请求头，默认 X-Tenant-ID（未携带时不做租户维度限制）
+	RateLimitCreatePerUID    int    // 每个用户每窗口最多创建房间次数，默认 20
+	RateLimitCreatePerIP     int    // 每个 IP 每窗口最多创建房间次数，默认 100
+	RateLimitCreatePerTenant int    // 每个租户每窗口最多创建房间次数，默认 0（不限制）
+	RateLimitInvitePerUID    int    // 每个用户每窗口最多邀请次数，默认 30
+	RateLimitInvitePerIP     int    // 每个 IP 每窗口最多邀请次数，默认 200
+	RateLimitInvitePerTenant int    // 每个租户每窗口最多邀请次数，默认 0（不限制）
+	RateLimitJoinPerUID      int    // 每个用户每窗口最多加入房间次数，默认 30
+	RateLimitJoinPerIP       int    // 每个 IP 每窗口最多加入房间次数，默认 300
+	RateLimitJoinPerTenant   int    // 每个租户每窗口最多加入房间次数，默认 0（不限制）
+	RateLimitRingPerCallee   int    // 每个被叫每个振铃窗口最多收到的来电次数，默认 10
+	RateLimitRingWindow      int    // 被叫来电统计窗口（秒），默认 300 秒
+
+	// 呼叫策略配置（本地屏蔽列表始终生效，以下为业务后端呼叫策略接口）
+	CallPolicyURL      string // 呼叫策略接口地址，为空时不调用（仅检查本地屏蔽列表）
+	CallPolicySecret   string // 呼叫策略接口签名密钥（X-Signature 为请求体的 HMAC-SHA256）
+	CallPolicyTimeout  int    // 呼叫策略接口超时时间（秒），默认 2 秒
+	CallPolicyCacheTTL int    // 呼叫策略结果缓存时间（秒），默认 60 秒，0 表示不缓存
+	CallPolicyFailOpen bool   // 呼叫策略接口异常时是否放行，默认放行
+
+	// 通话索引配置
+	ActiveCallIndexEnabled bool // 是否启用 Redis 通话索引（忙线检查、同步接口、超时兜底轮询优先查询索引），默认启用
+
+	// 幂等配置（创建房间、邀请、加入房间支持 Idempotency-Key 请求头）
+	IdempotencyTTL int // 幂等响应保存时间（秒），默认 86400 秒，0 表示不启用
+
+	// 冷数据归档配置（已结束的房间及参与者移出热表）
+	ArchiveEnabled     bool   // 是否启用归档，默认关闭
+	ArchiveMode        string // 归档方式: table（移入归档表，默认）, file（导出 NDJSON 文件到本地目录或 S3 兼容存储）
+	ArchiveAfterDays   int    // 房间结束多少天后归档，默认 90 天
+	ArchiveInterval    int    // 归档任务执行间隔（秒），默认 3600 秒
+	ArchiveBatchSize   int    // 每批归档的记录数，默认 500
+	ArchiveBatchPause  int    // 批次之间的暂停时间（毫秒），默认 200，用于限制对数据库的压力
+	ArchiveMaxBatches  int    // 每次任务最多处理的批次数，默认 100，0 表示不限制
+	ArchiveWebhookLogs bool   // webhook 日志清理时先归档再删除（需启用归档），默认关闭
+	ArchiveDir         string // file 模式的本地目录，默认 data/archive
+	ArchiveS3Endpoint  string // file 模式的 S3 兼容存储地址，配置后写入 S3 而不是本地目录
+	ArchiveS3Bucket    string // S3 存储桶
+	ArchiveS3Region    string // S3 区域，默认 us-east-1
+	ArchiveS3AccessKey string // S3 Access Key
+	ArchiveS3SecretKey string // S3 Secret Key
+	ArchivePrefix      string // 归档文件 key 前缀（本地目录下的子目录或 S3 对象 key 前缀）
+
+	// 用户可用状态配置
+	PresenceDNDPolicy    string // 免打扰用户被呼叫时的处理: silent（默认，静默邀请）, reject（直接返回不可用）
+	PresenceOfflineAfter int    // 未设置状态的用户，推送 Token 超过该时间（秒）未更新时视为离线，默认 0（不推断）
 }
 
 // LoadConfig 从环境变量加载配置
@@ -145,18 +246,111 @@ func LoadConfig() *Config {
 		}
 	}
 
+	inviteLinkDefaultTTL := 86400 // 默认 1 天
+	if ttl := os.Getenv("INVITE_LINK_DEFAULT_TTL"); ttl != "" {
+		if t, err := strconv.Atoi(ttl); err == nil && t > 0 {
+			inviteLinkDefaultTTL = t
+		}
+	}
+
+	eventStreamTokenTTL := 86400 // 默认 1 天
+	if ttl := os.Getenv("EVENT_STREAM_TOKEN_TTL"); ttl != "" {
+		if t, err := strconv.Atoi(ttl); err == nil && t > 0 {
+			eventStreamTokenTTL = t
+		}
+	}
+
+	eventStreamHeartbeat := 25 // 默认 25 秒，低于常见代理的空闲超时
+	if heartbeat := os.Getenv("EVENT_STREAM_HEARTBEAT"); heartbeat != "" {
+		if h, err := strconv.Atoi(heartbeat); err == nil && h > 0 {
+			eventStreamHeartbeat = h
+		}
+	}
+
+	pushTimeout := 10 // 默认 10 秒
+	if timeout := os.Getenv("PUSH_TIMEOUT"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil && t > 0 {
+			pushTimeout = t
+		}
+	}
+
+	shutdownTimeout := 30 // 默认 30 秒，需小于 Kubernetes terminationGracePeriodSeconds
+	if timeout := os.Getenv("SHUTDOWN_TIMEOUT"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil && t > 0 {
+			shutdownTimeout = t
+		}
+	}
+
+	healthCheckTimeout := 3 // 默认 3 秒，需小于探针的超时时间
+	if timeout := os.Getenv("HEALTH_CHECK_TIMEOUT"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil && t > 0 {
+			healthCheckTimeout = t
+		}
+	}
+
+	tracingSampleRatio := 1.0 // 默认全部采样
+	if ratio := os.Getenv("TRACING_SAMPLE_RATIO"); ratio != "" {
+		if r, err := strconv.ParseFloat(ratio, 64); err == nil && r >= 0 && r <= 1 {
+			tracingSampleRatio = r
+		}
+	}
+
+	rateLimitWindow := 60 // 默认 1 分钟
+	if window := os.Getenv("RATE_LIMIT_WINDOW"); window != "" {
+		if w, err := strconv.Atoi(window); err == nil && w > 0 {
+			rateLimitWindow = w
+		}
+	}
+
+	rateLimitRingWindow := 300 // 默认 5 分钟
+	if window := os.Getenv("RATE_LIMIT_RING_WINDOW"); window != "" {
+		if w, err := strconv.Atoi(window); err == nil && w > 0 {
+			rateLimitRingWindow = w
+		}
+	}
+
+	callPolicyTimeout := 2 // 默认 2 秒
+	if timeout := os.Getenv("CALL_POLICY_TIMEOUT"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil && t > 0 {
+			callPolicyTimeout = t
+		}
+	}
+
+	// 受信任的反向代理（逗号分隔），未配置时客户端 IP 取 TCP 连接的对端地址
+	var trustedProxies []string
+	for _, proxy := range strings.Split(getEnv("TRUSTED_PROXIES", ""), ",") {
+		if trimmed := strings.TrimSpace(proxy); trimmed != "" {
+			trustedProxies = append(trustedProxies, trimmed)
+		}
+	}
+
+	dbDriver := strings.ToLower(getEnv("DB_DRIVER", "mysql"))
+	defaultDBPort := "3306"
+	if dbDriver == "postgres" {
+		defaultDBPort = "5432"
+	}
+
 	return &Config{
 		// 服务配置
-		Port:     getEnv("PORT", "8080"),
-		Env:      getEnv("ENV", "development"),
-		LogLevel: getEnv("LOG_LEVEL", "info"),
+		Port:            getEnv("PORT", "8080"),
+		Env:             getEnv("ENV", "development"),
+		LogLevel:        getEnv("LOG_LEVEL", "info"),
+		ShutdownTimeout: shutdownTimeout,
+		TrustedProxies:  trustedProxies,
 
 		// 数据库配置
+		DBDriver:   dbDriver,
 		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnv("DB_PORT", "3306"),
+		DBPort:     getEnv("DB_PORT", defaultDBPort),
 		DBUser:     getEnv("DB_USER", "root"),
 		DBPassword: getEnv("DB_PASSWORD", ""),
 		DBName:     getEnv("DB_NAME", "tgo_rtc"),
+		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
+		DBPath:     getEnv("DB_PATH", "data/tgo_rtc.db"),
+
+		DBAutoMigrate: os.Getenv("DB_AUTO_MIGRATE") != "false",
+		MigrationsDir: getEnv("MIGRATIONS_DIR", ""),
+		DocsDir:       getEnv("DOCS_DIR", ""),
 
 		// Redis 配置
 		RedisHost:     getEnv("REDIS_HOST", "localhost"),
@@ -182,6 +376,95 @@ func LoadConfig() *Config {
 		BusinessWebhookLogRetentionDays:   businessWebhookLogRetentionDays,
 		BusinessWebhookLogCleanupEnabled:  businessWebhookLogCleanupEnabled,
 		BusinessWebhookLogCleanupInterval: businessWebhookLogCleanupInterval,
+
+		// 邀请链接配置
+		InviteLinkSecret:     getEnvOrDerivedSecret("INVITE_LINK_SECRET", "invite-link"), // 默认由 LIVEKIT_API_SECRET 派生
+		InviteLinkBaseURL:    getEnv("INVITE_LINK_BASE_URL", ""),
+		InviteLinkDefaultTTL: inviteLinkDefaultTTL,
+
+		// 客户端实时事件推送配置
+		EventStreamEnabled:   os.Getenv("EVENT_STREAM_ENABLED") == "true",
+		EventStreamSecret:    getEnvOrDerivedSecret("EVENT_STREAM_SECRET", "event-stream"), // 默认由 LIVEKIT_API_SECRET 派生
+		EventStreamIssuerKey: getEnv("EVENT_STREAM_ISSUER_KEY", ""),
+		EventStreamTokenTTL:  eventStreamTokenTTL,
+		EventStreamHeartbeat: eventStreamHeartbeat,
+
+		// 移动端推送配置
+		PushTimeout:           pushTimeout,
+		APNsEndpoint:          getEnv("APNS_ENDPOINT", ""),
+		APNsKeyID:             getEnv("APNS_KEY_ID", ""),
+		APNsTeamID:            getEnv("APNS_TEAM_ID", ""),
+		APNsBundleID:          getEnv("APNS_BUNDLE_ID", ""),
+		APNsPrivateKeyFile:    getEnv("APNS_PRIVATE_KEY_FILE", ""),
+		FCMEndpoint:           getEnv("FCM_ENDPOINT", ""),
+		FCMProjectID:          getEnv("FCM_PROJECT_ID", ""),
+		FCMServiceAccountFile: getEnv("FCM_SERVICE_ACCOUNT_FILE", ""),
+		HMSEndpoint:           getEnv("HMS_ENDPOINT", ""),
+		HMSTokenURL:           getEnv("HMS_TOKEN_URL", ""),
+		HMSAppID:              getEnv("HMS_APP_ID", ""),
+		HMSClientSecret:       getEnv("HMS_CLIENT_SECRET", ""),
+
+		// 就绪检查配置
+		HealthCheckTimeout:  healthCheckTimeout,
+		HealthCheckLiveKit:  os.Getenv("HEALTH_CHECK_LIVEKIT") == "true",
+		HealthCheckWebhooks: os.Getenv("HEALTH_CHECK_WEBHOOKS") == "true",
+
+		// 链路追踪配置
+		TracingEnabled:     os.Getenv("TRACING_ENABLED") == "true",
+		TracingExporter:    getEnv("TRACING_EXPORTER", "otlp"),
+		TracingEndpoint:    getEnv("TRACING_ENDPOINT", ""),
+		TracingServiceName: getEnv("TRACING_SERVICE_NAME", "tgo-rtc-server"),
+		TracingSampleRatio: tracingSampleRatio,
+
+		// 频率限制配置
+		RateLimitEnabled:         os.Getenv("RATE_LIMIT_ENABLED") != "false",
+		RateLimitWindow:          rateLimitWindow,
+		RateLimitTenantHeader:    getEnv("RATE_LIMIT_TENANT_HEADER", "X-Tenant-ID"),
+		RateLimitCreatePerUID:    getEnvAsQuota("RATE_LIMIT_CREATE_PER_UID", 20),
+		RateLimitCreatePerIP:     getEnvAsQuota("RATE_LIMIT_CREATE_PER_IP", 100),
+		RateLimitCreatePerTenant: getEnvAsQuota("RATE_LIMIT_CREATE_PER_TENANT", 0),
+		RateLimitInvitePerUID:    getEnvAsQuota("RATE_LIMIT_INVITE_PER_UID", 30),
+		RateLimitInvitePerIP:     getEnvAsQuota("RATE_LIMIT_INVITE_PER_IP", 200),
+		RateLimitInvitePerTenant: getEnvAsQuota("RATE_LIMIT_INVITE_PER_TENANT", 0),
+		RateLimitJoinPerUID:      getEnvAsQuota("RATE_LIMIT_JOIN_PER_UID", 30),
+		RateLimitJoinPerIP:       getEnvAsQuota("RATE_LIMIT_JOIN_PER_IP", 300),
+		RateLimitJoinPerTenant:   getEnvAsQuota("RATE_LIMIT_JOIN_PER_TENANT", 0),
+		RateLimitRingPerCallee:   getEnvAsQuota("RATE_LIMIT_RING_PER_CALLEE", 10),
+		RateLimitRingWindow:      rateLimitRingWindow,
+
+		// 呼叫策略配置
+		CallPolicyURL:      getEnv("CALL_POLICY_URL", ""),
+		CallPolicySecret:   getEnv("CALL_POLICY_SECRET", ""),
+		CallPolicyTimeout:  callPolicyTimeout,
+		CallPolicyCacheTTL: getEnvAsQuota("CALL_POLICY_CACHE_TTL", 60),
+		CallPolicyFailOpen: os.Getenv("CALL_POLICY_FAIL_OPEN") != "false",
+
+		// 通话索引配置
+		ActiveCallIndexEnabled: os.Getenv("ACTIVE_CALL_INDEX_ENABLED") != "false",
+
+		// 幂等配置
+		IdempotencyTTL: getEnvAsQuota("IDEMPOTENCY_TTL", 86400),
+
+		// 冷数据归档配置
+		ArchiveEnabled:     os.Getenv("ARCHIVE_ENABLED") == "true",
+		ArchiveMode:        strings.ToLower(getEnv("ARCHIVE_MODE", "table")),
+		ArchiveAfterDays:   getEnvAsQuota("ARCHIVE_AFTER_DAYS", 90),
+		ArchiveInterval:    getEnvAsQuota("ARCHIVE_INTERVAL", 3600),
+		ArchiveBatchSize:   getEnvAsQuota("ARCHIVE_BATCH_SIZE", 500),
+		ArchiveBatchPause:  getEnvAsQuota("ARCHIVE_BATCH_PAUSE", 200),
+		ArchiveMaxBatches:  getEnvAsQuota("ARCHIVE_MAX_BATCHES", 100),
+		ArchiveWebhookLogs: os.Getenv("ARCHIVE_WEBHOOK_LOGS") == "true",
+		ArchiveDir:         getEnv("ARCHIVE_DIR", "data/archive"),
+		ArchiveS3Endpoint:  getEnv("ARCHIVE_S3_ENDPOINT", ""),
+		ArchiveS3Bucket:    getEnv("ARCHIVE_S3_BUCKET", ""),
+		ArchiveS3Region:    getEnv("ARCHIVE_S3_REGION", "us-east-1"),
+		ArchiveS3AccessKey: getEnv("ARCHIVE_S3_ACCESS_KEY", ""),
+		ArchiveS3SecretKey: getEnv("ARCHIVE_S3_SECRET_KEY", ""),
+		ArchivePrefix:      getEnv("ARCHIVE_PREFIX", ""),
+
+		// 用户可用状态配置
+		PresenceDNDPolicy:    getEnv("PRESENCE_DND_POLICY", "silent"),
+		PresenceOfflineAfter: getEnvAsQuota("PRESENCE_OFFLINE_AFTER", 0),
 	}
 }
 
@@ -192,3 +475,28 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvOrDerivedSecret 获取密钥类环境变量，未设置时由 LIVEKIT_API_SECRET 按用途派生独立的子密钥
+// 派生密钥与 LiveKit 密钥不同，泄露时不会暴露 LiveKit 密钥；需要独立轮换时应显式配置对应的环境变量
+func getEnvOrDerivedSecret(key, purpose string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	base := os.Getenv("LIVEKIT_API_SECRET")
+	if base == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(base))
+	mac.Write([]byte(purpose))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// getEnvAsQuota 获取配额类环境变量（非负整数，0 表示不限制），未设置或格式错误时返回默认值
+func getEnvAsQuota(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if v, err := strconv.Atoi(value); err == nil && v >= 0 {
+			return v
+		}
+	}
+	return defaultValue
+}
@@ -2,67 +2,24 @@ package database
 
 import (
 	"fmt"
-	"strings"
 
 	"tgo-rtc-server/internal/config"
-	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/tracing"
 	"tgo-rtc-server/internal/utils"
 
-	"gorm.io/driver/mysql"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-// InitDB 初始化数据库连接
+// InitDB 初始化数据库连接，DB_AUTO_MIGRATE 开启时（默认）同时执行迁移
 func InitDB(cfg *config.Config) (*gorm.DB, error) {
-	// 构建 DSN（带数据库名）
-	dsn := fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.DBUser,
-		cfg.DBPassword,
-		cfg.DBHost,
-		cfg.DBPort,
-		cfg.DBName,
-	)
-
-	// 优雅处理“Unknown database”场景：尝试自动创建数据库（仅在开发环境有效）
-	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
-	}
-	openWithDSN := func(d string) (*gorm.DB, error) { return gorm.Open(mysql.Open(d), gormConfig) }
-
-	db, err := openWithDSN(dsn)
+	db, err := OpenDB(cfg)
 	if err != nil {
-		if strings.Contains(err.Error(), "Unknown database") || strings.Contains(err.Error(), "1049") {
-			// 连接到不带数据库名的 DSN
-			dsnNoDB := fmt.Sprintf(
-				"%s:%s@tcp(%s:%s)/?charset=utf8mb4&parseTime=True&loc=Local",
-				cfg.DBUser,
-				cfg.DBPassword,
-				cfg.DBHost,
-				cfg.DBPort,
-			)
-			noDB, err2 := openWithDSN(dsnNoDB)
-			if err2 != nil {
-				return nil, fmt.Errorf("数据库连接失败: %w", err)
-			}
-			// 创建数据库
-			createSQL := fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s` DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci;", cfg.DBName)
-			if err2 = noDB.Exec(createSQL).Error; err2 != nil {
-				return nil, fmt.Errorf("创建数据库失败: %w", err2)
-			}
-			// 再次尝试连接目标数据库
-			db, err = openWithDSN(dsn)
-			if err != nil {
-				return nil, fmt.Errorf("数据库连接失败: %w", err)
-			}
-		} else {
-			return nil, fmt.Errorf("数据库连接失败: %w", err)
-		}
+		return nil, err
 	}
 
 	logger := utils.GetLogger()
-	logger.Info("✅ 数据库连接成功")
 
 	// 初始化迁移管理器
 	mm := NewMigrationManager(db)
@@ -72,17 +29,74 @@ func InitDB(cfg *config.Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("迁移表初始化失败: %w", err)
 	}
 
+	if !cfg.DBAutoMigrate {
+		// 未开启自动迁移时只提示待执行的迁移，由运维通过 migrate 子命令执行
+		warnPendingMigrations(mm, cfg)
+		return db, nil
+	}
+
 	// 运行迁移脚本
-	if err := RunMigrations(mm); err != nil {
+	if err := RunMigrations(mm, cfg); err != nil {
 		return nil, fmt.Errorf("运行迁移脚本失败: %w", err)
 	}
 
 	logger.Info("✅ 数据库迁移完成")
 
-	// 自动迁移（用于其他模型）
-	if err := db.AutoMigrate(&models.Room{}, &models.Participant{}); err != nil {
-		return nil, fmt.Errorf("自动迁移失败: %w", err)
+	// 表结构只由迁移脚本维护，启动时不再执行 AutoMigrate；模型标签与迁移结构的差异通过 migrate check 检查
+	return db, nil
+}
+
+// OpenDB 建立数据库连接并注册链路追踪插件，不执行迁移
+func OpenDB(cfg *config.Config) (*gorm.DB, error) {
+	gormConfig := &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	}
+
+	db, err := openDB(cfg, gormConfig)
+	if err != nil {
+		return nil, err
 	}
 
+	utils.GetLogger().Info("✅ 数据库连接成功", zap.String("driver", migrationsDirFor(cfg.DBDriver)))
+
+	// 注册链路追踪插件（通过 db.WithContext(ctx) 传入请求上下文的 SQL 才会生成 Span）
+	if err := db.Use(tracing.NewGormPlugin()); err != nil {
+		return nil, fmt.Errorf("注册链路追踪插件失败: %w", err)
+	}
 	return db, nil
 }
+
+// warnPendingMigrations 检查是否存在未执行或已被修改的迁移，仅记录警告
+func warnPendingMigrations(mm *MigrationManager, cfg *config.Config) {
+	logger := utils.GetLogger()
+	scripts, err := LoadMigrations(cfg)
+	if err != nil {
+		logger.Warn("⚠️  加载迁移脚本失败", zap.Error(err))
+		return
+	}
+	states, err := mm.Status(scripts)
+	if err != nil {
+		logger.Warn("⚠️  查询迁移状态失败", zap.Error(err))
+		return
+	}
+
+	var pending, modified []string
+	for _, state := range states {
+		switch state.State {
+		case MigrationStatePending, MigrationStateFailed:
+			pending = append(pending, state.Version)
+		case MigrationStateModified:
+			modified = append(modified, state.Version)
+		}
+	}
+	if len(pending) > 0 {
+		logger.Warn("⚠️  存在未执行的迁移，DB_AUTO_MIGRATE=false 时请执行 migrate up",
+			zap.Strings("versions", pending),
+		)
+	}
+	if len(modified) > 0 {
+		logger.Warn("⚠️  已执行的迁移文件被修改",
+			zap.Strings("versions", modified),
+		)
+	}
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tgo-rtc-server/internal/config"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// 支持的数据库驱动
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// openDB 根据配置的驱动打开数据库连接
+func openDB(cfg *config.Config, gormConfig *gorm.Config) (*gorm.DB, error) {
+	switch cfg.DBDriver {
+	case "", DriverMySQL:
+		return openMySQL(cfg, gormConfig)
+	case DriverPostgres:
+		return openPostgres(cfg, gormConfig)
+	case DriverSQLite:
+		return openSQLite(cfg, gormConfig)
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s（可选 mysql, postgres, sqlite）", cfg.DBDriver)
+	}
+}
+
+// openMySQL 连接 MySQL，数据库不存在时尝试自动创建
+func openMySQL(cfg *config.Config, gormConfig *gorm.Config) (*gorm.DB, error) {
+	// 构建 DSN（带数据库名）
+	dsn := fmt.Sprintf(
+		"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		cfg.DBUser,
+		cfg.DBPassword,
+		cfg.DBHost,
+		cfg.DBPort,
+		cfg.DBName,
+	)
+
+	// 优雅处理“Unknown database”场景：尝试自动创建数据库（仅在开发环境有效）
+	openWithDSN := func(d string) (*gorm.DB, error) { return gorm.Open(mysql.Open(d), gormConfig) }
+
+	db, err := openWithDSN(dsn)
+	if err == nil {
+		return db, nil
+	}
+	if !strings.Contains(err.Error(), "Unknown database") && !strings.Contains(err.Error(), "1049") {
+		return nil, fmt.Errorf("数据库连接失败: %w", err)
+	}
+
+	// 连接到不带数据库名的 DSN
+	dsnNoDB := fmt.Sprintf(
+		"%s:%s@tcp(%s:%s)/?charset=utf8mb4&parseTime=True&loc=Local",
+		cfg.DBUser,
+		cfg.DBPassword,
+		cfg.DBHost,
+		cfg.DBPort,
+	)
+	noDB, err2 := openWithDSN(dsnNoDB)
+	if err2 != nil {
+		return nil, fmt.Errorf("数据库连接失败: %w", err)
+	}
+	// 创建数据库
+	createSQL := fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s` DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci;", cfg.DBName)
+	if err2 = noDB.Exec(createSQL).Error; err2 != nil {
+		return nil, fmt.Errorf("创建数据库失败: %w", err2)
+	}
+	// 再次尝试连接目标数据库
+	db, err = openWithDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("数据库连接失败: %w", err)
+	}
+	return db, nil
+}
+
+// openPostgres 连接 PostgreSQL（数据库需预先创建）
+func openPostgres(cfg *config.Config, gormConfig *gorm.Config) (*gorm.DB, error) {
+	dsn := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s TimeZone=Local",
+		cfg.DBHost,
+		cfg.DBPort,
+		cfg.DBUser,
+		cfg.DBPassword,
+		cfg.DBName,
+		cfg.DBSSLMode,
+	)
+	db, err := gorm.Open(postgres.Open(dsn), gormConfig)
+	if err != nil {
+		return nil, fmt.Errorf("数据库连接失败: %w", err)
+	}
+	return db, nil
+}
+
+// openSQLite 打开 SQLite 数据库文件，目录不存在时自动创建
+// 适用于本地开发和单机部署；需要 CGO 构建
+func openSQLite(cfg *config.Config, gormConfig *gorm.Config) (*gorm.DB, error) {
+	var dsn string
+	if cfg.DBPath == ":memory:" {
+		dsn = "file::memory:?cache=shared"
+	} else {
+		if dir := filepath.Dir(cfg.DBPath); dir != "" && dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, fmt.Errorf("创建 SQLite 数据目录失败: %w", err)
+			}
+		}
+		dsn = cfg.DBPath + "?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=1"
+	}
+
+	db, err := gorm.Open(sqlite.Open(dsn), gormConfig)
+	if err != nil {
+		return nil, fmt.Errorf("数据库连接失败: %w", err)
+	}
+
+	// SQLite 同一时刻只允许一个写连接，限制连接数避免 database is locked
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// migrationsDirFor 返回指定驱动对应的迁移脚本子目录
+func migrationsDirFor(driver string) string {
+	if driver == "" {
+		return DriverMySQL
+	}
+	return driver
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"tgo-rtc-server/internal/config"
+)
+
+// MigrateUsage migrate 子命令用法
+const MigrateUsage = `用法: tgo-rtc-server migrate <命令> [数量]
+
+命令:
+  status      查看各迁移版本的执行状态
+  up [N]      执行待执行的迁移（默认全部，N 为最多执行的数量）
+  down [N]    回滚最近执行的迁移（默认 1 个）
+  redo        回滚最近执行的一个迁移并重新执行
+  check       对比模型定义与数据库表结构，报告差异（存在差异时退出码非 0）
+`
+
+// RunMigrateCommand 执行 migrate 子命令，结果输出到 out
+func RunMigrateCommand(cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(out, MigrateUsage)
+		return fmt.Errorf("缺少 migrate 命令")
+	}
+
+	command := args[0]
+	limit := 0
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return fmt.Errorf("无效的数量: %s", args[1])
+		}
+		limit = n
+	}
+
+	switch command {
+	case "status", "up", "down", "redo", "check":
+	default:
+		fmt.Fprint(out, MigrateUsage)
+		return fmt.Errorf("未知的 migrate 命令: %s", command)
+	}
+
+	db, err := OpenDB(cfg)
+	if err != nil {
+		return err
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	mm := NewMigrationManager(db)
+	if err := mm.InitMigrationTable(); err != nil {
+		return err
+	}
+
+	scripts, err := LoadMigrations(cfg)
+	if err != nil {
+		return fmt.Errorf("加载迁移脚本失败: %w", err)
+	}
+
+	switch command {
+	case "status":
+		states, err := mm.Status(scripts)
+		if err != nil {
+			return err
+		}
+		printMigrationStates(out, states)
+	case "up":
+		if err := mm.VerifyChecksums(scripts); err != nil {
+			return err
+		}
+		count, err := mm.Up(scripts, limit)
+		fmt.Fprintf(out, "已执行 %d 个迁移\n", count)
+		if err != nil {
+			return err
+		}
+	case "down":
+		rolledBack, err := mm.Down(scripts, limit)
+		for _, version := range rolledBack {
+			fmt.Fprintf(out, "已回滚 %s\n", version)
+		}
+		if err != nil {
+			return err
+		}
+		if len(rolledBack) == 0 {
+			fmt.Fprintln(out, "没有可回滚的迁移")
+		}
+	case "redo":
+		version, err := mm.Redo(scripts)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "已重新执行 %s\n", version)
+	case "check":
+		diffs, err := CheckSchemaDrift(db)
+		if err != nil {
+			return err
+		}
+		if len(diffs) == 0 {
+			fmt.Fprintln(out, "模型定义与数据库表结构一致")
+			return nil
+		}
+		printSchemaDiffs(out, diffs)
+		return fmt.Errorf("发现 %d 处表结构差异", len(diffs))
+	}
+	return nil
+}
+
+// printSchemaDiffs 以表格形式输出表结构差异
+func printSchemaDiffs(out io.Writer, diffs []SchemaDiff) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TABLE\tKIND\tNAME\tDETAIL")
+	for _, diff := range diffs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", diff.Table, diff.Kind, diff.Name, diff.Detail)
+	}
+	w.Flush()
+}
+
+// printMigrationStates 以表格形式输出迁移状态
+func printMigrationStates(out io.Writer, states []MigrationState) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATE\tDOWN\tEXECUTED AT\tNAME")
+	for _, state := range states {
+		executedAt := "-"
+		if state.ExecutedAt != nil {
+			executedAt = state.ExecutedAt.Format(time.DateTime)
+		}
+		down := "no"
+		if state.HasDown {
+			down = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", state.Version, state.State, down, executedAt, state.Name)
+	}
+	w.Flush()
+
+	// 失败原因单独输出，避免撑宽表格
+	for _, state := range states {
+		if state.State == MigrationStateFailed && state.Error != "" {
+			fmt.Fprintf(out, "\n%s 执行失败: %s\n", state.Version, state.Error)
+		}
+	}
+}
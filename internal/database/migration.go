@@ -1,7 +1,10 @@
 package database
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"tgo-rtc-server/internal/utils"
@@ -15,7 +18,8 @@ type Migration struct {
 	ID         int        `gorm:"primaryKey" json:"id"`
 	Version    string     `gorm:"column:version;size:50;not null;uniqueIndex" json:"version"`
 	Name       string     `gorm:"column:name;size:255;not null" json:"name"`
-	SQL        string     `gorm:"column:sql;type:longtext;not null" json:"sql"`
+	SQL        string     `gorm:"column:sql;not null" json:"sql"`
+	Checksum   string     `gorm:"column:checksum;size:64;not null;default:''" json:"checksum"`    // up 脚本的 SHA-256 校验和
 	Status     string     `gorm:"column:status;size:20;not null;default:'pending'" json:"status"` // pending, success, failed
 	Error      string     `gorm:"column:error;type:text" json:"error"`
 	ExecutedAt *time.Time `gorm:"column:executed_at" json:"executed_at"`
@@ -48,67 +52,374 @@ func (mm *MigrationManager) InitMigrationTable() error {
 	return nil
 }
 
-// ExecuteMigration 执行迁移
-func (mm *MigrationManager) ExecuteMigration(version, name, sql string) error {
-	// 检查迁移是否已执行
-	logger := utils.GetLogger()
-	var existingMigration Migration
-	if err := mm.db.Where("version = ?", version).First(&existingMigration).Error; err == nil {
-		if existingMigration.Status == "success" {
-			logger.Info("⏭️  迁移已执行，跳过",
-				zap.String("version", version),
-				zap.String("name", name),
-			)
-			return nil
+// 迁移记录状态
+const (
+	MigrationStatusPending = "pending"
+	MigrationStatusSuccess = "success"
+	MigrationStatusFailed  = "failed"
+)
+
+// 迁移脚本与迁移记录对比后的状态（仅用于 migrate status 展示）
+const (
+	MigrationStateApplied  = "applied"  // 已执行
+	MigrationStatePending  = "pending"  // 待执行
+	MigrationStateFailed   = "failed"   // 上次执行失败
+	MigrationStateModified = "modified" // 已执行但文件已被修改
+	MigrationStateMissing  = "missing"  // 已执行但文件已不存在
+)
+
+// MigrationState 单个迁移版本的状态
+type MigrationState struct {
+	Version    string
+	Name       string
+	State      string
+	HasDown    bool
+	ExecutedAt *time.Time
+	Error      string
+}
+
+// transactional 当前数据库是否支持事务性 DDL
+// PostgreSQL、SQLite 的 DDL 可在事务内回滚；MySQL 的 DDL 会隐式提交，只能逐条执行
+func (mm *MigrationManager) transactional() bool {
+	return mm.db.Dialector.Name() != DriverMySQL
+}
+
+// findRecord 查询指定版本的迁移记录，不存在时返回 nil
+func (mm *MigrationManager) findRecord(version string) (*Migration, error) {
+	var record Migration
+	err := mm.db.Where("version = ?", version).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	return &record, nil
+}
+
+// execOnConnection 在同一个数据库连接上逐条执行脚本中的语句
+// 非事务执行时也需固定连接，保证脚本中的会话变量（如 MySQL 的 @var、PREPARE）在语句间可见
+func (mm *MigrationManager) execOnConnection(sql string) error {
+	return mm.db.Connection(func(conn *gorm.DB) error {
+		return execStatements(conn, sql)
+	})
+}
+
+// execStatements 逐条执行脚本中的语句
+func execStatements(tx *gorm.DB, sql string) error {
+	for i, stmt := range splitStatements(sql) {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("第 %d 条语句执行失败: %w", i+1, err)
 		}
 	}
+	return nil
+}
+
+// ExecuteMigration 执行单个迁移的 up 脚本
+// 支持事务性 DDL 的数据库中脚本与迁移记录在同一事务内提交；重试失败的迁移时复用已有记录
+func (mm *MigrationManager) ExecuteMigration(script MigrationScript) error {
+	logger := utils.GetLogger()
 
-	// 记录迁移开始
-	migration := Migration{
-		Version: version,
-		Name:    name,
-		SQL:     sql,
-		Status:  "pending",
+	// 检查迁移是否已执行
+	record, err := mm.findRecord(script.Version)
+	if err != nil {
+		return err
+	}
+	if record != nil && record.Status == MigrationStatusSuccess {
+		logger.Info("⏭️  迁移已执行，跳过",
+			zap.String("version", script.Version),
+			zap.String("name", script.Name),
+		)
+		return nil
 	}
 
-	if err := mm.db.Create(&migration).Error; err != nil {
+	// 记录迁移开始（失败后重试时更新原记录，避免违反 version 唯一索引）
+	if record == nil {
+		record = &Migration{Version: script.Version}
+	}
+	record.Name = script.Name
+	record.SQL = script.SQL
+	record.Checksum = script.Checksum
+	record.Status = MigrationStatusPending
+	record.Error = ""
+	if err := mm.db.Save(record).Error; err != nil {
 		return fmt.Errorf("记录迁移失败: %w", err)
 	}
 
 	// 执行 SQL
 	logger.Info("🔄 执行迁移",
-		zap.String("version", version),
-		zap.String("name", name),
+		zap.String("version", script.Version),
+		zap.String("name", script.Name),
+		zap.Bool("transactional", mm.transactional()),
 	)
 	logger.Info("📝 SQL",
-		zap.String("sql", sql),
+		zap.String("sql", script.SQL),
 	)
 
-	if err := mm.db.Exec(sql).Error; err != nil {
+	now := time.Now()
+	success := map[string]interface{}{
+		"status":      MigrationStatusSuccess,
+		"executed_at": now,
+	}
+	if mm.transactional() {
+		err = mm.db.Transaction(func(tx *gorm.DB) error {
+			if err := execStatements(tx, script.SQL); err != nil {
+				return err
+			}
+			return tx.Model(record).Updates(success).Error
+		})
+	} else {
+		err = mm.execOnConnection(script.SQL)
+		if err == nil {
+			err = mm.db.Model(record).Updates(success).Error
+		}
+	}
+
+	if err != nil {
 		// 更新迁移状态为失败
-		mm.db.Model(&migration).Updates(map[string]interface{}{
-			"status": "failed",
+		mm.db.Model(record).Updates(map[string]interface{}{
+			"status": MigrationStatusFailed,
 			"error":  err.Error(),
 		})
-		return fmt.Errorf("执行迁移失败: %w", err)
+		if !mm.transactional() {
+			logger.Error("❌ 迁移执行失败，MySQL 中已执行的语句不会回滚，请检查后手动修复再重试",
+				zap.String("version", script.Version),
+				zap.Error(err),
+			)
+		}
+		return fmt.Errorf("执行迁移 %s 失败: %w", script.Version, err)
 	}
 
-	// 更新迁移状态为成功
-	now := time.Now()
-	if err := mm.db.Model(&migration).Updates(map[string]interface{}{
-		"status":      "success",
-		"executed_at": now,
-	}).Error; err != nil {
-		return fmt.Errorf("更新迁移状态失败: %w", err)
+	logger.Info("✅ 迁移执行成功",
+		zap.String("version", script.Version),
+		zap.String("name", script.Name),
+	)
+	return nil
+}
+
+// RollbackMigration 执行单个迁移的 down 脚本并删除迁移记录
+func (mm *MigrationManager) RollbackMigration(script MigrationScript) error {
+	logger := utils.GetLogger()
+
+	if !script.HasDown {
+		return fmt.Errorf("迁移 %s 没有 down 脚本，无法回滚", script.Version)
 	}
 
-	logger.Info("✅ 迁移执行成功",
-		zap.String("version", version),
-		zap.String("name", name),
+	record, err := mm.findRecord(script.Version)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return fmt.Errorf("迁移 %s 未执行，无需回滚", script.Version)
+	}
+
+	logger.Info("↩️  回滚迁移",
+		zap.String("version", script.Version),
+		zap.String("name", script.Name),
+		zap.Bool("transactional", mm.transactional()),
+	)
+	logger.Info("📝 SQL",
+		zap.String("sql", script.DownSQL),
+	)
+
+	if mm.transactional() {
+		err = mm.db.Transaction(func(tx *gorm.DB) error {
+			if err := execStatements(tx, script.DownSQL); err != nil {
+				return err
+			}
+			return tx.Delete(record).Error
+		})
+	} else {
+		err = mm.execOnConnection(script.DownSQL)
+		if err == nil {
+			err = mm.db.Delete(record).Error
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("回滚迁移 %s 失败: %w", script.Version, err)
+	}
+
+	logger.Info("✅ 迁移回滚成功",
+		zap.String("version", script.Version),
+		zap.String("name", script.Name),
 	)
 	return nil
 }
 
+// VerifyChecksums 校验已执行迁移的文件是否被修改
+// 历史记录没有校验和时以当前文件补齐
+func (mm *MigrationManager) VerifyChecksums(scripts []MigrationScript) error {
+	var records []Migration
+	if err := mm.db.Where("status = ?", MigrationStatusSuccess).Find(&records).Error; err != nil {
+		return fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+
+	byVersion := make(map[string]MigrationScript, len(scripts))
+	for _, script := range scripts {
+		byVersion[script.Version] = script
+	}
+
+	var modified []string
+	for _, record := range records {
+		script, ok := byVersion[record.Version]
+		if !ok {
+			continue
+		}
+		if record.Checksum == "" {
+			if err := mm.db.Model(&record).Update("checksum", script.Checksum).Error; err != nil {
+				return fmt.Errorf("补齐迁移校验和失败: %w", err)
+			}
+			continue
+		}
+		if record.Checksum != script.Checksum {
+			modified = append(modified, record.Version)
+		}
+	}
+
+	if len(modified) > 0 {
+		return fmt.Errorf("已执行的迁移文件被修改: %s（请新增迁移而不是修改已执行的脚本）", strings.Join(modified, ", "))
+	}
+	return nil
+}
+
+// Status 对比迁移脚本与迁移记录，返回每个版本的状态
+func (mm *MigrationManager) Status(scripts []MigrationScript) ([]MigrationState, error) {
+	var records []Migration
+	if err := mm.db.Order("version ASC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	recordByVersion := make(map[string]Migration, len(records))
+	for _, record := range records {
+		recordByVersion[record.Version] = record
+	}
+
+	states := make([]MigrationState, 0, len(scripts))
+	seen := make(map[string]bool, len(scripts))
+	for _, script := range scripts {
+		seen[script.Version] = true
+		state := MigrationState{
+			Version: script.Version,
+			Name:    script.Name,
+			State:   MigrationStatePending,
+			HasDown: script.HasDown,
+		}
+		if record, ok := recordByVersion[script.Version]; ok {
+			state.ExecutedAt = record.ExecutedAt
+			state.Error = record.Error
+			switch {
+			case record.Status == MigrationStatusFailed:
+				state.State = MigrationStateFailed
+			case record.Status != MigrationStatusSuccess:
+				state.State = MigrationStatePending
+			case record.Checksum != "" && record.Checksum != script.Checksum:
+				state.State = MigrationStateModified
+			default:
+				state.State = MigrationStateApplied
+			}
+		}
+		states = append(states, state)
+	}
+
+	// 已执行但脚本已不存在的迁移
+	for _, record := range records {
+		if seen[record.Version] {
+			continue
+		}
+		states = append(states, MigrationState{
+			Version:    record.Version,
+			Name:       record.Name,
+			State:      MigrationStateMissing,
+			ExecutedAt: record.ExecutedAt,
+			Error:      record.Error,
+		})
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Version < states[j].Version
+	})
+	return states, nil
+}
+
+// Up 按版本顺序执行待执行的迁移，limit 为 0 时执行全部，返回实际执行的数量
+func (mm *MigrationManager) Up(scripts []MigrationScript, limit int) (int, error) {
+	states, err := mm.Status(scripts)
+	if err != nil {
+		return 0, err
+	}
+	applied := make(map[string]bool, len(states))
+	for _, state := range states {
+		if state.State == MigrationStateApplied || state.State == MigrationStateModified {
+			applied[state.Version] = true
+		}
+	}
+
+	count := 0
+	for _, script := range scripts {
+		if applied[script.Version] {
+			continue
+		}
+		if limit > 0 && count >= limit {
+			break
+		}
+		if err := mm.ExecuteMigration(script); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Down 按版本倒序回滚最近执行的迁移，limit 小于 1 时回滚 1 个，返回实际回滚的版本
+func (mm *MigrationManager) Down(scripts []MigrationScript, limit int) ([]string, error) {
+	if limit < 1 {
+		limit = 1
+	}
+
+	var records []Migration
+	if err := mm.db.Where("status = ?", MigrationStatusSuccess).
+		Order("version DESC").
+		Limit(limit).
+		Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+
+	byVersion := make(map[string]MigrationScript, len(scripts))
+	for _, script := range scripts {
+		byVersion[script.Version] = script
+	}
+
+	var rolledBack []string
+	for _, record := range records {
+		script, ok := byVersion[record.Version]
+		if !ok {
+			return rolledBack, fmt.Errorf("迁移 %s 的脚本文件不存在，无法回滚", record.Version)
+		}
+		if err := mm.RollbackMigration(script); err != nil {
+			return rolledBack, err
+		}
+		rolledBack = append(rolledBack, record.Version)
+	}
+	return rolledBack, nil
+}
+
+// Redo 回滚最近执行的一个迁移并重新执行
+func (mm *MigrationManager) Redo(scripts []MigrationScript) (string, error) {
+	rolledBack, err := mm.Down(scripts, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(rolledBack) == 0 {
+		return "", fmt.Errorf("没有已执行的迁移")
+	}
+
+	version := rolledBack[0]
+	for _, script := range scripts {
+		if script.Version == version {
+			return version, mm.ExecuteMigration(script)
+		}
+	}
+	return version, fmt.Errorf("迁移 %s 的脚本文件不存在", version)
+}
+
 // GetMigrationHistory 获取迁移历史
 func (mm *MigrationManager) GetMigrationHistory() ([]Migration, error) {
 	var migrations []Migration
@@ -124,9 +435,15 @@ func (mm *MigrationManager) GetMigrationStatus() (map[string]interface{}, error)
 	var successCount int64
 	var failedCount int64
 
-	mm.db.Model(&Migration{}).Count(&total)
-	mm.db.Model(&Migration{}).Where("status = ?", "success").Count(&successCount)
-	mm.db.Model(&Migration{}).Where("status = ?", "failed").Count(&failedCount)
+	if err := mm.db.Model(&Migration{}).Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("查询迁移状态失败: %w", err)
+	}
+	if err := mm.db.Model(&Migration{}).Where("status = ?", MigrationStatusSuccess).Count(&successCount).Error; err != nil {
+		return nil, fmt.Errorf("查询迁移状态失败: %w", err)
+	}
+	if err := mm.db.Model(&Migration{}).Where("status = ?", MigrationStatusFailed).Count(&failedCount).Error; err != nil {
+		return nil, fmt.Errorf("查询迁移状态失败: %w", err)
+	}
 
 	return map[string]interface{}{
 		"total":   total,
@@ -1,83 +1,121 @@
 package database
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
 
+	"tgo-rtc-server/internal/config"
 	"tgo-rtc-server/internal/utils"
+	"tgo-rtc-server/migrations"
 
 	"go.uber.org/zap"
 )
 
 // MigrationScript 迁移脚本结构
 type MigrationScript struct {
-	Version string
-	Name    string
-	SQL     string
+	Version  string
+	Name     string
+	SQL      string // up 脚本
+	DownSQL  string // down 脚本（HasDown 为 true 且为空表示回滚无需执行任何语句）
+	HasDown  bool
+	Checksum string // up 脚本的 SHA-256 校验和，用于检测已执行的迁移文件是否被修改
 }
 
-// LoadMigrations 从 migrations 目录加载所有迁移脚本
-func LoadMigrations() ([]MigrationScript, error) {
-	// 获取 migrations 目录路径
-	migrationsDir := "migrations"
+var (
+	// 日期+序号格式: 20251027-01.up.sql / 20251027-01.down.sql / 20251027-01.sql
+	dateSeqFileRe = regexp.MustCompile(`^(\d{8})-(\d{2})(?:\.(up|down))?$`)
+	// 版本号+描述格式: 001_create_rtc_room_table.up.sql
+	versionDescFileRe = regexp.MustCompile(`^(\d+)_(.+?)(?:\.(up|down))?$`)
+)
 
-	// 如果目录不存在，尝试从上级目录查找
-	if _, err := os.Stat(migrationsDir); os.IsNotExist(err) {
-		// 尝试从项目根目录查找
-		wd, err := os.Getwd()
-		if err != nil {
-			return nil, fmt.Errorf("获取工作目录失败: %w", err)
-		}
-		migrationsDir = filepath.Join(wd, "migrations")
+// migrationSource 返回迁移脚本来源及当前方言目录的描述（用于日志）
+// 配置 MIGRATIONS_DIR 时从该目录读取（便于开发时修改脚本无需重新编译），否则使用编译进二进制的脚本
+func migrationSource(cfg *config.Config, driverDir string) (fs.FS, string) {
+	if cfg.MigrationsDir != "" {
+		return os.DirFS(cfg.MigrationsDir), filepath.Join(cfg.MigrationsDir, driverDir)
 	}
+	return migrations.FS, "embedded:migrations/" + driverDir
+}
+
+// LoadMigrations 加载当前数据库方言（<driver>/ 子目录）的所有迁移脚本
+func LoadMigrations(cfg *config.Config) ([]MigrationScript, error) {
+	driverDir := migrationsDirFor(cfg.DBDriver)
+	fsys, migrationsDir := migrationSource(cfg, driverDir)
 
-	// 读取 migrations 目录
-	entries, err := os.ReadDir(migrationsDir)
+	// 读取方言目录
+	entries, err := fs.ReadDir(fsys, driverDir)
 	if err != nil {
-		return nil, fmt.Errorf("读取 migrations 目录失败: %w", err)
+		return nil, fmt.Errorf("读取 migrations 目录 %s 失败: %w", migrationsDir, err)
 	}
 
-	var scripts []MigrationScript
+	byVersion := make(map[string]*MigrationScript)
+	logger := utils.GetLogger()
 
-	// 遍历所有 .sql 文件
+	// 遍历所有 .sql 文件，按版本号合并 up/down 脚本
 	for _, entry := range entries {
 		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
 			continue
 		}
 
-		filePath := filepath.Join(migrationsDir, entry.Name())
+		filePath := path.Join(driverDir, entry.Name())
+
+		// 解析文件名获取版本号、名称和方向
+		version, name, direction := parseMigrationFileName(entry.Name())
+		if version == "" {
+			continue
+		}
 
 		// 读取文件内容
-		content, err := os.ReadFile(filePath)
+		content, err := fs.ReadFile(fsys, filePath)
 		if err != nil {
 			return nil, fmt.Errorf("读取文件 %s 失败: %w", filePath, err)
 		}
 
-		// 解析文件名获取版本号和名称
-		version, name := parseMigrationFileName(entry.Name())
-		if version == "" {
+		// 提取 SQL 语句（去除注释和空行）
+		sql := extractSQL(string(content))
+
+		script, ok := byVersion[version]
+		if !ok {
+			script = &MigrationScript{Version: version, Name: name}
+			byVersion[version] = script
+		}
+
+		if direction == "down" {
+			script.DownSQL = sql
+			script.HasDown = true
 			continue
 		}
 
-		// 提取 SQL 语句（去除注释和空行）
-		sql := extractSQL(string(content))
+		if script.SQL != "" {
+			return nil, fmt.Errorf("迁移版本 %s 存在多个 up 脚本", version)
+		}
 		if sql == "" {
-			logger := utils.GetLogger()
 			logger.Warn("⚠️  警告: 文件中没有找到有效的 SQL 语句",
 				zap.String("file", entry.Name()),
 			)
 			continue
 		}
+		script.SQL = sql
+		script.Checksum = checksumSQL(sql)
+	}
 
-		scripts = append(scripts, MigrationScript{
-			Version: version,
-			Name:    name,
-			SQL:     sql,
-		})
+	var scripts []MigrationScript
+	for _, script := range byVersion {
+		if script.SQL == "" {
+			if script.HasDown {
+				return nil, fmt.Errorf("迁移版本 %s 只有 down 脚本，缺少 up 脚本", script.Version)
+			}
+			continue
+		}
+		scripts = append(scripts, *script)
 	}
 
 	// 按版本号排序
@@ -86,47 +124,53 @@ func LoadMigrations() ([]MigrationScript, error) {
 	})
 
 	if len(scripts) == 0 {
-		return nil, fmt.Errorf("在 %s 目录中没有找到任何迁移脚本", migrationsDir)
+		return nil, fmt.Errorf("在 %s 中没有找到任何迁移脚本", migrationsDir)
 	}
 
-	logger := utils.GetLogger()
 	logger.Info("✅ 成功加载迁移脚本",
+		zap.String("source", migrationsDir),
 		zap.Int("count", len(scripts)),
 	)
 	return scripts, nil
 }
 
 // parseMigrationFileName 解析迁移文件名
-// 支持两种格式:
-// 1. 日期+序号格式: 20251027-01.sql (推荐)
-// 2. 版本号+描述格式: 001_create_rtc_room_table.sql (兼容)
-func parseMigrationFileName(filename string) (version, name string) {
+// 支持两种格式，均可带 .up / .down 后缀（不带后缀视为 up 脚本）:
+// 1. 日期+序号格式: 20251027-01.up.sql (推荐)
+// 2. 版本号+描述格式: 001_create_rtc_room_table.up.sql (兼容)
+func parseMigrationFileName(filename string) (version, name, direction string) {
 	// 移除 .sql 扩展名
 	nameWithoutExt := strings.TrimSuffix(filename, ".sql")
 
 	// 尝试匹配日期+序号格式: 20251027-01
-	dateSeqRe := regexp.MustCompile(`^(\d{8})-(\d{2})$`)
-	if matches := dateSeqRe.FindStringSubmatch(nameWithoutExt); len(matches) == 3 {
+	if matches := dateSeqFileRe.FindStringSubmatch(nameWithoutExt); len(matches) == 4 {
 		date := matches[1] // 20251027
 		seq := matches[2]  // 01
 		version = date + "-" + seq
 		name = "Migration " + date + "-" + seq
-		return version, name
+		return version, name, directionOf(matches[3])
 	}
 
 	// 尝试匹配版本号+描述格式: 001_create_rtc_room_table
-	versionDescRe := regexp.MustCompile(`^(\d+)_(.+)$`)
-	if matches := versionDescRe.FindStringSubmatch(nameWithoutExt); len(matches) == 3 {
+	if matches := versionDescFileRe.FindStringSubmatch(nameWithoutExt); len(matches) == 4 {
 		version = matches[1]
 		description := matches[2]
 
 		// 将下划线替换为空格
 		description = strings.ReplaceAll(description, "_", " ")
 
-		return version, description
+		return version, description, directionOf(matches[3])
 	}
 
-	return "", ""
+	return "", "", ""
+}
+
+// directionOf 文件名未带方向后缀时视为 up 脚本
+func directionOf(suffix string) string {
+	if suffix == "" {
+		return "up"
+	}
+	return suffix
 }
 
 // extractSQL 从文件内容中提取 SQL 语句
@@ -155,19 +199,92 @@ func extractSQL(content string) string {
 	return sql
 }
 
-// RunMigrations 运行所有迁移
-func RunMigrations(mm *MigrationManager) error {
+// checksumSQL 计算 SQL 的校验和（基于去除注释和空行后的内容，修改注释不会导致校验失败）
+func checksumSQL(sql string) string {
+	sum := sha256.Sum256([]byte(sql))
+	return hex.EncodeToString(sum[:])
+}
+
+// splitStatements 将脚本按分号拆分为单条语句
+// 跳过引号、反引号、PostgreSQL $$ 块及注释中的分号；MySQL 连接未开启 multiStatements，必须逐条执行
+func splitStatements(sql string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		quote      byte // 当前所在的引号字符，0 表示不在引号内
+		dollar     bool // 是否在 $$ 块内
+	)
+
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		switch {
+		case quote != 0:
+			current.WriteByte(c)
+			if c == '\\' && quote != '`' && i+1 < len(sql) {
+				i++
+				current.WriteByte(sql[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case dollar:
+			current.WriteByte(c)
+			if c == '$' && i+1 < len(sql) && sql[i+1] == '$' {
+				i++
+				current.WriteByte('$')
+				dollar = false
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			current.WriteByte(c)
+		case c == '$' && i+1 < len(sql) && sql[i+1] == '$':
+			dollar = true
+			i++
+			current.WriteString("$$")
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			// 行内注释，跳到行尾
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			// 块注释
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 3
+			}
+			current.WriteByte(' ')
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
+
+// RunMigrations 校验已执行迁移的校验和后运行所有待执行的迁移
+func RunMigrations(mm *MigrationManager, cfg *config.Config) error {
 	// 加载迁移脚本
-	scripts, err := LoadMigrations()
+	scripts, err := LoadMigrations(cfg)
 	if err != nil {
 		return fmt.Errorf("加载迁移脚本失败: %w", err)
 	}
 
-	// 执行每个迁移脚本
-	for _, script := range scripts {
-		if err := mm.ExecuteMigration(script.Version, script.Name, script.SQL); err != nil {
-			return err
-		}
+	if err := mm.VerifyChecksums(scripts); err != nil {
+		return err
 	}
-	return nil
+
+	_, err = mm.Up(scripts, 0)
+	return err
 }
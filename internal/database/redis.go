@@ -3,21 +3,26 @@ package database
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"tgo-rtc-server/internal/config"
+	"tgo-rtc-server/internal/tracing"
 	"tgo-rtc-server/internal/utils"
 
 	"github.com/go-redis/redis/v8"
 )
 
 // InitRedis 初始化 Redis 连接
+// 仅支持单节点 Redis（含主从/哨兵）：通话索引、频率限制等 Lua 脚本会访问未在 KEYS 中声明的 key，不能运行在 Redis Cluster 上
 func InitRedis(cfg *config.Config) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
 		Password: cfg.RedisPassword,
 		DB:       cfg.RedisDB,
 	})
+	// 注册链路追踪 Hook
+	client.AddHook(tracing.NewRedisHook())
 
 	// 测试连接
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -26,6 +31,11 @@ func InitRedis(cfg *config.Config) (*redis.Client, error) {
 	if err := client.Ping(ctx).Err(); err != nil {
 		return nil, fmt.Errorf("Redis 连接失败: %w", err)
 	}
+	// 部分托管 Redis 禁用了 INFO 命令，查询失败时不做检查
+	if info, err := client.Info(ctx, "cluster").Result(); err == nil && strings.Contains(info, "cluster_enabled:1") {
+		client.Close()
+		return nil, fmt.Errorf("不支持 Redis Cluster，请使用单节点 Redis（可配合主从/哨兵）")
+	}
 
 	logger := utils.GetLogger()
 	logger.Info("✅ Redis 连接成功")
@@ -0,0 +1,209 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"tgo-rtc-server/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// schemaModels 参与结构一致性检查的模型
+// 表结构以迁移脚本为唯一来源，模型的 gorm 标签（字段、索引名、索引列、唯一性）需与迁移结果保持一致
+var schemaModels = []interface{}{
+	&models.Room{},
+	&models.ChannelLock{},
+	&models.Participant{},
+	&models.RoomSession{},
+	&models.RoomSessionParticipant{},
+	&models.InviteLink{},
+	&models.DeviceToken{},
+	&models.UserBlock{},
+	&models.CallPolicyLog{},
+	&models.UserPresence{},
+	&models.BusinessWebhookLog{},
+}
+
+// 结构差异类型
+const (
+	SchemaDiffMissingTable  = "missing_table"  // 模型对应的表不存在
+	SchemaDiffMissingColumn = "missing_column" // 模型字段在表中不存在
+	SchemaDiffExtraColumn   = "extra_column"   // 表中存在模型未声明的字段
+	SchemaDiffMissingIndex  = "missing_index"  // 模型声明的索引在表中不存在
+	SchemaDiffExtraIndex    = "extra_index"    // 表中存在模型未声明的索引
+	SchemaDiffIndexMismatch = "index_mismatch" // 同名索引的列或唯一性不一致
+)
+
+// SchemaDiff 模型与数据库结构的一处差异
+type SchemaDiff struct {
+	Table  string
+	Kind   string
+	Name   string
+	Detail string
+}
+
+// schemaIndex 索引的可比较形式
+type schemaIndex struct {
+	Columns []string
+	Unique  bool
+}
+
+func (i schemaIndex) String() string {
+	if i.Unique {
+		return "UNIQUE (" + strings.Join(i.Columns, ", ") + ")"
+	}
+	return "(" + strings.Join(i.Columns, ", ") + ")"
+}
+
+// CheckSchemaDrift 对比模型定义与数据库中已迁移的表结构，返回所有差异
+// 只比较字段是否存在及索引（名称、列、唯一性），字段类型在各方言间表示不同，不做比较
+func CheckSchemaDrift(db *gorm.DB) ([]SchemaDiff, error) {
+	var diffs []SchemaDiff
+	for _, model := range schemaModels {
+		modelDiffs, err := checkModelSchema(db, model)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, modelDiffs...)
+	}
+	return diffs, nil
+}
+
+// checkModelSchema 检查单个模型
+func checkModelSchema(db *gorm.DB, model interface{}) ([]SchemaDiff, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, fmt.Errorf("解析模型失败: %w", err)
+	}
+	table := stmt.Schema.Table
+
+	migrator := db.Migrator()
+	if !migrator.HasTable(model) {
+		return []SchemaDiff{{Table: table, Kind: SchemaDiffMissingTable, Name: table}}, nil
+	}
+
+	var diffs []SchemaDiff
+
+	// 字段
+	columnTypes, err := migrator.ColumnTypes(model)
+	if err != nil {
+		return nil, fmt.Errorf("查询表 %s 字段失败: %w", table, err)
+	}
+	dbColumns := make(map[string]bool, len(columnTypes))
+	for _, columnType := range columnTypes {
+		dbColumns[columnType.Name()] = true
+	}
+	modelColumns := make(map[string]bool, len(stmt.Schema.DBNames))
+	for _, name := range stmt.Schema.DBNames {
+		modelColumns[name] = true
+		if !dbColumns[name] {
+			diffs = append(diffs, SchemaDiff{Table: table, Kind: SchemaDiffMissingColumn, Name: name})
+		}
+	}
+	for _, columnType := range columnTypes {
+		if !modelColumns[columnType.Name()] {
+			diffs = append(diffs, SchemaDiff{Table: table, Kind: SchemaDiffExtraColumn, Name: columnType.Name()})
+		}
+	}
+
+	// 索引
+	modelIndexes := modelSchemaIndexes(stmt.Schema)
+	dbIndexes, err := databaseIndexes(db, model, table)
+	if err != nil {
+		return nil, fmt.Errorf("查询表 %s 索引失败: %w", table, err)
+	}
+	for _, name := range sortedIndexNames(modelIndexes) {
+		want := modelIndexes[name]
+		got, ok := dbIndexes[name]
+		switch {
+		case !ok:
+			diffs = append(diffs, SchemaDiff{Table: table, Kind: SchemaDiffMissingIndex, Name: name, Detail: want.String()})
+		case got.Unique != want.Unique || strings.Join(got.Columns, ",") != strings.Join(want.Columns, ","):
+			diffs = append(diffs, SchemaDiff{
+				Table:  table,
+				Kind:   SchemaDiffIndexMismatch,
+				Name:   name,
+				Detail: fmt.Sprintf("模型 %s，数据库 %s", want, got),
+			})
+		}
+	}
+	for _, name := range sortedIndexNames(dbIndexes) {
+		if _, ok := modelIndexes[name]; !ok {
+			diffs = append(diffs, SchemaDiff{Table: table, Kind: SchemaDiffExtraIndex, Name: name, Detail: dbIndexes[name].String()})
+		}
+	}
+	return diffs, nil
+}
+
+// modelSchemaIndexes 从模型标签解析索引
+func modelSchemaIndexes(s *schema.Schema) map[string]schemaIndex {
+	indexes := make(map[string]schemaIndex)
+	for name, index := range s.ParseIndexes() {
+		columns := make([]string, 0, len(index.Fields))
+		for _, field := range index.Fields {
+			columns = append(columns, field.DBName)
+		}
+		indexes[name] = schemaIndex{Columns: columns, Unique: index.Class == "UNIQUE"}
+	}
+	return indexes
+}
+
+// databaseIndexes 查询表中的索引（不含主键）
+// PostgreSQL、SQLite 的索引名在库内唯一，迁移脚本以 {表名}_ 为前缀，比较前去掉前缀
+func databaseIndexes(db *gorm.DB, model interface{}, table string) (map[string]schemaIndex, error) {
+	indexes := make(map[string]schemaIndex)
+	dialect := db.Dialector.Name()
+
+	if dialect == DriverSQLite {
+		// 当前 SQLite 驱动未实现 GetIndexes，直接读取 PRAGMA
+		var list []struct {
+			Name   string
+			Unique bool
+			Origin string
+		}
+		if err := db.Raw("SELECT name, `unique`, origin FROM pragma_index_list(?)", table).Scan(&list).Error; err != nil {
+			return nil, err
+		}
+		for _, item := range list {
+			if item.Origin == "pk" {
+				continue
+			}
+			var columns []string
+			if err := db.Raw("SELECT name FROM pragma_index_info(?) ORDER BY seqno", item.Name).Scan(&columns).Error; err != nil {
+				return nil, err
+			}
+			indexes[strings.TrimPrefix(item.Name, table+"_")] = schemaIndex{Columns: columns, Unique: item.Unique}
+		}
+		return indexes, nil
+	}
+
+	dbIndexes, err := db.Migrator().GetIndexes(model)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range dbIndexes {
+		if primary, ok := index.PrimaryKey(); ok && primary {
+			continue
+		}
+		name := index.Name()
+		if dialect != DriverMySQL {
+			name = strings.TrimPrefix(name, table+"_")
+		}
+		unique, _ := index.Unique()
+		indexes[name] = schemaIndex{Columns: index.Columns(), Unique: unique}
+	}
+	return indexes, nil
+}
+
+// sortedIndexNames 返回排序后的索引名，保证输出顺序稳定
+func sortedIndexNames(indexes map[string]schemaIndex) []string {
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
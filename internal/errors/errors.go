@@ -12,6 +12,8 @@ type BusinessError struct {
 	Key     i18n.MessageKey
 	Args    []interface{}
 	Code    int // 业务错误码，用于响应体中的 code 字段
+
+	RetryAfter int // 频率限制错误的建议重试等待时间（秒），用于 Retry-After 响应头
 }
 
 func (e *BusinessError) Error() string {
@@ -52,6 +54,17 @@ func NewConflictError(key i18n.MessageKey, args ...interface{}) *BusinessError {
 	}
 }
 
+// NewRateLimitError 创建频率限制错误（code 429，HTTP 状态码 429 并携带 Retry-After）
+// retryAfter 会追加到翻译参数末尾
+func NewRateLimitError(retryAfter int, key i18n.MessageKey, args ...interface{}) *BusinessError {
+	return &BusinessError{
+		Key:        key,
+		Args:       append(args, retryAfter),
+		Code:       http.StatusTooManyRequests,
+		RetryAfter: retryAfter,
+	}
+}
+
 // GetLocalizedMessage 获取本地化的错误消息
 func (e *BusinessError) GetLocalizedMessage(lang string) string {
 	if e.Key != "" {
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"tgo-rtc-server/internal/errors"
+	"tgo-rtc-server/internal/middleware"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/service"
+	"tgo-rtc-server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// BlockHandler 用户屏蔽列表处理器
+type BlockHandler struct {
+	callPolicyService *service.CallPolicyService
+}
+
+// NewBlockHandler 创建用户屏蔽列表处理器
+func NewBlockHandler(callPolicyService *service.CallPolicyService) *BlockHandler {
+	return &BlockHandler{
+		callPolicyService: callPolicyService,
+	}
+}
+
+// BlockUser 屏蔽用户（被屏蔽的用户呼叫/邀请该用户时静默拦截）
+// POST /api/v1/blocks
+func (bh *BlockHandler) BlockUser(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	logger := utils.ContextLogger(c)
+
+	var req models.BlockUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("屏蔽用户参数绑定失败",
+			zap.Error(err),
+			zap.String("language", lang),
+		)
+		utils.RespondWithBindError(c)
+		return
+	}
+
+	if err := bh.callPolicyService.BlockUser(utils.RequestContext(c), &req); err != nil {
+		if businessErr, ok := err.(*errors.BusinessError); ok {
+			logger.Warn("屏蔽用户业务错误",
+				zap.String("error_key", string(businessErr.Key)),
+				zap.String("error_message", businessErr.GetLocalizedMessage(lang)),
+				zap.String("uid", req.UID),
+				zap.String("blocked_uid", req.BlockedUID),
+				zap.String("language", lang),
+			)
+		} else {
+			logger.Error("屏蔽用户系统错误",
+				zap.Error(err),
+				zap.String("uid", req.UID),
+				zap.String("blocked_uid", req.BlockedUID),
+				zap.String("language", lang),
+			)
+		}
+		utils.RespondWithBusinessError(c, err)
+		return
+	}
+
+	utils.RespondWithData(c, nil)
+}
+
+// UnblockUser 取消屏蔽用户
+// DELETE /api/v1/blocks
+func (bh *BlockHandler) UnblockUser(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	logger := utils.ContextLogger(c)
+
+	var req models.BlockUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("取消屏蔽用户参数绑定失败",
+			zap.Error(err),
+			zap.String("language", lang),
+		)
+		utils.RespondWithBindError(c)
+		return
+	}
+
+	if err := bh.callPolicyService.UnblockUser(utils.RequestContext(c), &req); err != nil {
+		logger.Error("取消屏蔽用户失败",
+			zap.Error(err),
+			zap.String("uid", req.UID),
+			zap.String("blocked_uid", req.BlockedUID),
+			zap.String("language", lang),
+		)
+		utils.RespondWithBusinessError(c, err)
+		return
+	}
+
+	utils.RespondWithData(c, nil)
+}
+
+// GetBlockList 获取用户的屏蔽列表
+// GET /api/v1/blocks?uid=xxx
+func (bh *BlockHandler) GetBlockList(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	logger := utils.ContextLogger(c)
+
+	uid := c.Query("uid")
+	if uid == "" {
+		logger.Error("获取屏蔽列表参数 uid 缺失",
+			zap.String("language", lang),
+		)
+		utils.RespondWithBindError(c)
+		return
+	}
+
+	resp, err := bh.callPolicyService.GetBlockList(utils.RequestContext(c), uid)
+	if err != nil {
+		logger.Error("获取屏蔽列表失败",
+			zap.Error(err),
+			zap.String("uid", uid),
+			zap.String("language", lang),
+		)
+		utils.RespondWithBusinessError(c, err)
+		return
+	}
+
+	utils.RespondWithData(c, resp)
+}
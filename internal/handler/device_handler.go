@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"tgo-rtc-server/internal/errors"
+	"tgo-rtc-server/internal/middleware"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/service"
+	"tgo-rtc-server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DeviceHandler 设备推送 Token 处理器
+type DeviceHandler struct {
+	pushService *service.PushService
+}
+
+// NewDeviceHandler 创建设备推送 Token 处理器
+func NewDeviceHandler(pushService *service.PushService) *DeviceHandler {
+	return &DeviceHandler{
+		pushService: pushService,
+	}
+}
+
+// RegisterDeviceToken 注册设备推送 Token
+// POST /api/v1/devices/token
+func (dh *DeviceHandler) RegisterDeviceToken(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	logger := utils.ContextLogger(c)
+
+	var req models.RegisterDeviceTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("注册设备推送 Token 参数绑定失败",
+			zap.Error(err),
+			zap.String("language", lang),
+		)
+		utils.RespondWithBindError(c)
+		return
+	}
+
+	if err := dh.pushService.RegisterDeviceToken(utils.RequestContext(c), &req); err != nil {
+		if businessErr, ok := err.(*errors.BusinessError); ok {
+			logger.Warn("注册设备推送 Token 业务错误",
+				zap.String("error_key", string(businessErr.Key)),
+				zap.String("error_message", businessErr.GetLocalizedMessage(lang)),
+				zap.String("uid", req.UID),
+				zap.String("device_type", req.DeviceType),
+				zap.String("provider", req.Provider),
+				zap.String("language", lang),
+			)
+		} else {
+			logger.Error("注册设备推送 Token 系统错误",
+				zap.Error(err),
+				zap.String("uid", req.UID),
+				zap.String("device_type", req.DeviceType),
+				zap.String("language", lang),
+			)
+		}
+		utils.RespondWithBusinessError(c, err)
+		return
+	}
+
+	utils.RespondWithData(c, nil)
+}
+
+// UnregisterDeviceToken 注销设备推送 Token
+// DELETE /api/v1/devices/token
+func (dh *DeviceHandler) UnregisterDeviceToken(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	logger := utils.ContextLogger(c)
+
+	var req models.UnregisterDeviceTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("注销设备推送 Token 参数绑定失败",
+			zap.Error(err),
+			zap.String("language", lang),
+		)
+		utils.RespondWithBindError(c)
+		return
+	}
+
+	if err := dh.pushService.UnregisterDeviceToken(utils.RequestContext(c), &req); err != nil {
+		logger.Error("注销设备推送 Token 失败",
+			zap.Error(err),
+			zap.String("uid", req.UID),
+			zap.String("device_type", req.DeviceType),
+			zap.String("language", lang),
+		)
+		utils.RespondWithBusinessError(c, err)
+		return
+	}
+
+	utils.RespondWithData(c, nil)
+}
@@ -1,14 +1,37 @@
 package handler
 
 import (
+	"net/http"
 	"path/filepath"
 
+	"tgo-rtc-server/docs"
+	"tgo-rtc-server/internal/config"
+
 	"github.com/gin-gonic/gin"
 )
 
-// GetSwaggerJSON 提供 Swagger JSON 文件
-func GetSwaggerJSON(c *gin.Context) {
-	swaggerPath := filepath.Join("docs", "swagger.json")
-	c.File(swaggerPath)
+// DocsHandler Swagger 文档处理器
+type DocsHandler struct {
+	docsDir string
+}
+
+// NewDocsHandler 创建 Swagger 文档处理器
+func NewDocsHandler(cfg *config.Config) *DocsHandler {
+	return &DocsHandler{docsDir: cfg.DocsDir}
 }
 
+// GetSwaggerJSON 提供 Swagger JSON 文件
+// 默认使用编译进二进制的文件；配置 DOCS_DIR 时从该目录读取，便于开发时修改后即时生效
+func (h *DocsHandler) GetSwaggerJSON(c *gin.Context) {
+	if h.docsDir != "" {
+		c.File(filepath.Join(h.docsDir, "swagger.json"))
+		return
+	}
+
+	data, err := docs.FS.ReadFile("swagger.json")
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"tgo-rtc-server/internal/errors"
+	"tgo-rtc-server/internal/i18n"
+	"tgo-rtc-server/internal/middleware"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/service"
+	"tgo-rtc-server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// EventStreamHandler 客户端实时事件流处理器（SSE）
+type EventStreamHandler struct {
+	realtimeService *service.RealtimeService
+	heartbeat       time.Duration
+}
+
+// NewEventStreamHandler 创建客户端实时事件流处理器
+func NewEventStreamHandler(realtimeService *service.RealtimeService, heartbeatSeconds int) *EventStreamHandler {
+	return &EventStreamHandler{
+		realtimeService: realtimeService,
+		heartbeat:       time.Duration(heartbeatSeconds) * time.Second,
+	}
+}
+
+// IssueToken 为用户签发事件流 Token（由业务服务端调用后下发给客户端）
+// POST /api/v1/events/token
+// 需携带 X-Server-Key 请求头（EVENT_STREAM_ISSUER_KEY），由 ServerKeyAuthMiddleware 校验
+func (eh *EventStreamHandler) IssueToken(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	logger := utils.ContextLogger(c)
+
+	var req models.EventStreamTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("签发事件流 Token 参数绑定失败",
+			zap.Error(err),
+			zap.String("language", lang),
+		)
+		utils.RespondWithBindError(c)
+		return
+	}
+
+	token, expiresAt, err := eh.realtimeService.IssueToken(req.UID, req.ExpiresIn)
+	if err != nil {
+		logger.Error("签发事件流 Token 失败",
+			zap.Error(err),
+			zap.String("uid", req.UID),
+		)
+		utils.RespondWithBusinessError(c, errors.NewBusinessErrorWithKey(i18n.EventStreamTokenFailed, err.Error()))
+		return
+	}
+
+	utils.RespondWithData(c, &models.EventStreamTokenResp{
+		Token:     token,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// Stream 客户端订阅自己的通话事件（Server-Sent Events）
+// GET /api/v1/events/stream?token=xxx
+// 也可以通过 Authorization: Bearer xxx 传递 Token
+func (eh *EventStreamHandler) Stream(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	logger := utils.ContextLogger(c)
+
+	token := c.Query("token")
+	if token == "" {
+		token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	}
+	uid, err := eh.realtimeService.VerifyToken(token)
+	if err != nil {
+		logger.Warn("事件流 Token 校验失败",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		utils.RespondUnauthorized(c, i18n.Translate(lang, i18n.EventStreamTokenInvalid))
+		return
+	}
+
+	sub := eh.realtimeService.Subscribe(uid)
+	defer eh.realtimeService.Unsubscribe(sub)
+
+	logger.Info("客户端订阅事件流",
+		zap.String("uid", uid),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭 Nginx 缓冲
+
+	// 连接建立后立即发送 ready 事件，客户端可在此之后调用 /rooms/sync 补齐断线期间的状态
+	fmt.Fprintf(c.Writer, "event: ready\ndata: {\"uid\":%q}\n\n", uid)
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(eh.heartbeat)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-eh.realtimeService.Done():
+			// 服务正在关闭，断开连接让客户端重连到其他实例
+			return false
+		case event := <-sub.Events:
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+			return true
+		case <-ticker.C:
+			// SSE 注释行作为心跳，避免代理断开空闲连接
+			fmt.Fprint(w, ": ping\n\n")
+			return true
+		}
+	})
+
+	logger.Info("客户端事件流断开",
+		zap.String("uid", uid),
+	)
+}
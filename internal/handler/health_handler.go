@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/service"
+	"tgo-rtc-server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// HealthHandler 存活/就绪检查处理器
+type HealthHandler struct {
+	healthService *service.HealthService
+}
+
+// NewHealthHandler 创建存活/就绪检查处理器
+func NewHealthHandler(healthService *service.HealthService) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// Livez 存活检查，进程能处理请求即返回 200（不检查外部依赖，避免依赖故障导致 Pod 被反复重启）
+// GET /livez
+func (hh *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": models.HealthStatusOK,
+	})
+}
+
+// Readyz 就绪检查，关键依赖异常时返回 503，使负载均衡摘除该实例
+// GET /readyz
+func (hh *HealthHandler) Readyz(c *gin.Context) {
+	report := hh.healthService.CheckReadiness(c.Request.Context())
+	if report.Status == models.HealthStatusUnhealthy {
+		logger := utils.ContextLogger(c)
+		failed := make([]string, 0)
+		for name, check := range report.Checks {
+			if check.Status == models.HealthStatusUnhealthy {
+				failed = append(failed, name)
+			}
+		}
+		logger.Warn("就绪检查失败",
+			zap.Strings("failed_checks", failed),
+		)
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"tgo-rtc-server/internal/errors"
+	"tgo-rtc-server/internal/middleware"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/service"
+	"tgo-rtc-server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// InviteLinkHandler 邀请链接处理器
+type InviteLinkHandler struct {
+	inviteLinkService *service.InviteLinkService
+}
+
+// NewInviteLinkHandler 创建邀请链接处理器
+func NewInviteLinkHandler(inviteLinkService *service.InviteLinkService) *InviteLinkHandler {
+	return &InviteLinkHandler{
+		inviteLinkService: inviteLinkService,
+	}
+}
+
+// CreateInviteLink 创建房间邀请链接
+// POST /api/v1/rooms/:room_id/invite-links
+func (ih *InviteLinkHandler) CreateInviteLink(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	logger := utils.ContextLogger(c)
+	roomID := c.Param("room_id")
+
+	var req models.CreateInviteLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("创建邀请链接参数绑定失败",
+			zap.Error(err),
+			zap.String("room_id", roomID),
+			zap.String("language", lang),
+		)
+		utils.RespondWithBindError(c)
+		return
+	}
+	// 从 URL 参数中获取 room_id
+	req.RoomID = roomID
+
+	resp, err := ih.inviteLinkService.CreateInviteLink(utils.RequestContext(c), &req)
+	if err != nil {
+		if businessErr, ok := err.(*errors.BusinessError); ok {
+			logger.Warn("创建邀请链接业务错误",
+				zap.String("error_key", string(businessErr.Key)),
+				zap.String("error_message", businessErr.GetLocalizedMessage(lang)),
+				zap.String("room_id", req.RoomID),
+				zap.String("language", lang),
+			)
+		} else {
+			logger.Error("创建邀请链接系统错误",
+				zap.Error(err),
+				zap.String("room_id", req.RoomID),
+				zap.String("language", lang),
+			)
+		}
+		utils.RespondWithBusinessError(c, err)
+		return
+	}
+
+	utils.RespondWithData(c, resp)
+}
+
+// RedeemInviteLink 使用邀请码加入房间
+// POST /api/v1/invite-links/redeem
+func (ih *InviteLinkHandler) RedeemInviteLink(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	logger := utils.ContextLogger(c)
+
+	var req models.RedeemInviteLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("使用邀请码参数绑定失败",
+			zap.Error(err),
+			zap.String("language", lang),
+		)
+		utils.RespondWithBindError(c)
+		return
+	}
+	if req.DeviceType == "" {
+		logger.Error("使用邀请码参数 device_type 缺失",
+			zap.String("language", lang),
+		)
+		utils.RespondWithBindError(c)
+		return
+	}
+
+	resp, err := ih.inviteLinkService.RedeemInviteLink(utils.RequestContext(c), &req)
+	if err != nil {
+		if businessErr, ok := err.(*errors.BusinessError); ok {
+			logger.Warn("使用邀请码业务错误",
+				zap.String("error_key", string(businessErr.Key)),
+				zap.String("error_message", businessErr.GetLocalizedMessage(lang)),
+				zap.String("language", lang),
+			)
+		} else {
+			logger.Error("使用邀请码系统错误",
+				zap.Error(err),
+				zap.String("language", lang),
+			)
+		}
+		utils.RespondWithBusinessError(c, err)
+		return
+	}
+
+	utils.RespondWithData(c, resp)
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+
 	"tgo-rtc-server/internal/errors"
 	"tgo-rtc-server/internal/middleware"
 	"tgo-rtc-server/internal/models"
@@ -33,7 +35,7 @@ func (ph *ParticipantHandler) SetBusinessWebhookService(bws *service.BusinessWeb
 // POST /api/v1/rooms/:room_id/join
 func (ph *ParticipantHandler) JoinRoom(c *gin.Context) {
 	lang := middleware.GetLanguageFromContext(c)
-	logger := utils.GetLogger()
+	logger := utils.ContextLogger(c)
 	roomID := c.Param("room_id")
 
 	var req models.JoinRoomRequest
@@ -56,8 +58,9 @@ func (ph *ParticipantHandler) JoinRoom(c *gin.Context) {
 	}
 	// 从 URL 参数中获取 room_id
 	req.RoomID = roomID
+	req.IdempotencyKey = c.GetHeader(models.IdempotencyKeyHeader)
 
-	resp, err := ph.participantService.JoinRoom(&req)
+	resp, err := ph.participantService.JoinRoom(utils.RequestContext(c), &req)
 	if err != nil {
 		if businessErr, ok := err.(*errors.BusinessError); ok {
 			logger.Warn("加入房间业务错误",
@@ -97,7 +100,7 @@ func (ph *ParticipantHandler) JoinRoom(c *gin.Context) {
 // POST /api/v1/rooms/:room_id/leave
 func (ph *ParticipantHandler) LeaveRoom(c *gin.Context) {
 	lang := middleware.GetLanguageFromContext(c)
-	logger := utils.GetLogger()
+	logger := utils.ContextLogger(c)
 	roomID := c.Param("room_id")
 
 	var req models.LeaveRoomRequest
@@ -114,7 +117,7 @@ func (ph *ParticipantHandler) LeaveRoom(c *gin.Context) {
 	// 从 URL 参数中获取 room_id
 	req.RoomID = roomID
 
-	if err := ph.participantService.LeaveRoom(&req); err != nil {
+	if err := ph.participantService.LeaveRoom(utils.RequestContext(c), &req); err != nil {
 		if businessErr, ok := err.(*errors.BusinessError); ok {
 			logger.Warn("离开房间业务错误",
 				zap.String("error_key", string(businessErr.Key)),
@@ -141,7 +144,7 @@ func (ph *ParticipantHandler) LeaveRoom(c *gin.Context) {
 // POST /api/rooms/:room_id/invite
 func (ph *ParticipantHandler) InviteParticipants(c *gin.Context) {
 	lang := middleware.GetLanguageFromContext(c)
-	logger := utils.GetLogger()
+	logger := utils.ContextLogger(c)
 	roomID := c.Param("room_id")
 
 	var req models.InviteParticipantRequest
@@ -156,8 +159,10 @@ func (ph *ParticipantHandler) InviteParticipants(c *gin.Context) {
 	}
 
 	req.RoomID = roomID
+	req.IdempotencyKey = c.GetHeader(models.IdempotencyKeyHeader)
 
-	if err := ph.participantService.InviteParticipants(&req); err != nil {
+	resp, err := ph.participantService.InviteParticipants(utils.RequestContext(c), &req)
+	if err != nil {
 		if businessErr, ok := err.(*errors.BusinessError); ok {
 			logger.Warn("邀请参与者业务错误",
 				zap.String("error_key", string(businessErr.Key)),
@@ -178,14 +183,14 @@ func (ph *ParticipantHandler) InviteParticipants(c *gin.Context) {
 		return
 	}
 
-	utils.RespondWithData(c, nil)
+	utils.RespondWithData(c, resp)
 }
 
 // GetUserAvailableRooms 同步用户可加入的房间列表
 // GET /api/v1/rooms/sync?uid=xxx
 func (ph *ParticipantHandler) GetUserAvailableRooms(c *gin.Context) {
 	lang := middleware.GetLanguageFromContext(c)
-	logger := utils.GetLogger()
+	logger := utils.ContextLogger(c)
 
 	// 从 query 参数获取 uid
 	uid := c.Query("uid")
@@ -204,7 +209,7 @@ func (ph *ParticipantHandler) GetUserAvailableRooms(c *gin.Context) {
 		utils.RespondWithBindError(c)
 		return
 	}
-	data, err := ph.participantService.GetUserAvailableRooms(uid, deviceType)
+	data, err := ph.participantService.GetUserAvailableRooms(utils.RequestContext(c), uid, deviceType)
 	if err != nil {
 		logger.Error("获取用户可加入房间列表失败",
 			zap.Error(err),
@@ -222,3 +227,136 @@ func (ph *ParticipantHandler) GetUserAvailableRooms(c *gin.Context) {
 	// 直接返回数组，不包装在 data 节点中
 	utils.RespondWithData(c, data)
 }
+
+// AdmitLobbyParticipants 主持人准入等候室中的用户
+// POST /api/v1/rooms/:room_id/lobby/admit
+func (ph *ParticipantHandler) AdmitLobbyParticipants(c *gin.Context) {
+	ph.handleLobbyDecision(c, "准入", ph.participantService.AdmitLobbyParticipants)
+}
+
+// DenyLobbyParticipants 主持人拒绝等候室中的用户
+// POST /api/v1/rooms/:room_id/lobby/deny
+func (ph *ParticipantHandler) DenyLobbyParticipants(c *gin.Context) {
+	ph.handleLobbyDecision(c, "拒绝", ph.participantService.DenyLobbyParticipants)
+}
+
+// handleLobbyDecision 处理等候室准入/拒绝请求
+func (ph *ParticipantHandler) handleLobbyDecision(c *gin.Context, action string, decide func(ctx context.Context, req *models.LobbyDecisionRequest) error) {
+	lang := middleware.GetLanguageFromContext(c)
+	logger := utils.ContextLogger(c)
+	roomID := c.Param("room_id")
+
+	var req models.LobbyDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("等候室"+action+"参数绑定失败",
+			zap.Error(err),
+			zap.String("room_id", roomID),
+			zap.String("language", lang),
+		)
+		utils.RespondWithBindError(c)
+		return
+	}
+	req.RoomID = roomID
+
+	if err := decide(utils.RequestContext(c), &req); err != nil {
+		if businessErr, ok := err.(*errors.BusinessError); ok {
+			logger.Warn("等候室"+action+"业务错误",
+				zap.String("error_key", string(businessErr.Key)),
+				zap.String("error_message", businessErr.GetLocalizedMessage(lang)),
+				zap.String("room_id", roomID),
+				zap.String("uid", req.UID),
+				zap.Strings("uids", req.UIDs),
+				zap.String("language", lang),
+			)
+		} else {
+			logger.Error("等候室"+action+"系统错误",
+				zap.Error(err),
+				zap.String("room_id", roomID),
+				zap.String("uid", req.UID),
+				zap.Strings("uids", req.UIDs),
+				zap.String("language", lang),
+			)
+		}
+		utils.RespondWithBusinessError(c, err)
+		return
+	}
+
+	utils.RespondWithData(c, nil)
+}
+
+// GetLobbyParticipants 获取等候室中的用户列表
+// GET /api/v1/rooms/:room_id/lobby?uid=xxx
+func (ph *ParticipantHandler) GetLobbyParticipants(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	logger := utils.ContextLogger(c)
+	roomID := c.Param("room_id")
+
+	uid := c.Query("uid")
+	if uid == "" {
+		logger.Error("获取等候室列表参数 uid 缺失",
+			zap.String("room_id", roomID),
+			zap.String("language", lang),
+		)
+		utils.RespondWithBindError(c)
+		return
+	}
+
+	data, err := ph.participantService.GetLobbyParticipants(utils.RequestContext(c), roomID, uid)
+	if err != nil {
+		logger.Warn("获取等候室列表失败",
+			zap.Error(err),
+			zap.String("room_id", roomID),
+			zap.String("uid", uid),
+		)
+		utils.RespondWithBusinessError(c, err)
+		return
+	}
+
+	utils.RespondWithData(c, data)
+}
+
+// TransferHost 主持人转移主持人身份
+// 已连接会话的元数据角色会同步更新；已签发 Token 中的 RoomAdmin 授权不变，新主持人需重新获取 Token
+// POST /api/v1/rooms/:room_id/host
+func (ph *ParticipantHandler) TransferHost(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	logger := utils.ContextLogger(c)
+	roomID := c.Param("room_id")
+
+	var req models.TransferHostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("转移主持人参数绑定失败",
+			zap.Error(err),
+			zap.String("room_id", roomID),
+			zap.String("language", lang),
+		)
+		utils.RespondWithBindError(c)
+		return
+	}
+	req.RoomID = roomID
+
+	if err := ph.participantService.TransferHost(utils.RequestContext(c), &req); err != nil {
+		if businessErr, ok := err.(*errors.BusinessError); ok {
+			logger.Warn("转移主持人业务错误",
+				zap.String("error_key", string(businessErr.Key)),
+				zap.String("error_message", businessErr.GetLocalizedMessage(lang)),
+				zap.String("room_id", roomID),
+				zap.String("uid", req.UID),
+				zap.String("new_host", req.NewHost),
+				zap.String("language", lang),
+			)
+		} else {
+			logger.Error("转移主持人系统错误",
+				zap.Error(err),
+				zap.String("room_id", roomID),
+				zap.String("uid", req.UID),
+				zap.String("new_host", req.NewHost),
+				zap.String("language", lang),
+			)
+		}
+		utils.RespondWithBusinessError(c, err)
+		return
+	}
+
+	utils.RespondWithData(c, nil)
+}
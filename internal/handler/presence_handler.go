@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"strings"
+
+	"tgo-rtc-server/internal/errors"
+	"tgo-rtc-server/internal/middleware"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/service"
+	"tgo-rtc-server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// PresenceHandler 用户可用状态处理器
+type PresenceHandler struct {
+	presenceService *service.PresenceService
+}
+
+// NewPresenceHandler 创建用户可用状态处理器
+func NewPresenceHandler(presenceService *service.PresenceService) *PresenceHandler {
+	return &PresenceHandler{
+		presenceService: presenceService,
+	}
+}
+
+// SetPresence 设置用户可用状态（available/dnd/away/offline）
+// PUT /api/v1/presence
+func (ph *PresenceHandler) SetPresence(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	logger := utils.ContextLogger(c)
+
+	var req models.SetPresenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("设置用户可用状态参数绑定失败",
+			zap.Error(err),
+			zap.String("language", lang),
+		)
+		utils.RespondWithBindError(c)
+		return
+	}
+
+	resp, err := ph.presenceService.SetPresence(utils.RequestContext(c), &req)
+	if err != nil {
+		if businessErr, ok := err.(*errors.BusinessError); ok {
+			logger.Warn("设置用户可用状态业务错误",
+				zap.String("error_key", string(businessErr.Key)),
+				zap.String("error_message", businessErr.GetLocalizedMessage(lang)),
+				zap.String("uid", req.UID),
+				zap.String("status", req.Status),
+				zap.String("language", lang),
+			)
+		} else {
+			logger.Error("设置用户可用状态系统错误",
+				zap.Error(err),
+				zap.String("uid", req.UID),
+				zap.String("status", req.Status),
+				zap.String("language", lang),
+			)
+		}
+		utils.RespondWithBusinessError(c, err)
+		return
+	}
+
+	utils.RespondWithData(c, resp)
+}
+
+// GetPresence 批量获取用户当前可用状态
+// GET /api/v1/presence?uids=a,b
+func (ph *PresenceHandler) GetPresence(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	logger := utils.ContextLogger(c)
+
+	uids := make([]string, 0)
+	for _, uid := range strings.Split(c.Query("uids"), ",") {
+		if uid = strings.TrimSpace(uid); uid != "" {
+			uids = append(uids, uid)
+		}
+	}
+	if len(uids) == 0 {
+		logger.Error("获取用户可用状态参数 uids 缺失",
+			zap.String("language", lang),
+		)
+		utils.RespondWithBindError(c)
+		return
+	}
+
+	resp, err := ph.presenceService.GetPresence(utils.RequestContext(c), uids)
+	if err != nil {
+		logger.Error("获取用户可用状态失败",
+			zap.Error(err),
+			zap.Strings("uids", uids),
+			zap.String("language", lang),
+		)
+		utils.RespondWithBusinessError(c, err)
+		return
+	}
+
+	utils.RespondWithData(c, resp)
+}
@@ -33,7 +33,7 @@ func (rh *RoomHandler) SetBusinessWebhookService(bws *service.BusinessWebhookSer
 // POST /api/rooms
 func (rh *RoomHandler) CreateRoom(c *gin.Context) {
 	lang := middleware.GetLanguageFromContext(c)
-	logger := utils.GetLogger()
+	logger := utils.ContextLogger(c)
 
 	var req models.CreateRoomRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -51,7 +51,8 @@ func (rh *RoomHandler) CreateRoom(c *gin.Context) {
 		utils.RespondWithBindError(c)
 		return
 	}
-	resp, err := rh.roomService.CreateRoom(&req)
+	req.IdempotencyKey = c.GetHeader(models.IdempotencyKeyHeader)
+	resp, err := rh.roomService.CreateRoom(utils.RequestContext(c), &req)
 	if err != nil {
 		if businessErr, ok := err.(*errors.BusinessError); ok {
 			logger.Warn("创建房间业务错误",
@@ -74,3 +75,34 @@ func (rh *RoomHandler) CreateRoom(c *gin.Context) {
 
 	utils.RespondWithData(c, resp)
 }
+
+// GetChannelActiveRoom 获取频道当前进行中的房间
+// GET /api/v1/channels/:channel_id/active-room
+// 只返回房间信息，加入房间需调用加入房间接口获取 Token
+func (rh *RoomHandler) GetChannelActiveRoom(c *gin.Context) {
+	lang := middleware.GetLanguageFromContext(c)
+	logger := utils.ContextLogger(c)
+	channelID := c.Param("channel_id")
+
+	resp, err := rh.roomService.GetChannelActiveRoom(utils.RequestContext(c), channelID)
+	if err != nil {
+		if businessErr, ok := err.(*errors.BusinessError); ok {
+			logger.Warn("获取频道进行中的房间业务错误",
+				zap.String("error_key", string(businessErr.Key)),
+				zap.String("error_message", businessErr.GetLocalizedMessage(lang)),
+				zap.String("channel_id", channelID),
+				zap.String("language", lang),
+			)
+		} else {
+			logger.Error("获取频道进行中的房间系统错误",
+				zap.Error(err),
+				zap.String("channel_id", channelID),
+				zap.String("language", lang),
+			)
+		}
+		utils.RespondWithBusinessError(c, err)
+		return
+	}
+
+	utils.RespondWithData(c, resp)
+}
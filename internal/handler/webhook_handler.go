@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"net/http"
+
+	"tgo-rtc-server/internal/i18n"
 	"tgo-rtc-server/internal/livekit"
+	"tgo-rtc-server/internal/middleware"
 	"tgo-rtc-server/internal/service"
 	"tgo-rtc-server/internal/utils"
 
@@ -26,7 +30,7 @@ func NewWebhookHandler(webhookService *service.WebhookService, webhookValidator
 // HandleWebhook 处理 webhook 请求
 // POST /api/v1/webhooks/livekit
 func (wh *WebhookHandler) HandleWebhook(c *gin.Context) {
-	logger := utils.GetLogger()
+	logger := utils.ContextLogger(c)
 
 	// 验证请求
 	body, err := wh.webhookValidator.ValidateRequest(c.Request)
@@ -51,7 +55,13 @@ func (wh *WebhookHandler) HandleWebhook(c *gin.Context) {
 	}
 
 	// 处理事件
-	if err := wh.webhookService.HandleWebhookEvent(event); err != nil {
+	if err := wh.webhookService.HandleWebhookEvent(utils.RequestContext(c), event); err != nil {
+		// 其他实例正在处理同一事件，返回 409 让 LiveKit 稍后重试
+		if err == service.ErrWebhookEventInProgress {
+			utils.RespondWithError(c, http.StatusConflict, http.StatusConflict,
+				i18n.Translate(middleware.GetLanguageFromContext(c), i18n.WebhookEventInProgress))
+			return
+		}
 		logger.Error("处理 webhook 事件失败",
 			zap.Error(err),
 			zap.String("event_type", event.Event),
@@ -23,7 +23,7 @@ func NewWebhookLogHandler(businessWebhookService *service.BusinessWebhookService
 // GetLogStats 获取 webhook 日志统计信息
 // GET /api/v1/webhooks/logs/stats
 func (wlh *WebhookLogHandler) GetLogStats(c *gin.Context) {
-	logger := utils.GetLogger()
+	logger := utils.ContextLogger(c)
 
 	stats, err := wlh.businessWebhookService.GetLogStats()
 	if err != nil {
@@ -40,7 +40,7 @@ func (wlh *WebhookLogHandler) GetLogStats(c *gin.Context) {
 // CleanupLogs 手动清理 webhook 日志
 // POST /api/v1/webhooks/logs/cleanup
 func (wlh *WebhookLogHandler) CleanupLogs(c *gin.Context) {
-	logger := utils.GetLogger()
+	logger := utils.ContextLogger(c)
 
 	var req struct {
 		RetentionDays int `json:"retention_days" binding:"required,min=1"`
@@ -12,6 +12,13 @@ const (
 	// 房间相关错误
 	RoomAlreadyExists           MessageKey = "room_already_exists"
 	ChannelHasActiveRoom        MessageKey = "channel_has_active_room"
+	ChannelNoActiveRoom         MessageKey = "channel_no_active_room"
+	ChannelRoomBusy             MessageKey = "channel_room_busy"
+	CallSetupBusy               MessageKey = "call_setup_busy"
+	IdempotencyKeyReused        MessageKey = "idempotency_key_reused"
+	IdempotencyInProgress       MessageKey = "idempotency_in_progress"
+	WebhookEventInProgress      MessageKey = "webhook_event_in_progress"
+	RoomSessionActive           MessageKey = "room_session_active"
 	CreatorInAnotherCall        MessageKey = "creator_in_another_call"
 	ParticipantInCall           MessageKey = "participant_in_call"
 	RoomNotFound                MessageKey = "room_not_found"
@@ -30,6 +37,27 @@ const (
 	ParticipantStatusUpdateFailed MessageKey = "participant_status_update_failed"
 	ParticipantListQueryFailed    MessageKey = "participant_list_query_failed"
 	ParticipantNotInvited         MessageKey = "participant_not_invited"
+	NotRoomHost                   MessageKey = "not_room_host"
+	LobbyEntryDenied              MessageKey = "lobby_entry_denied"
+	HostTransferTargetInvalid     MessageKey = "host_transfer_target_invalid"
+	HostTransferFailed            MessageKey = "host_transfer_failed"
+	InviteLinkInvalid             MessageKey = "invite_link_invalid"
+	InviteLinkExpired             MessageKey = "invite_link_expired"
+	InviteLinkExhausted           MessageKey = "invite_link_exhausted"
+	InviteLinkCreateFailed        MessageKey = "invite_link_create_failed"
+	EventStreamTokenInvalid       MessageKey = "event_stream_token_invalid"
+	EventStreamTokenFailed        MessageKey = "event_stream_token_failed"
+	ServerKeyInvalid              MessageKey = "server_key_invalid"
+	PushProviderNotSupported      MessageKey = "push_provider_not_supported"
+	DeviceTokenSaveFailed         MessageKey = "device_token_save_failed"
+	RateLimitExceeded             MessageKey = "rate_limit_exceeded"
+	CalleeRingLimitExceeded       MessageKey = "callee_ring_limit_exceeded"
+	BlockSelfNotAllowed           MessageKey = "block_self_not_allowed"
+	BlockListUpdateFailed         MessageKey = "block_list_update_failed"
+	BlockListQueryFailed          MessageKey = "block_list_query_failed"
+	CalleeUnavailable             MessageKey = "callee_unavailable"
+	PresenceUpdateFailed          MessageKey = "presence_update_failed"
+	PresenceQueryFailed           MessageKey = "presence_query_failed"
 
 	// 数据库操作错误
 	RoomQueryFailed         MessageKey = "room_query_failed"
@@ -44,6 +72,13 @@ var Translations = map[string]map[MessageKey]string{
 		InvalidParameters:             "参数错误",
 		RoomAlreadyExists:             "房间已存在: %s",
 		ChannelHasActiveRoom:          "该渠道已存在正在通话的房间",
+		ChannelNoActiveRoom:           "该渠道没有正在通话的房间: %s",
+		ChannelRoomBusy:               "该渠道正在创建房间，请稍后重试",
+		CallSetupBusy:                 "用户正在发起或接听其他呼叫，请稍后重试",
+		IdempotencyKeyReused:          "幂等键已用于其他请求，请使用新的 Idempotency-Key",
+		IdempotencyInProgress:         "相同 Idempotency-Key 的请求正在处理中，请稍后重试",
+		WebhookEventInProgress:        "事件正在由其他实例处理中，请稍后重试",
+		RoomSessionActive:             "房间 %s 的通话仍在进行中，无法重新开始",
 		CreatorInAnotherCall:          "创建者正在进行其他通话，无法创建房间",
 		ParticipantInCall:             "参与者 %s 正在通话中，无法邀请",
 		RoomNotFound:                  "房间不存在: %s",
@@ -60,6 +95,27 @@ var Translations = map[string]map[MessageKey]string{
 		ParticipantStatusUpdateFailed: "更新参与者状态失败: %v",
 		ParticipantListQueryFailed:    "查询参与者列表失败: %v",
 		ParticipantNotInvited:         "您未被邀请加入此房间",
+		NotRoomHost:                   "只有主持人可以执行此操作",
+		LobbyEntryDenied:              "主持人已拒绝你加入房间",
+		HostTransferTargetInvalid:     "新主持人必须是已加入房间的其他参与者: %s",
+		HostTransferFailed:            "转移主持人失败: %s",
+		InviteLinkInvalid:             "邀请链接无效",
+		InviteLinkExpired:             "邀请链接已过期",
+		InviteLinkExhausted:           "邀请链接使用次数已达上限",
+		InviteLinkCreateFailed:        "创建邀请链接失败: %s",
+		EventStreamTokenInvalid:       "事件流 Token 无效或已过期",
+		EventStreamTokenFailed:        "生成事件流 Token 失败: %s",
+		ServerKeyInvalid:              "服务端密钥无效，拒绝访问",
+		PushProviderNotSupported:      "不支持的推送通道: %s",
+		DeviceTokenSaveFailed:         "保存设备推送 Token 失败: %s",
+		RateLimitExceeded:             "请求过于频繁，请在 %d 秒后重试",
+		CalleeRingLimitExceeded:       "用户 %s 短时间内来电过多，请在 %d 秒后重试",
+		BlockSelfNotAllowed:           "不能屏蔽自己",
+		BlockListUpdateFailed:         "更新屏蔽列表失败: %s",
+		BlockListQueryFailed:          "查询屏蔽列表失败: %s",
+		CalleeUnavailable:             "用户 %s 当前不可用（免打扰或离线）",
+		PresenceUpdateFailed:          "更新用户可用状态失败: %s",
+		PresenceQueryFailed:           "查询用户可用状态失败: %s",
 		RoomQueryFailed:               "查询房间失败: %v",
 		RoomStatusUpdateFailed:        "更新房间状态失败: %v",
 		ParticipantAddFailed:          "添加参与者失败: %v",
@@ -69,6 +125,13 @@ var Translations = map[string]map[MessageKey]string{
 		InvalidParameters:             "參數錯誤",
 		RoomAlreadyExists:             "房間已存在: %s",
 		ChannelHasActiveRoom:          "該渠道已存在正在通話的房間",
+		ChannelNoActiveRoom:           "該渠道沒有正在通話的房間: %s",
+		ChannelRoomBusy:               "該渠道正在建立房間，請稍後重試",
+		CallSetupBusy:                 "使用者正在發起或接聽其他通話，請稍後重試",
+		IdempotencyKeyReused:          "冪等鍵已用於其他請求，請使用新的 Idempotency-Key",
+		IdempotencyInProgress:         "相同 Idempotency-Key 的請求正在處理中，請稍後重試",
+		WebhookEventInProgress:        "事件正在由其他實例處理中，請稍後重試",
+		RoomSessionActive:             "房間 %s 的通話仍在進行中，無法重新開始",
 		CreatorInAnotherCall:          "建立者正在進行其他通話，無法建立房間",
 		ParticipantInCall:             "參與者 %s 正在通話中，無法邀請",
 		RoomNotFound:                  "房間不存在: %s",
@@ -85,6 +148,27 @@ var Translations = map[string]map[MessageKey]string{
 		ParticipantStatusUpdateFailed: "更新參與者狀態失敗: %v",
 		ParticipantListQueryFailed:    "查詢參與者列表失敗: %v",
 		ParticipantNotInvited:         "您未被邀請加入此房間",
+		NotRoomHost:                   "只有主持人可以執行此操作",
+		LobbyEntryDenied:              "主持人已拒絕你加入房間",
+		HostTransferTargetInvalid:     "新主持人必須是已加入房間的其他參與者: %s",
+		HostTransferFailed:            "轉移主持人失敗: %s",
+		InviteLinkInvalid:             "邀請連結無效",
+		InviteLinkExpired:             "邀請連結已過期",
+		InviteLinkExhausted:           "邀請連結使用次數已達上限",
+		InviteLinkCreateFailed:        "建立邀請連結失敗: %s",
+		EventStreamTokenInvalid:       "事件流 Token 無效或已過期",
+		EventStreamTokenFailed:        "產生事件流 Token 失敗: %s",
+		ServerKeyInvalid:              "服務端金鑰無效，拒絕存取",
+		PushProviderNotSupported:      "不支援的推送通道: %s",
+		DeviceTokenSaveFailed:         "儲存裝置推送 Token 失敗: %s",
+		RateLimitExceeded:             "請求過於頻繁，請在 %d 秒後重試",
+		CalleeRingLimitExceeded:       "使用者 %s 短時間內來電過多，請在 %d 秒後重試",
+		BlockSelfNotAllowed:           "不能封鎖自己",
+		BlockListUpdateFailed:         "更新封鎖清單失敗: %s",
+		BlockListQueryFailed:          "查詢封鎖清單失敗: %s",
+		CalleeUnavailable:             "使用者 %s 目前無法接聽（勿擾或離線）",
+		PresenceUpdateFailed:          "更新使用者可用狀態失敗: %s",
+		PresenceQueryFailed:           "查詢使用者可用狀態失敗: %s",
 		RoomQueryFailed:               "查詢房間失敗: %v",
 		RoomStatusUpdateFailed:        "更新房間狀態失敗: %v",
 		ParticipantAddFailed:          "添加參與者失敗: %v",
@@ -94,6 +178,13 @@ var Translations = map[string]map[MessageKey]string{
 		InvalidParameters:             "Invalid parameters",
 		RoomAlreadyExists:             "Room already exists: %s",
 		ChannelHasActiveRoom:          "An active room already exists for this channel",
+		ChannelNoActiveRoom:           "No active room for channel: %s",
+		ChannelRoomBusy:               "A room is being created for this channel, please retry later",
+		CallSetupBusy:                 "A user is setting up another call, please retry later",
+		IdempotencyKeyReused:          "The Idempotency-Key was already used for a different request",
+		IdempotencyInProgress:         "A request with the same Idempotency-Key is still being processed, please retry later",
+		WebhookEventInProgress:        "The event is being processed by another instance, please retry later",
+		RoomSessionActive:             "A call is still active in room %s, cannot restart",
 		CreatorInAnotherCall:          "Creator is in another call, cannot create room",
 		ParticipantInCall:             "Participant %s is in a call, cannot invite",
 		RoomNotFound:                  "Room not found: %s",
@@ -110,6 +201,27 @@ var Translations = map[string]map[MessageKey]string{
 		ParticipantStatusUpdateFailed: "Failed to update participant status: %v",
 		ParticipantListQueryFailed:    "Failed to query participant list: %v",
 		ParticipantNotInvited:         "You are not invited to join this room",
+		NotRoomHost:                   "Only the host can perform this operation",
+		LobbyEntryDenied:              "The host has denied your request to join the room",
+		HostTransferTargetInvalid:     "The new host must be another participant who has joined the room: %s",
+		HostTransferFailed:            "Failed to transfer host: %s",
+		InviteLinkInvalid:             "Invalid invite link",
+		InviteLinkExpired:             "Invite link has expired",
+		InviteLinkExhausted:           "Invite link has reached its maximum number of uses",
+		InviteLinkCreateFailed:        "Failed to create invite link: %s",
+		EventStreamTokenInvalid:       "Event stream token is invalid or expired",
+		EventStreamTokenFailed:        "Failed to generate event stream token: %s",
+		ServerKeyInvalid:              "Invalid server key, access denied",
+		PushProviderNotSupported:      "Unsupported push provider: %s",
+		DeviceTokenSaveFailed:         "Failed to save device push token: %s",
+		RateLimitExceeded:             "Too many requests, please retry in %d seconds",
+		CalleeRingLimitExceeded:       "User %s is receiving too many calls, please retry in %d seconds",
+		BlockSelfNotAllowed:           "You cannot block yourself",
+		BlockListUpdateFailed:         "Failed to update block list: %s",
+		BlockListQueryFailed:          "Failed to query block list: %s",
+		CalleeUnavailable:             "User %s is currently unavailable (do not disturb or offline)",
+		PresenceUpdateFailed:          "Failed to update user availability: %s",
+		PresenceQueryFailed:           "Failed to query user availability: %s",
 		RoomQueryFailed:               "Failed to query room: %v",
 		RoomStatusUpdateFailed:        "Failed to update room status: %v",
 		ParticipantAddFailed:          "Failed to add participant: %v",
@@ -119,6 +231,13 @@ var Translations = map[string]map[MessageKey]string{
 		InvalidParameters:             "Paramètres invalides",
 		RoomAlreadyExists:             "La salle existe déjà: %s",
 		ChannelHasActiveRoom:          "Une salle active existe déjà pour ce canal",
+		ChannelNoActiveRoom:           "Aucune salle active pour le canal: %s",
+		ChannelRoomBusy:               "Une salle est en cours de création pour ce canal, veuillez réessayer plus tard",
+		CallSetupBusy:                 "Un utilisateur est en train d'établir un autre appel, veuillez réessayer plus tard",
+		IdempotencyKeyReused:          "L'Idempotency-Key a déjà été utilisée pour une autre requête",
+		IdempotencyInProgress:         "Une requête avec la même Idempotency-Key est en cours de traitement, veuillez réessayer plus tard",
+		WebhookEventInProgress:        "L'événement est en cours de traitement par une autre instance, veuillez réessayer plus tard",
+		RoomSessionActive:             "Un appel est toujours en cours dans la salle %s, impossible de redémarrer",
 		CreatorInAnotherCall:          "Le créateur est en appel, impossible de créer la salle",
 		ParticipantInCall:             "Le participant %s est en appel, impossible d'inviter",
 		RoomNotFound:                  "Salle non trouvée: %s",
@@ -135,6 +254,27 @@ var Translations = map[string]map[MessageKey]string{
 		ParticipantStatusUpdateFailed: "Échec de la mise à jour du statut du participant: %v",
 		ParticipantListQueryFailed:    "Échec de la requête de la liste des participants: %v",
 		ParticipantNotInvited:         "Vous n'êtes pas invité à rejoindre cette salle",
+		NotRoomHost:                   "Seul l'hôte peut effectuer cette opération",
+		LobbyEntryDenied:              "L'hôte a refusé votre demande de rejoindre la salle",
+		HostTransferTargetInvalid:     "Le nouvel hôte doit être un autre participant ayant rejoint la salle : %s",
+		HostTransferFailed:            "Échec du transfert de l'hôte : %s",
+		InviteLinkInvalid:             "Lien d'invitation invalide",
+		InviteLinkExpired:             "Le lien d'invitation a expiré",
+		InviteLinkExhausted:           "Le lien d'invitation a atteint son nombre maximal d'utilisations",
+		InviteLinkCreateFailed:        "Échec de la création du lien d'invitation : %s",
+		EventStreamTokenInvalid:       "Le jeton du flux d'événements est invalide ou expiré",
+		EventStreamTokenFailed:        "Échec de la génération du jeton du flux d'événements : %s",
+		ServerKeyInvalid:              "Clé serveur invalide, accès refusé",
+		PushProviderNotSupported:      "Fournisseur de notifications non pris en charge : %s",
+		DeviceTokenSaveFailed:         "Échec de l'enregistrement du jeton de notification de l'appareil : %s",
+		RateLimitExceeded:             "Trop de requêtes, veuillez réessayer dans %d secondes",
+		CalleeRingLimitExceeded:       "L'utilisateur %s reçoit trop d'appels, veuillez réessayer dans %d secondes",
+		BlockSelfNotAllowed:           "Vous ne pouvez pas vous bloquer vous-même",
+		BlockListUpdateFailed:         "Échec de la mise à jour de la liste de blocage : %s",
+		BlockListQueryFailed:          "Échec de la récupération de la liste de blocage : %s",
+		CalleeUnavailable:             "L'utilisateur %s est actuellement indisponible (ne pas déranger ou hors ligne)",
+		PresenceUpdateFailed:          "Échec de la mise à jour de la disponibilité de l'utilisateur : %s",
+		PresenceQueryFailed:           "Échec de la récupération de la disponibilité de l'utilisateur : %s",
 		RoomQueryFailed:               "Échec de la requête de la salle: %v",
 		RoomStatusUpdateFailed:        "Échec de la mise à jour du statut de la salle: %v",
 		ParticipantAddFailed:          "Échec de l'ajout du participant: %v",
@@ -144,6 +284,13 @@ var Translations = map[string]map[MessageKey]string{
 		InvalidParameters:             "無効なパラメータ",
 		RoomAlreadyExists:             "ルームは既に存在します: %s",
 		ChannelHasActiveRoom:          "このチャネルには既にアクティブなルームが存在します",
+		ChannelNoActiveRoom:           "このチャネルにはアクティブなルームがありません: %s",
+		ChannelRoomBusy:               "このチャネルのルームを作成中です。しばらくしてから再試行してください",
+		CallSetupBusy:                 "ユーザーが別の通話を発信または応答中です。しばらくしてから再試行してください",
+		IdempotencyKeyReused:          "この Idempotency-Key は別のリクエストで使用済みです",
+		IdempotencyInProgress:         "同じ Idempotency-Key のリクエストを処理中です。しばらくしてから再試行してください",
+		WebhookEventInProgress:        "イベントは別のインスタンスで処理中です。しばらくしてから再試行してください",
+		RoomSessionActive:             "ルーム %s の通話はまだ進行中です。再開できません",
 		CreatorInAnotherCall:          "作成者は別の通話中です。ルームを作成できません",
 		ParticipantInCall:             "参加者 %s は通話中です。招待できません",
 		RoomNotFound:                  "ルームが見つかりません: %s",
@@ -160,6 +307,27 @@ var Translations = map[string]map[MessageKey]string{
 		ParticipantStatusUpdateFailed: "参加者ステータスの更新に失敗しました: %v",
 		ParticipantListQueryFailed:    "参加者リストのクエリに失敗しました: %v",
 		ParticipantNotInvited:         "このルームへの招待を受けていません",
+		NotRoomHost:                   "この操作はホストのみ実行できます",
+		LobbyEntryDenied:              "ホストがルームへの参加を拒否しました",
+		HostTransferTargetInvalid:     "新しいホストはルームに参加している他の参加者である必要があります: %s",
+		HostTransferFailed:            "ホストの移譲に失敗しました: %s",
+		InviteLinkInvalid:             "招待リンクが無効です",
+		InviteLinkExpired:             "招待リンクの有効期限が切れています",
+		InviteLinkExhausted:           "招待リンクの使用回数が上限に達しました",
+		InviteLinkCreateFailed:        "招待リンクの作成に失敗しました: %s",
+		EventStreamTokenInvalid:       "イベントストリームのトークンが無効または期限切れです",
+		EventStreamTokenFailed:        "イベントストリームのトークン生成に失敗しました: %s",
+		ServerKeyInvalid:              "サーバーキーが無効なため、アクセスが拒否されました",
+		PushProviderNotSupported:      "サポートされていないプッシュプロバイダーです: %s",
+		DeviceTokenSaveFailed:         "デバイスのプッシュトークンの保存に失敗しました: %s",
+		RateLimitExceeded:             "リクエストが多すぎます。%d 秒後に再試行してください",
+		CalleeRingLimitExceeded:       "ユーザー %s への着信が多すぎます。%d 秒後に再試行してください",
+		BlockSelfNotAllowed:           "自分自身をブロックすることはできません",
+		BlockListUpdateFailed:         "ブロックリストの更新に失敗しました: %s",
+		BlockListQueryFailed:          "ブロックリストの取得に失敗しました: %s",
+		CalleeUnavailable:             "ユーザー %s は現在応答できません（おやすみモードまたはオフライン）",
+		PresenceUpdateFailed:          "ユーザーの在席状態の更新に失敗しました: %s",
+		PresenceQueryFailed:           "ユーザーの在席状態の取得に失敗しました: %s",
 		RoomQueryFailed:               "ルームのクエリに失敗しました: %v",
 		RoomStatusUpdateFailed:        "ルームステータスの更新に失敗しました: %v",
 		ParticipantAddFailed:          "参加者の追加に失敗しました: %v",
@@ -0,0 +1,137 @@
+package livekit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"tgo-rtc-server/internal/config"
+
+	"github.com/livekit/protocol/auth"
+)
+
+// RoomClient LiveKit RoomService 客户端（Twirp JSON 接口），用于更新已连接参与者的元数据和权限
+type RoomClient struct {
+	apiKey    string
+	apiSecret string
+	url       string // 后端调用 LiveKit API 的地址
+	client    *http.Client
+}
+
+// NewRoomClient 创建 LiveKit RoomService 客户端
+func NewRoomClient(cfg *config.Config, client *http.Client) *RoomClient {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	// LiveKit 地址可能配置为 ws(s)://，调用 API 时使用对应的 http(s)://
+	url := cfg.LiveKitURL
+	url = strings.Replace(url, "wss://", "https://", 1)
+	url = strings.Replace(url, "ws://", "http://", 1)
+	return &RoomClient{
+		apiKey:    cfg.LiveKitAPIKey,
+		apiSecret: cfg.LiveKitAPISecret,
+		url:       strings.TrimRight(url, "/"),
+		client:    client,
+	}
+}
+
+// participantInfo GetParticipant 返回的参与者信息（只解析需要的字段）
+type participantInfo struct {
+	Identity string `json:"identity"`
+	Metadata string `json:"metadata"`
+}
+
+// participantPermission 参与者房间内权限
+// RoomAdmin 属于 Token 授权，LiveKit 不支持对已连接的参与者修改，只能在重新签发 Token 后生效
+type participantPermission struct {
+	CanSubscribe   bool `json:"can_subscribe"`
+	CanPublish     bool `json:"can_publish"`
+	CanPublishData bool `json:"can_publish_data"`
+}
+
+// UpdateParticipantRole 更新已连接参与者的角色：元数据中的 role 替换为指定角色（保留设备类型、访客信息等其他字段），并恢复发布权限
+// 参与者未连接 LiveKit 时返回 connected=false，不视为错误
+func (rc *RoomClient) UpdateParticipantRole(ctx context.Context, roomName, identity, role string) (connected bool, err error) {
+	var info participantInfo
+	found, err := rc.call(ctx, roomName, "GetParticipant", map[string]string{
+		"room":     roomName,
+		"identity": identity,
+	}, &info)
+	if err != nil || !found {
+		return false, err
+	}
+
+	var metadata ParticipantMetadata
+	if info.Metadata != "" {
+		if err := json.Unmarshal([]byte(info.Metadata), &metadata); err != nil {
+			return true, fmt.Errorf("解析参与者元数据失败: %w", err)
+		}
+	}
+	metadata.Role = role
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return true, fmt.Errorf("序列化 metadata 失败: %w", err)
+	}
+
+	found, err = rc.call(ctx, roomName, "UpdateParticipant", map[string]interface{}{
+		"room":     roomName,
+		"identity": identity,
+		"metadata": string(metadataJSON),
+		"permission": participantPermission{
+			CanSubscribe:   true,
+			CanPublish:     true,
+			CanPublishData: true,
+		},
+	}, nil)
+	return found, err
+}
+
+// call 调用 RoomService 的 Twirp 接口，参与者或房间不存在时返回 found=false
+func (rc *RoomClient) call(ctx context.Context, roomName, method string, body interface{}, out interface{}) (bool, error) {
+	if rc.apiKey == "" || rc.apiSecret == "" {
+		return false, fmt.Errorf("LiveKit API 密钥未配置")
+	}
+	at := auth.NewAccessToken(rc.apiKey, rc.apiSecret)
+	at.AddGrant(&auth.VideoGrant{RoomAdmin: true, Room: roomName}).
+		SetValidFor(time.Minute)
+	token, err := at.ToJWT()
+	if err != nil {
+		return false, fmt.Errorf("生成 LiveKit API Token 失败: %w", err)
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rc.url+"/twirp/livekit.RoomService/"+method, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := rc.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("LiveKit %s 返回状态码 %d: %s", method, resp.StatusCode, string(respBody))
+	}
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return true, fmt.Errorf("解析 LiveKit %s 响应失败: %w", method, err)
+		}
+	}
+	return true, nil
+}
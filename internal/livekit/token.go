@@ -14,8 +14,18 @@ import (
 // ParticipantMetadata 参与者元数据
 type ParticipantMetadata struct {
 	DeviceType string `json:"device_type"`
+	Role       string `json:"role,omitempty"`  // 参与者角色，见 Role 常量
+	Name       string `json:"name,omitempty"`  // 显示名称（访客使用）
+	Guest      bool   `json:"guest,omitempty"` // 是否为访客（不在业务用户体系内）
 }
 
+// Role 参与者角色常量
+const (
+	RoleParticipant = "participant" // 普通参与者（默认）
+	RoleViewer      = "viewer"      // 观众，只能订阅不能发布音视频
+	RoleHost        = "host"        // 主持人，拥有房间管理权限
+)
+
 // TokenResult Token 生成结果，包含 Token 和配置信息
 type TokenResult struct {
 	Token   string
@@ -65,16 +75,32 @@ func (tg *TokenGenerator) GenerateTokenWithConfig(roomName, uid, deviceType stri
 	return result, nil
 }
 
+// GenerateTokenWithMetadata 按指定的参与者元数据（角色、访客信息等）生成 Token 并返回配置信息
+func (tg *TokenGenerator) GenerateTokenWithMetadata(roomName, uid string, metadata ParticipantMetadata) (*TokenResult, error) {
+	token, err := tg.generateToken(roomName, uid, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenResult{
+		Token:   token,
+		URL:     tg.clientURL,
+		Timeout: tg.timeout,
+	}, nil
+}
+
 // GenerateTokenWithExpiry 生成指定过期时间的 Token
 func (tg *TokenGenerator) GenerateTokenWithExpiry(roomName, uid, deviceType string) (string, error) {
+	return tg.generateToken(roomName, uid, ParticipantMetadata{DeviceType: deviceType})
+}
+
+// generateToken 生成 Token，根据角色设置房间内权限
+func (tg *TokenGenerator) generateToken(roomName, uid string, metadata ParticipantMetadata) (string, error) {
 	if tg.apiKey == "" || tg.apiSecret == "" {
 		return "", fmt.Errorf("LiveKit API 密钥未配置")
 	}
 
 	// 构建 metadata JSON
-	metadata := ParticipantMetadata{
-		DeviceType: deviceType,
-	}
 	metadataJSON, err := json.Marshal(metadata)
 	if err != nil {
 		return "", fmt.Errorf("序列化 metadata 失败: %w", err)
@@ -86,6 +112,13 @@ func (tg *TokenGenerator) GenerateTokenWithExpiry(roomName, uid, deviceType stri
 		RoomCreate: true,
 		Room:       roomName,
 	}
+	switch metadata.Role {
+	case RoleViewer:
+		grant.SetCanPublish(false)
+		grant.SetCanPublishData(true)
+	case RoleHost:
+		grant.RoomAdmin = true
+	}
 	at.AddGrant(grant).
 		SetIdentity(uid).
 		SetMetadata(string(metadataJSON)).
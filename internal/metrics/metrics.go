@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"net/url"
+	"strconv"
+
+	"tgo-rtc-server/internal/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// namespace 指标名前缀
+const namespace = "tgo_rtc"
+
+var (
+	// RoomsCreated 创建的房间数（按呼叫类型）
+	RoomsCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rooms_created_total",
+		Help:      "创建的房间数",
+	}, []string{"rtc_type"})
+
+	// RoomsFinished 结束的房间数（按最终状态）
+	RoomsFinished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rooms_finished_total",
+		Help:      "结束的房间数（按最终状态：finished/cancelled/rejected/busy/missed）",
+	}, []string{"status"})
+
+	// LiveKitWebhookEvents 收到的 LiveKit webhook 事件数（按事件类型和处理结果）
+	LiveKitWebhookEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "livekit_webhook_events_total",
+		Help:      "收到的 LiveKit webhook 事件数（result: ok/error/duplicate/in_progress/stale/ignored）",
+	}, []string{"event", "result"})
+
+	// BusinessWebhookDuration 业务 webhook 投递耗时（按端点）
+	BusinessWebhookDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "business_webhook_delivery_duration_seconds",
+		Help:      "业务 webhook 投递耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	// BusinessWebhookFailures 业务 webhook 投递失败数（按端点和失败原因）
+	BusinessWebhookFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "business_webhook_delivery_failures_total",
+		Help:      "业务 webhook 投递失败数（reason: network/status/request）",
+	}, []string{"endpoint", "reason"})
+
+	// SchedulerActiveTimers 当前活跃的参与者超时精确定时器数
+	SchedulerActiveTimers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "scheduler_active_timers",
+		Help:      "当前活跃的参与者超时精确定时器数",
+	})
+
+	// SchedulerFallbackCatches 兜底轮询标记为超时的参与者数（精确定时器未处理到的超时）
+	SchedulerFallbackCatches = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduler_fallback_catches_total",
+		Help:      "兜底轮询标记为超时的参与者数（精确定时器未处理到的超时）",
+	})
+
+	// HTTPRequestDuration HTTP 接口耗时（按方法、路由和状态码）
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP 接口耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// RateLimitRejected 被频率限制拒绝的请求数（按动作和限流维度）
+	RateLimitRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejected_total",
+		Help:      "被频率限制拒绝的请求数（action: create/invite/join/ring，scope: uid/ip/tenant/callee）",
+	}, []string{"action", "scope"})
+
+	// CallPolicyBlocked 被呼叫策略拦截的被叫数（按拦截来源）
+	CallPolicyBlocked = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "call_policy_blocked_total",
+		Help:      "被呼叫策略拦截的被叫数（source: block_list/callout）",
+	}, []string{"source"})
+
+	// ActiveCallIndexLookups 忙线检查的查询方式
+	ActiveCallIndexLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "active_call_index_lookups_total",
+		Help:      "忙线检查次数（result: index 由 Redis 索引直接判定空闲/db_verify 索引命中后查询数据库确认/db_fallback 索引不可用时查询数据库）",
+	}, []string{"result"})
+
+	// CallGlareMerged 对呼合并次数
+	CallGlareMerged = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "call_glare_merged_total",
+		Help:      "双方同时互相呼叫时，后发起的一方直接加入对方房间的次数",
+	})
+
+	// IdempotencyReplays 幂等请求重放次数
+	IdempotencyReplays = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "idempotency_replays_total",
+		Help:      "携带相同 Idempotency-Key 的重试请求直接返回首次响应的次数（action: create/invite/join）",
+	}, []string{"action"})
+
+	// ArchivedRows 归档任务移出热表的记录数
+	ArchivedRows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "archived_rows_total",
+		Help:      "归档任务移出热表的记录数（kind: rooms/participants/sessions/session_participants/webhook_logs）",
+	}, []string{"kind"})
+)
+
+// RTCTypeLabel 呼叫类型标签
+func RTCTypeLabel(rtcType uint8) string {
+	switch rtcType {
+	case models.RTCTypeVoice:
+		return "voice"
+	case models.RTCTypeVideo:
+		return "video"
+	default:
+		return strconv.Itoa(int(rtcType))
+	}
+}
+
+// RoomStatusLabel 房间状态标签
+func RoomStatusLabel(status uint8) string {
+	switch status {
+	case models.RoomStatusNotStarted:
+		return "not_started"
+	case models.RoomStatusInProgress:
+		return "in_progress"
+	case models.RoomStatusFinished:
+		return "finished"
+	case models.RoomStatusCancelled:
+		return "cancelled"
+	case models.RoomStatusRejected:
+		return "rejected"
+	case models.RoomStatusBusy:
+		return "busy"
+	case models.RoomStatusMissed:
+		return "missed"
+	default:
+		return strconv.Itoa(int(status))
+	}
+}
+
+// EndpointLabel 端点标签，只保留 scheme、host 和 path，避免查询参数中的敏感信息进入指标
+func EndpointLabel(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "invalid"
+	}
+	return u.Scheme + "://" + u.Host + u.Path
+}
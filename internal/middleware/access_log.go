@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"tgo-rtc-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// probePaths 探针和指标抓取路径，访问日志降为 Debug 级别，避免刷屏
+var probePaths = map[string]bool{
+	"/health":  true,
+	"/livez":   true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// AccessLogMiddleware 结构化访问日志中间件（替代 gin 默认的文本日志）
+// 需注册在 RequestIDMiddleware 之后，访问日志与业务日志通过 request_id 关联
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		logger := LoggerFromContext(c.Request.Context())
+		if logger == nil {
+			return
+		}
+		status := c.Writer.Status()
+		level := zapcore.InfoLevel
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = zapcore.ErrorLevel
+		case status >= http.StatusBadRequest:
+			level = zapcore.WarnLevel
+		case probePaths[c.Request.URL.Path]:
+			level = zapcore.DebugLevel
+		}
+
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("route", c.FullPath()),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_ip", c.ClientIP()),
+			zap.Int("response_size", c.Writer.Size()),
+		}
+		if errs := c.Errors.ByType(gin.ErrorTypePrivate).String(); errs != "" {
+			fields = append(fields, zap.String("errors", errs))
+		}
+		logger.Log(level, "HTTP 请求", fields...)
+	}
+}
+
+// RecoveryMiddleware panic 恢复中间件
+// 使用请求级日志记录器记录堆栈，并返回带 request_id 的 500 响应
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		if logger := LoggerFromContext(c.Request.Context()); logger != nil {
+			logger.Error("请求处理发生 panic",
+				zap.String("path", c.Request.URL.Path),
+				zap.Any("panic", recovered),
+				zap.Stack("stacktrace"),
+			)
+		}
+		resp := models.NewErrorResponse(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		resp.RequestID = GetRequestID(c)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, resp)
+	})
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader 请求 ID 请求头/响应头
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDContextKey 请求 ID 在 gin.Context 中的存储键
+const RequestIDContextKey = "request_id"
+
+// validRequestID 上游传入的请求 ID 只接受常见字符，避免日志注入和超长值
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:\-]{1,128}$`)
+
+// loggerContextKey 请求级日志记录器在 context.Context 中的存储键
+type loggerContextKey struct{}
+
+// RequestIDMiddleware 请求 ID 中间件
+// 沿用上游传入的 X-Request-ID（网关生成的 ID 可直接串联），否则生成新的 ID 并写入响应头；
+// 同时将带 request_id（及 trace_id）字段的日志记录器注入请求上下文，业务层通过 utils.LoggerFromContext 获取
+func RequestIDMiddleware(base *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.New().String()
+		}
+		c.Set(RequestIDContextKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		fields := []zap.Field{zap.String("request_id", requestID)}
+		if spanCtx := trace.SpanContextFromContext(c.Request.Context()); spanCtx.HasTraceID() {
+			fields = append(fields, zap.String("trace_id", spanCtx.TraceID().String()))
+		}
+		ctx := ContextWithLogger(c.Request.Context(), base.With(fields...))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// GetRequestID 从上下文中获取请求 ID
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(RequestIDContextKey)
+}
+
+// ContextWithLogger 将日志记录器写入上下文
+func ContextWithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// LoggerFromContext 获取上下文中的日志记录器，不存在时返回 nil
+func LoggerFromContext(ctx context.Context) *zap.Logger {
+	if ctx == nil {
+		return nil
+	}
+	logger, _ := ctx.Value(loggerContextKey{}).(*zap.Logger)
+	return logger
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"tgo-rtc-server/internal/i18n"
+	"tgo-rtc-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ServerKeyHeader 业务服务端调用服务端接口时携带的共享密钥请求头
+const ServerKeyHeader = "X-Server-Key"
+
+// ServerKeyAuthMiddleware 校验业务服务端共享密钥，仅允许服务端到服务端调用
+// key 为空时拒绝所有请求，避免未配置密钥时接口对客户端开放
+func ServerKeyAuthMiddleware(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader(ServerKeyHeader)
+		if key != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(key)) == 1 {
+			c.Next()
+			return
+		}
+
+		if logger := LoggerFromContext(c.Request.Context()); logger != nil {
+			logger.Warn("服务端密钥校验失败",
+				zap.String("path", c.FullPath()),
+				zap.Bool("key_configured", key != ""),
+				zap.Bool("key_provided", provided != ""),
+			)
+		}
+		resp := models.NewErrorResponse(http.StatusUnauthorized,
+			i18n.Translate(GetLanguageFromContext(c), i18n.ServerKeyInvalid))
+		resp.RequestID = GetRequestID(c)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, resp)
+	}
+}
@@ -15,8 +15,9 @@ type BusinessWebhookEvent struct {
 // 业务事件类型常量
 const (
 	// 房间事件
-	BusinessEventRoomStarted  = "room.started"  // 房间已开始
-	BusinessEventRoomFinished = "room.finished" // 房间已结束
+	BusinessEventRoomStarted     = "room.started"      // 房间已开始
+	BusinessEventRoomFinished    = "room.finished"     // 房间已结束
+	BusinessEventRoomHostChanged = "room.host_changed" // 房间主持人已变更
 
 	// 参与者事件
 	BusinessEventParticipantJoined    = "participant.joined"    // 参与者已加入
@@ -25,12 +26,18 @@ const (
 	BusinessEventParticipantMissed    = "participant.missed"    // 参与者已超时
 	BusinessEventParticipantCancelled = "participant.cancelled" // 参与者已取消
 	BusinessEventParticipantInvited   = "participant.invited"   // 参与者已邀请
+	BusinessEventParticipantLobby     = "participant.lobby"     // 参与者进入等候室
+	BusinessEventParticipantAdmitted  = "participant.admitted"  // 等候室参与者已准入
+	BusinessEventParticipantDenied    = "participant.denied"    // 等候室参与者已拒绝
 )
 
 // RoomEventData 房间事件数据
 type RoomEventData struct {
 	RoomID          string   `json:"room_id"`
+	SessionID       string   `json:"session_id,omitempty"` // 会话ID（仅持久房间）
+	ChannelID       string   `json:"channel_id,omitempty"` // 绑定的外部频道/群组ID
 	Creator         string   `json:"creator"`
+	Host            string   `json:"host,omitempty"`   // 当前主持人
 	RTCType         uint8    `json:"rtc_type"`         // 0: 语音, 1: 视频
 	InviteOn        uint8    `json:"invite_on"`        // 0: 否, 1: 是
 	Status          uint8    `json:"status"`           // 房间状态
@@ -45,12 +52,29 @@ type RoomEventData struct {
 // 用于所有参与者相关事件：joined, left, rejected, timeout, missed, cancelled, invited
 type ParticipantEventData struct {
 	RoomEventData          // 嵌入房间事件数据
-	UID           string   `json:"uid"`          // 操作者 UID（加入者/离开者/拒绝者等）
-	DeviceType    string   `json:"device_type"`  // 设备类型
-	InvitedUIDs   []string `json:"invited_uids"` // 被邀请的参与者uids 事件类型为invited有值
-	MissedUIDs    []string `json:"missed_uids"`  // 超时的参与者uids 事件类型为missed有值
+	UID           string   `json:"uid"`                   // 操作者 UID（加入者/离开者/拒绝者等）
+	DeviceType    string   `json:"device_type"`           // 设备类型
+	InvitedUIDs   []string `json:"invited_uids"`          // 被邀请的参与者uids 事件类型为invited有值
+	SilentUIDs    []string `json:"silent_uids,omitempty"` // 被邀请者中处于免打扰、已静默邀请的uids（不推送，客户端不应振铃）
+	MissedUIDs    []string `json:"missed_uids"`           // 超时的参与者uids 事件类型为missed有值
+	LobbyUIDs     []string `json:"lobby_uids,omitempty"`  // 被准入/拒绝的等候室参与者uids 事件类型为admitted/denied有值
 }
 
+// RoomHostChangedEventData 房间主持人变更事件数据
+// 新旧主持人已连接会话的元数据角色会同步更新；已签发 Token 中的 RoomAdmin 授权无法修改，新主持人需重新获取 Token
+type RoomHostChangedEventData struct {
+	RoomEventData              // 嵌入房间事件数据，Host 为新主持人
+	PreviousHost        string `json:"previous_host"`        // 原主持人 UID
+	Reason              string `json:"reason"`               // 变更原因: transfer（主动转移）, auto（主持人离开后自动顺延）
+	ParticipantsUpdated bool   `json:"participants_updated"` // 是否已更新新旧主持人在 LiveKit 中已连接会话的角色元数据（失败时客户端需自行刷新）
+}
+
+// 主持人变更原因常量
+const (
+	HostChangeReasonTransfer = "transfer" // 主持人主动转移
+	HostChangeReasonAuto     = "auto"     // 主持人离开，按加入顺序自动顺延
+)
+
 // BusinessWebhookRequest 业务 webhook 请求
 type BusinessWebhookRequest struct {
 	EventType  string      `json:"event_type"`
@@ -71,15 +95,15 @@ type BusinessWebhookResponse struct {
 // BusinessWebhookLog 业务 webhook 日志
 type BusinessWebhookLog struct {
 	ID        int64     `gorm:"primaryKey" json:"id"`
-	EventType string    `json:"event_type"`
-	EventID   string    `json:"event_id"`
-	URL       string    `json:"url"`
+	EventType string    `gorm:"index:idx_event_type" json:"event_type"`
+	EventID   string    `gorm:"index:idx_event_id" json:"event_id"`
+	URL       string    `gorm:"index:idx_url" json:"url"`
 	Status    int       `json:"status"`   // HTTP 状态码
 	Request   string    `json:"request"`  // 请求体
 	Response  string    `json:"response"` // 响应体
 	Error     string    `json:"error"`    // 错误信息
 	Retry     int       `json:"retry"`    // 重试次数
-	CreatedAt time.Time `json:"created_at"`
+	CreatedAt time.Time `gorm:"index:idx_created_at" json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
@@ -87,3 +111,15 @@ type BusinessWebhookLog struct {
 func (BusinessWebhookLog) TableName() string {
 	return "business_webhook_log"
 }
+
+// EventStreamTokenRequest 签发事件流 Token 请求
+type EventStreamTokenRequest struct {
+	UID       string `json:"uid" binding:"required"`
+	ExpiresIn int    `json:"expires_in"` // 有效期（秒），不传使用默认值
+}
+
+// EventStreamTokenResp 签发事件流 Token 响应
+type EventStreamTokenResp struct {
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"` // 过期时间戳（秒）
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// UserBlock 用户屏蔽关系（uid 屏蔽了 blocked_uid，blocked_uid 呼叫/邀请 uid 时静默拦截）
+type UserBlock struct {
+	ID         int       `gorm:"primaryKey" json:"id"`
+	UID        string    `gorm:"column:uid;size:40;not null;default:'';uniqueIndex:uk_uid_blocked_uid,priority:1" json:"uid"`
+	BlockedUID string    `gorm:"column:blocked_uid;size:40;not null;default:'';uniqueIndex:uk_uid_blocked_uid,priority:2;index:idx_blocked_uid" json:"blocked_uid"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// TableName 指定表名
+func (UserBlock) TableName() string {
+	return "rtc_user_block"
+}
+
+// CallPolicyLog 呼叫策略拦截记录
+type CallPolicyLog struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	RoomID    string    `gorm:"column:room_id;size:40;not null;default:'';index:idx_room_id" json:"room_id"`
+	Caller    string    `gorm:"column:caller;size:40;not null;default:'';index:idx_caller" json:"caller"`
+	Callee    string    `gorm:"column:callee;size:40;not null;default:'';index:idx_callee" json:"callee"`
+	Source    string    `gorm:"column:source;size:20;not null;default:''" json:"source"` // 拦截来源: block_list, callout
+	Reason    string    `gorm:"column:reason;size:255;not null;default:''" json:"reason"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// TableName 指定表名
+func (CallPolicyLog) TableName() string {
+	return "rtc_call_policy_log"
+}
+
+// 呼叫策略拦截来源
+const (
+	CallPolicySourceBlockList = "block_list" // 被叫的本地屏蔽列表
+	CallPolicySourceCallout   = "callout"    // 业务后端呼叫策略接口
+)
+
+// BlockUserRequest 屏蔽/取消屏蔽用户请求
+type BlockUserRequest struct {
+	UID        string `json:"uid" binding:"required"`         // 操作者 UID
+	BlockedUID string `json:"blocked_uid" binding:"required"` // 被屏蔽的 UID
+}
+
+// BlockListResp 屏蔽列表响应
+type BlockListResp struct {
+	UID         string   `json:"uid"`
+	BlockedUIDs []string `json:"blocked_uids"`
+}
+
+// CallPolicyRequest 呼叫策略接口请求体（POST CALL_POLICY_URL）
+type CallPolicyRequest struct {
+	RoomID  string   `json:"room_id"`
+	Caller  string   `json:"caller"`
+	Callees []string `json:"callees"`
+	RTCType uint8    `json:"rtc_type"`
+}
+
+// CallPolicyResponse 呼叫策略接口响应体，未返回的被叫视为允许
+type CallPolicyResponse struct {
+	Results []CallPolicyResult `json:"results"`
+}
+
+// CallPolicyResult 单个被叫的呼叫策略结果
+type CallPolicyResult struct {
+	UID     string `json:"uid"`
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
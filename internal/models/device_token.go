@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// DeviceToken 设备推送 Token 模型（每个用户每种设备类型一条记录）
+type DeviceToken struct {
+	ID         int       `gorm:"primaryKey" json:"id"`
+	UID        string    `gorm:"column:uid;size:40;not null;default:'';uniqueIndex:uk_uid_device_type,priority:1" json:"uid"`
+	DeviceType string    `gorm:"column:device_type;size:20;not null;default:'';uniqueIndex:uk_uid_device_type,priority:2" json:"device_type"`
+	Provider   string    `gorm:"column:provider;size:20;not null;default:''" json:"provider"` // 推送通道: apns, fcm, hms
+	Token      string    `gorm:"column:token;size:255;not null;default:''" json:"token"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (DeviceToken) TableName() string {
+	return "rtc_device_token"
+}
+
+// RegisterDeviceTokenRequest 注册设备推送 Token 请求
+type RegisterDeviceTokenRequest struct {
+	UID        string `json:"uid" binding:"required"`
+	DeviceType string `json:"device_type" binding:"required"` // 设备类型
+	Provider   string `json:"provider" binding:"required"`    // 推送通道: apns（VoIP）, fcm, hms
+	Token      string `json:"token" binding:"required"`       // 推送通道下发的设备 Token
+}
+
+// UnregisterDeviceTokenRequest 注销设备推送 Token 请求
+type UnregisterDeviceTokenRequest struct {
+	UID        string `json:"uid" binding:"required"`
+	DeviceType string `json:"device_type" binding:"required"`
+}
@@ -0,0 +1,33 @@
+package models
+
+// 健康检查状态
+const (
+	HealthStatusOK        = "ok"        // 正常
+	HealthStatusDegraded  = "degraded"  // 非关键依赖异常，仍可接收流量
+	HealthStatusUnhealthy = "unhealthy" // 关键依赖异常，不可接收流量
+	HealthStatusSkipped   = "skipped"   // 未启用该项检查
+)
+
+// HealthCheck 单项依赖检查结果
+type HealthCheck struct {
+	Status    string      `json:"status"`            // ok, unhealthy, skipped
+	Critical  bool        `json:"critical"`          // 是否为关键依赖（异常时就绪检查失败）
+	LatencyMs int64       `json:"latency_ms"`        // 检查耗时（毫秒）
+	Error     string      `json:"error,omitempty"`   // 失败原因
+	Details   interface{} `json:"details,omitempty"` // 附加信息（如迁移统计、各 webhook 端点结果）
+}
+
+// HealthReport 就绪检查结果
+type HealthReport struct {
+	Status    string                  `json:"status"` // ok, degraded, unhealthy
+	Timestamp int64                   `json:"timestamp"`
+	Checks    map[string]*HealthCheck `json:"checks"`
+}
+
+// WebhookEndpointHealth 单个业务 webhook 端点的连通性
+type WebhookEndpointHealth struct {
+	URL        string `json:"url"`
+	Status     string `json:"status"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// InviteLink 房间邀请链接模型
+type InviteLink struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	LinkID    string    `gorm:"column:link_id;size:40;not null;default:'';uniqueIndex:uk_link_id" json:"link_id"`
+	RoomID    string    `gorm:"column:room_id;size:40;not null;default:'';index:idx_room_id" json:"room_id"`
+	Creator   string    `gorm:"column:creator;size:40;not null;default:''" json:"creator"`
+	Role      string    `gorm:"column:role;size:20;not null;default:''" json:"role"`
+	MaxUses   int       `gorm:"column:max_uses;not null;default:0" json:"max_uses"` // 0 表示不限次数
+	UsedCount int       `gorm:"column:used_count;not null;default:0" json:"used_count"`
+	Status    uint8     `gorm:"column:status;not null;default:0" json:"status"` // 0: 有效, 1: 已撤销
+	ExpiresAt time.Time `gorm:"column:expires_at" json:"expires_at"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (InviteLink) TableName() string {
+	return "rtc_invite_link"
+}
+
+// InviteLinkStatus 邀请链接状态常量
+const (
+	InviteLinkActive  = 0 // 有效
+	InviteLinkRevoked = 1 // 已撤销
+)
+
+// CreateInviteLinkRequest 创建邀请链接请求
+// room_id 从 URL 参数中获取: POST /api/v1/rooms/:room_id/invite-links
+type CreateInviteLinkRequest struct {
+	RoomID    string `json:"room_id"`                // 从 URL 参数中设置
+	UID       string `json:"uid" binding:"required"` // 创建者 UID（房间主持人）
+	Role      string `json:"role"`                   // 通过链接加入的角色: participant(默认), viewer
+	ExpiresIn int    `json:"expires_in"`             // 有效期（秒），不传使用默认值
+	MaxUses   int    `json:"max_uses"`               // 最多使用次数，0 表示不限
+}
+
+// InviteLinkResp 邀请链接响应
+type InviteLinkResp struct {
+	Code      string `json:"code"`          // 签名后的邀请码
+	URL       string `json:"url,omitempty"` // 邀请链接（配置了 INVITE_LINK_BASE_URL 时返回）
+	RoomID    string `json:"room_id"`
+	Role      string `json:"role"`
+	MaxUses   int    `json:"max_uses"`
+	ExpiresAt string `json:"expires_at"` // yyyy-mm-dd hh:mm:ss 格式
+}
+
+// RedeemInviteLinkRequest 使用邀请码加入房间请求
+type RedeemInviteLinkRequest struct {
+	Code       string `json:"code" binding:"required"`
+	Name       string `json:"name"` // 访客显示名称
+	DeviceType string `json:"device_type"`
+}
@@ -7,10 +7,10 @@ import (
 // Participant 参与者模型
 type Participant struct {
 	ID         int       `gorm:"primaryKey" json:"id"`
-	RoomID     string    `gorm:"column:room_id;size:40;not null;default:'';index:idx_room_uid,unique" json:"room_id"`
-	UID        string    `gorm:"column:uid;size:40;not null;default:'';index:idx_uid;index:idx_room_uid,unique" json:"uid"`
+	RoomID     string    `gorm:"column:room_id;size:40;not null;default:'';uniqueIndex:uk_room_uid,priority:1;index:idx_room_id" json:"room_id"`
+	UID        string    `gorm:"column:uid;size:40;not null;default:'';uniqueIndex:uk_room_uid,priority:2;index:idx_uid" json:"uid"`
 	DeviceType string    `gorm:"column:device_type;size:20;not null;default:''" json:"device_type"` // 设备类型
-	Status     uint8     `gorm:"column:status;not null;default:0" json:"status"`                    // 0-6: 见常量定义
+	Status     uint8     `gorm:"column:status;not null;default:0;index:idx_status" json:"status"`   // 0-9: 见常量定义
 	JoinTime   int64     `gorm:"column:join_time;not null;default:0" json:"join_time"`
 	LeaveTime  int64     `gorm:"column:leave_time;not null;default:0" json:"leave_time"`
 	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
@@ -24,21 +24,33 @@ func (Participant) TableName() string {
 
 // ParticipantStatus 参与者状态常量
 const (
-	ParticipantStatusInviting  = 0 // 邀请中
-	ParticipantStatusJoined    = 1 // 已加入
-	ParticipantStatusRejected  = 2 // 已拒绝
-	ParticipantStatusHangup    = 3 // 已挂断
-	ParticipantStatusMissed    = 4 // 超时未加入
-	ParticipantStatusBusy      = 5 // 通话中未接听
-	ParticipantStatusCancelled = 6 // 已取消
+	ParticipantStatusInviting    = 0  // 邀请中
+	ParticipantStatusJoined      = 1  // 已加入
+	ParticipantStatusRejected    = 2  // 已拒绝
+	ParticipantStatusHangup      = 3  // 已挂断
+	ParticipantStatusMissed      = 4  // 超时未加入
+	ParticipantStatusBusy        = 5  // 通话中未接听
+	ParticipantStatusCancelled   = 6  // 已取消
+	ParticipantStatusLobby       = 7  // 等候室中，等待主持人准入
+	ParticipantStatusBlocked     = 8  // 被呼叫策略拦截（静默处理：不振铃、不通知被叫，事件中不出现）
+	ParticipantStatusUnavailable = 9  // 被叫免打扰或离线，未振铃
+	ParticipantStatusDenied      = 10 // 主持人拒绝进入等候室，本次会话内不能再次进入
 )
 
 // JoinRoomRequest 加入房间请求
 // room_id 从 URL 参数中获取: POST /api/v1/rooms/:room_id/join
 type JoinRoomRequest struct {
-	RoomID     string `json:"room_id"`    // 从 URL 参数中设置
+	RoomID     string `json:"room_id"` // 从 URL 参数中设置
 	UID        string `json:"uid" binding:"required"`
 	DeviceType string `json:"device_type"` // 设备类型
+
+	// 以下字段仅供服务内部使用（如通过邀请链接加入），不从请求体中解析
+	Invited bool   `json:"-"` // 已持有有效邀请，跳过邀请/等候室检查
+	Role    string `json:"-"` // 参与者角色
+	Name    string `json:"-"` // 显示名称
+	Guest   bool   `json:"-"` // 是否为访客
+
+	IdempotencyKey string `json:"-"` // 从 Idempotency-Key 请求头中设置
 }
 
 // JoinRoomResponse 加入房间响应（别名，保持向后兼容）
@@ -55,6 +67,15 @@ type LeaveRoomRequest struct {
 type InviteParticipantRequest struct {
 	RoomID string   `json:"room_id"`
 	UIDs   []string `json:"uids" binding:"required"`
+	UID    string   `json:"uid"` // 邀请人 UID（可选，未传时按房间主持人计入邀请频率限制）
+
+	IdempotencyKey string `json:"-"` // 从 Idempotency-Key 请求头中设置
+}
+
+// InviteParticipantResponse 邀请参与者响应
+type InviteParticipantResponse struct {
+	SilentUIDs      []string `json:"silent_uids,omitempty"`      // 免打扰的被邀请者，已静默邀请（不推送、不应振铃）
+	UnavailableUIDs []string `json:"unavailable_uids,omitempty"` // 离线或免打扰拒接的被邀请者，未邀请
 }
 
 // GetParticipantsResponse 获取参与者列表响应
@@ -82,3 +103,19 @@ type GetUserAvailableRoomsRequest struct {
 
 // GetUserAvailableRoomsResponse 获取用户可加入的房间列表响应（RoomResp 数组）
 type GetUserAvailableRoomsResponse []RoomResp
+
+// LobbyDecisionRequest 主持人处理等候室请求（准入/拒绝）
+// room_id 从 URL 参数中获取: POST /api/v1/rooms/:room_id/lobby/admit | deny
+type LobbyDecisionRequest struct {
+	RoomID string   `json:"room_id"`                 // 从 URL 参数中设置
+	UID    string   `json:"uid" binding:"required"`  // 操作者（主持人）UID
+	UIDs   []string `json:"uids" binding:"required"` // 需要准入/拒绝的用户 UID 列表
+}
+
+// TransferHostRequest 转移主持人请求
+// room_id 从 URL 参数中获取: POST /api/v1/rooms/:room_id/host
+type TransferHostRequest struct {
+	RoomID  string `json:"room_id"`                     // 从 URL 参数中设置
+	UID     string `json:"uid" binding:"required"`      // 当前主持人 UID
+	NewHost string `json:"new_host" binding:"required"` // 新主持人 UID（必须已加入房间）
+}
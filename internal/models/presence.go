@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// UserPresence 用户在线/可用状态（由客户端或业务服务端设置）
+type UserPresence struct {
+	ID        int        `gorm:"primaryKey" json:"id"`
+	UID       string     `gorm:"column:uid;size:40;not null;default:'';uniqueIndex:uk_uid" json:"uid"`
+	Status    string     `gorm:"column:status;size:16;not null;default:''" json:"status"` // 可用状态: available, dnd, away, offline
+	ExpiresAt *time.Time `gorm:"column:expires_at" json:"expires_at"`                     // 过期时间（如免打扰到某个时间），为空表示长期有效
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (UserPresence) TableName() string {
+	return "rtc_user_presence"
+}
+
+// 用户可用状态
+const (
+	PresenceAvailable = "available" // 可用，正常振铃
+	PresenceDND       = "dnd"       // 免打扰，按 PRESENCE_DND_POLICY 静默邀请或直接返回不可用
+	PresenceAway      = "away"      // 离开，正常振铃
+	PresenceOffline   = "offline"   // 离线，不振铃，直接返回不可用
+)
+
+// 免打扰用户被呼叫/邀请时的处理策略
+const (
+	PresenceDNDPolicySilent = "silent" // 静默邀请：记录邀请但不推送、不振铃，客户端同步时可见
+	PresenceDNDPolicyReject = "reject" // 直接返回不可用，不邀请
+)
+
+// SetPresenceRequest 设置用户可用状态请求
+type SetPresenceRequest struct {
+	UID      string `json:"uid" binding:"required"`
+	Status   string `json:"status" binding:"required,oneof=available dnd away offline"`
+	Duration int    `json:"duration" binding:"min=0"` // 有效时长（秒），0 表示长期有效，到期后恢复为 available
+}
+
+// PresenceResp 用户可用状态响应
+type PresenceResp struct {
+	UID       string `json:"uid"`
+	Status    string `json:"status"`
+	ExpiresAt int64  `json:"expires_at,omitempty"` // 过期时间（Unix 秒），长期有效时不返回
+}
@@ -4,8 +4,9 @@ import "net/http"
 
 // ErrorResponse 统一错误响应结构
 type ErrorResponse struct {
-	Code    int    `json:"code"`    // 错误代码
-	Message string `json:"message"` // 错误消息
+	Code      int    `json:"code"`                 // 错误代码
+	Message   string `json:"message"`              // 错误消息
+	RequestID string `json:"request_id,omitempty"` // 请求 ID，反馈问题时提供以便排查
 }
 
 // SuccessResponse 统一成功响应结构（带数据）
@@ -6,15 +6,20 @@ import (
 
 // Room 房间模型
 type Room struct {
-	ID      int    `gorm:"primaryKey" json:"id"`
-	Creator string `gorm:"column:creator;size:40;not null;default:''" json:"creator"`
-	RoomID            string    `gorm:"column:room_id;size:40;not null;default:'';uniqueIndex" json:"room_id"`
-	RTCType           uint8     `gorm:"column:rtc_type;not null;default:0" json:"rtc_type"`                 // 0: 语音, 1: 视频
-	InviteOn          uint8     `gorm:"column:invite_on;not null;default:0" json:"invite_on"`               // 0: 否, 1: 是
-	Status            uint8     `gorm:"column:status;not null;default:0" json:"status"`                     // 0: 未开始, 1: 进行中, 2: 已结束, 3: 已取消
-	MaxParticipants   int       `gorm:"column:max_participants;not null;default:2" json:"max_participants"` // 最多参与者数
-	CreatedAt         time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
-	UpdatedAt         time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+	ID              int       `gorm:"primaryKey" json:"id"`
+	Creator         string    `gorm:"column:creator;size:40;not null;default:'';index:idx_creator" json:"creator"`
+	RoomID          string    `gorm:"column:room_id;size:40;not null;default:'';uniqueIndex:uk_room_id" json:"room_id"`
+	RTCType         uint8     `gorm:"column:rtc_type;not null;default:0" json:"rtc_type"`                                   // 0: 语音, 1: 视频
+	InviteOn        uint8     `gorm:"column:invite_on;not null;default:0" json:"invite_on"`                                 // 0: 否, 1: 是
+	Status          uint8     `gorm:"column:status;not null;default:0;index:idx_status" json:"status"`                      // 0: 未开始, 1: 进行中, 2: 已结束, 3: 已取消
+	MaxParticipants int       `gorm:"column:max_participants;not null;default:2" json:"max_participants"`                   // 最多参与者数
+	Persistent      uint8     `gorm:"column:persistent;not null;default:0" json:"persistent"`                               // 0: 一次性房间, 1: 持久房间（可重复开始）
+	SessionID       string    `gorm:"column:session_id;size:40;not null;default:''" json:"session_id"`                      // 当前会话ID（仅持久房间）
+	ChannelID       string    `gorm:"column:channel_id;size:64;not null;default:'';index:idx_channel_id" json:"channel_id"` // 绑定的外部频道/群组ID
+	Host            string    `gorm:"column:host;size:40;not null;default:''" json:"host"`                                  // 当前主持人（默认为创建者，可转移）
+	LastEventAt     int64     `gorm:"column:last_event_at;not null;default:0" json:"last_event_at"`                         // 最后应用的 LiveKit 事件时间（Unix 秒），用于识别乱序到达的事件
+	CreatedAt       time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
 }
 
 // TableName 指定表名
@@ -22,6 +27,27 @@ func (Room) TableName() string {
 	return "rtc_room"
 }
 
+// ChannelLock 频道建房锁记录，每个频道一行
+// 创建绑定频道的房间时在事务内锁定该行，同一频道的建房请求在数据库层串行执行
+type ChannelLock struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	ChannelID string    `gorm:"column:channel_id;size:64;not null;default:'';uniqueIndex:uk_channel_id" json:"channel_id"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// TableName 指定表名
+func (ChannelLock) TableName() string {
+	return "rtc_channel_lock"
+}
+
+// HostUID 返回房间当前主持人，未设置时（历史数据）为创建者
+func (r *Room) HostUID() string {
+	if r.Host != "" {
+		return r.Host
+	}
+	return r.Creator
+}
+
 // RoomStatus 房间状态常量
 const (
 	RoomStatusNotStarted = 0 // 未开始
@@ -39,35 +65,58 @@ const (
 	RTCTypeVideo = 1 // 视频
 )
 
+// RoomPersistent 持久房间常量
+const (
+	RoomOneTime    = 0 // 一次性房间（默认）
+	RoomPersistent = 1 // 持久房间，结束后可使用同一个 room_id 重新开始
+)
+
 // InviteStatus 邀请状态常量
 const (
 	InviteDisabled = 0 // 不开启邀请
 	InviteEnabled  = 1 // 开启邀请
+	InviteLobby    = 2 // 开启等候室：未被邀请的用户加入时进入等候室，由主持人准入
 )
 
+// IdempotencyKeyHeader 幂等键请求头，创建房间、邀请和加入房间时携带，重试请求返回首次请求的响应
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 // CreateRoomRequest 创建房间请求
 type CreateRoomRequest struct {
-	Creator string `json:"creator" binding:"required"`
-	RoomID            string   `json:"room_id"`          // 可选，不传则自动生成 UUID
-	RTCType           uint8    `json:"rtc_type"`         // 0: 语音, 1: 视频
-	InviteOn          uint8    `json:"invite_on"`        // 0: 否, 1: 是
-	MaxParticipants   int      `json:"max_participants"` // 最多参与者数，默认 2
-	UIDs              []string `json:"uids"`             // 邀请的用户 ID 列表
-	DeviceType        string   `json:"device_type"`      // 设备类型
+	Creator         string   `json:"creator" binding:"required"`
+	RoomID          string   `json:"room_id"`          // 可选，不传则自动生成 UUID
+	RTCType         uint8    `json:"rtc_type"`         // 0: 语音, 1: 视频
+	InviteOn        uint8    `json:"invite_on"`        // 0: 否, 1: 是
+	MaxParticipants int      `json:"max_participants"` // 最多参与者数，默认 2
+	UIDs            []string `json:"uids"`             // 邀请的用户 ID 列表
+	DeviceType      string   `json:"device_type"`      // 设备类型
+	Persistent      uint8    `json:"persistent"`       // 0: 一次性房间, 1: 持久房间
+	ChannelID       string   `json:"channel_id"`       // 可选，绑定的外部频道/群组ID，同一频道同时只有一个进行中的房间
+
+	IdempotencyKey string `json:"-"` // 从 Idempotency-Key 请求头中设置
 }
 
 // RoomResp 房间响应（创建房间和加入房间共用）
 type RoomResp struct {
-	RoomID  string `json:"room_id"`
-	Creator           string   `json:"creator"`
-	Token             string   `json:"token"`
-	URL               string   `json:"url"`
-	Status            uint8    `json:"status"`
-	CreatedAt         string   `json:"created_at"` // yyyy-mm-dd hh:mm:ss 格式
-	MaxParticipants   int      `json:"max_participants"`
-	Timeout           int      `json:"timeout"`  // 单位：秒
-	UIDs              []string `json:"uids"`     // 参与者uids
-	RTCType           uint8    `json:"rtc_type"` // 0: 语音, 1: 视频
+	RoomID          string   `json:"room_id"`
+	UID             string   `json:"uid,omitempty"` // 当前用户 UID（访客加入时为系统分配的访客身份）
+	Creator         string   `json:"creator"`
+	Host            string   `json:"host,omitempty"` // 当前主持人
+	Token           string   `json:"token"`
+	URL             string   `json:"url"`
+	Status          uint8    `json:"status"`
+	CreatedAt       string   `json:"created_at"` // yyyy-mm-dd hh:mm:ss 格式
+	MaxParticipants int      `json:"max_participants"`
+	Timeout         int      `json:"timeout"`              // 单位：秒
+	UIDs            []string `json:"uids"`                 // 参与者uids
+	RTCType         uint8    `json:"rtc_type"`             // 0: 语音, 1: 视频
+	SessionID       string   `json:"session_id,omitempty"` // 当前会话ID（仅持久房间）
+	ChannelID       string   `json:"channel_id,omitempty"` // 绑定的外部频道/群组ID
+	InLobby         bool     `json:"in_lobby,omitempty"`   // 是否在等候室中等待主持人准入（此时不返回 Token）
+
+	SilentUIDs      []string          `json:"silent_uids,omitempty"`      // 免打扰的被叫，已静默邀请（不推送、不应振铃）
+	UnavailableUIDs []string          `json:"unavailable_uids,omitempty"` // 离线或免打扰拒接的被叫，未邀请
+	Presence        map[string]string `json:"presence,omitempty"`         // 参与者当前可用状态（uid -> available/dnd/away/offline），同步接口返回
 }
 
 // CreateRoomResponse 创建房间响应（别名，保持向后兼容）
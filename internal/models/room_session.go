@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// RoomSession 房间会话模型
+// 持久房间每次开始到结束记为一个会话，会话拥有独立的参与者和通话时长
+type RoomSession struct {
+	ID         int        `gorm:"primaryKey" json:"id"`
+	SessionID  string     `gorm:"column:session_id;size:40;not null;default:'';uniqueIndex:uk_session_id" json:"session_id"`
+	RoomID     string     `gorm:"column:room_id;size:40;not null;default:'';index:idx_room_id" json:"room_id"`
+	Creator    string     `gorm:"column:creator;size:40;not null;default:''" json:"creator"`
+	RTCType    uint8      `gorm:"column:rtc_type;not null;default:0" json:"rtc_type"`
+	Status     uint8      `gorm:"column:status;not null;default:0" json:"status"` // 与房间状态一致
+	Duration   int64      `gorm:"column:duration;not null;default:0" json:"duration"`
+	FinishedAt *time.Time `gorm:"column:finished_at" json:"finished_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (RoomSession) TableName() string {
+	return "rtc_room_session"
+}
+
+// RoomSessionParticipant 会话参与者历史记录
+// 持久房间重新开始时，上一个会话的参与者从 rtc_participant 移入此表
+type RoomSessionParticipant struct {
+	ID         int       `gorm:"primaryKey" json:"id"`
+	SessionID  string    `gorm:"column:session_id;size:40;not null;default:'';index:idx_session_id" json:"session_id"`
+	RoomID     string    `gorm:"column:room_id;size:40;not null;default:''" json:"room_id"`
+	UID        string    `gorm:"column:uid;size:40;not null;default:''" json:"uid"`
+	DeviceType string    `gorm:"column:device_type;size:20;not null;default:''" json:"device_type"`
+	Status     uint8     `gorm:"column:status;not null;default:0" json:"status"`
+	JoinTime   int64     `gorm:"column:join_time;not null;default:0" json:"join_time"`
+	LeaveTime  int64     `gorm:"column:leave_time;not null;default:0" json:"leave_time"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// TableName 指定表名
+func (RoomSessionParticipant) TableName() string {
+	return "rtc_room_session_participant"
+}
@@ -0,0 +1,127 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// APNsConfig APNs 推送配置（Token 鉴权方式）
+type APNsConfig struct {
+	Endpoint   string // 默认 https://api.push.apple.com，开发环境使用 https://api.sandbox.push.apple.com
+	KeyID      string // .p8 密钥 ID
+	TeamID     string // 开发者团队 ID
+	BundleID   string // 应用 Bundle ID，VoIP 推送的 topic 为 <BundleID>.voip
+	PrivateKey []byte // .p8 私钥内容（PEM）
+}
+
+// apnsProviderTokenTTL APNs Provider Token 有效期（Apple 要求 20~60 分钟内刷新）
+const apnsProviderTokenTTL = 50 * time.Minute
+
+// APNsProvider Apple PushKit VoIP 推送
+// 来电和来电结束都通过 VoIP 推送送达，客户端需在收到结束推送时上报并立即结束 CallKit 来电
+type APNsProvider struct {
+	cfg    APNsConfig
+	key    *ecdsa.PrivateKey
+	client *http.Client
+	token  cachedToken
+}
+
+// NewAPNsProvider 创建 APNs VoIP 推送通道
+func NewAPNsProvider(cfg APNsConfig, client *http.Client) (*APNsProvider, error) {
+	key, err := jwt.ParseECPrivateKeyFromPEM(cfg.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("解析 APNs 私钥失败: %w", err)
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://api.push.apple.com"
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	return &APNsProvider{cfg: cfg, key: key, client: client}, nil
+}
+
+// Name 推送通道名称
+func (p *APNsProvider) Name() string {
+	return ProviderAPNs
+}
+
+// Send 发送 VoIP 推送
+func (p *APNsProvider) Send(ctx context.Context, token string, n *Notification) error {
+	authToken, err := p.token.get(p.signProviderToken)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{},
+	}
+	for k, v := range n.Data() {
+		payload[k] = v
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.Endpoint+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+authToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("apns-topic", p.cfg.BundleID+".voip")
+	req.Header.Set("apns-push-type", "voip")
+	req.Header.Set("apns-priority", "10")
+	req.Header.Set("apns-collapse-id", n.RoomID)
+	expiration := int64(0)
+	if n.TTL > 0 {
+		expiration = time.Now().Add(time.Duration(n.TTL) * time.Second).Unix()
+	}
+	req.Header.Set("apns-expiration", strconv.FormatInt(expiration, 10))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("APNs 请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var result struct {
+		Reason string `json:"reason"`
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	_ = json.Unmarshal(respBody, &result)
+	switch {
+	case resp.StatusCode == http.StatusGone,
+		result.Reason == "BadDeviceToken", result.Reason == "Unregistered", result.Reason == "DeviceTokenNotForTopic":
+		return ErrInvalidToken
+	case resp.StatusCode == http.StatusForbidden && result.Reason == "ExpiredProviderToken":
+		p.token.invalidate()
+	}
+	return fmt.Errorf("APNs 推送失败: status=%d reason=%s", resp.StatusCode, result.Reason)
+}
+
+// signProviderToken 生成 APNs Provider Token（ES256 JWT）
+func (p *APNsProvider) signProviderToken() (string, time.Duration, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": p.cfg.TeamID,
+		"iat": time.Now().Unix(),
+	})
+	token.Header["kid"] = p.cfg.KeyID
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		return "", 0, fmt.Errorf("生成 APNs Provider Token 失败: %w", err)
+	}
+	return signed, apnsProviderTokenTTL, nil
+}
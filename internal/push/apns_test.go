@@ -0,0 +1,186 @@
+package push
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newTestAPNs 创建指向本地 HTTP 替身的 APNs 通道
+func newTestAPNs(t *testing.T, handler http.HandlerFunc) (*APNsProvider, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成 EC 私钥失败: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("序列化 EC 私钥失败: %v", err)
+	}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	p, err := NewAPNsProvider(APNsConfig{
+		Endpoint:   srv.URL + "/",
+		KeyID:      "KEY123",
+		TeamID:     "TEAM123",
+		BundleID:   "com.example.app",
+		PrivateKey: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}),
+	}, srv.Client())
+	if err != nil {
+		t.Fatalf("NewAPNsProvider() error: %v", err)
+	}
+	return p, key
+}
+
+func testNotification() *Notification {
+	return &Notification{
+		Type:    TypeIncomingCall,
+		RoomID:  "room_1",
+		Caller:  "alice",
+		RTCType: 1,
+		TTL:     30,
+	}
+}
+
+func TestAPNsSend(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		gotReq  *http.Request
+		payload map[string]interface{}
+	)
+	p, key := newTestAPNs(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		gotReq = r
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+	})
+
+	if err := p.Send(context.Background(), "device-token", testNotification()); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if gotReq.URL.Path != "/3/device/device-token" {
+		t.Errorf("path = %q, want /3/device/device-token", gotReq.URL.Path)
+	}
+	for header, want := range map[string]string{
+		"apns-topic":       "com.example.app.voip",
+		"apns-push-type":   "voip",
+		"apns-priority":    "10",
+		"apns-collapse-id": "room_1",
+	} {
+		if got := gotReq.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	if gotReq.Header.Get("apns-expiration") == "0" {
+		t.Error("apns-expiration = 0, want expiration from TTL")
+	}
+	if payload["room_id"] != "room_1" || payload["type"] != TypeIncomingCall || payload["rtc_type"] != "1" {
+		t.Errorf("payload = %v", payload)
+	}
+
+	// Provider Token 为 ES256 签名的 JWT，携带 kid 和 team id
+	auth := strings.TrimPrefix(gotReq.Header.Get("Authorization"), "bearer ")
+	token, err := jwt.Parse(auth, func(token *jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"ES256"}))
+	if err != nil {
+		t.Fatalf("解析 Provider Token 失败: %v", err)
+	}
+	if token.Header["kid"] != "KEY123" {
+		t.Errorf("kid = %v, want KEY123", token.Header["kid"])
+	}
+	if iss, _ := token.Claims.GetIssuer(); iss != "TEAM123" {
+		t.Errorf("iss = %q, want TEAM123", iss)
+	}
+}
+
+func TestAPNsInvalidToken(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		reason string
+	}{
+		{"gone", http.StatusGone, "Unregistered"},
+		{"bad device token", http.StatusBadRequest, "BadDeviceToken"},
+		{"wrong topic", http.StatusBadRequest, "DeviceTokenNotForTopic"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, _ := newTestAPNs(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_ = json.NewEncoder(w).Encode(map[string]string{"reason": tc.reason})
+			})
+			if err := p.Send(context.Background(), "device-token", testNotification()); !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("Send() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestAPNsExpiredProviderToken(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		auths []string
+	)
+	p, _ := newTestAPNs(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auths = append(auths, r.Header.Get("Authorization"))
+		first := len(auths) == 1
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(map[string]string{"reason": "ExpiredProviderToken"})
+		}
+	})
+
+	err := p.Send(context.Background(), "device-token", testNotification())
+	if err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Send() error = %v, want provider token error", err)
+	}
+	if err := p.Send(context.Background(), "device-token", testNotification()); err != nil {
+		t.Fatalf("retry Send() error: %v", err)
+	}
+
+	// Provider Token 过期后重新签名
+	mu.Lock()
+	defer mu.Unlock()
+	if len(auths) != 2 || auths[0] == auths[1] {
+		t.Fatalf("authorization headers = %v, want a re-signed provider token", auths)
+	}
+}
+
+func TestAPNsServerError(t *testing.T) {
+	p, _ := newTestAPNs(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]string{"reason": "InternalServerError"})
+	})
+	err := p.Send(context.Background(), "device-token", testNotification())
+	if err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Send() error = %v, want server error", err)
+	}
+	if !strings.Contains(err.Error(), "InternalServerError") {
+		t.Errorf("error = %v, want reason in message", err)
+	}
+}
+
+func TestNewAPNsProviderInvalidKey(t *testing.T) {
+	if _, err := NewAPNsProvider(APNsConfig{PrivateKey: []byte("not a key")}, http.DefaultClient); err == nil {
+		t.Fatal("NewAPNsProvider() error = nil, want parse error")
+	}
+}
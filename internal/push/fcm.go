@@ -0,0 +1,180 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fcmScope FCM HTTP v1 接口所需的 OAuth2 权限
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// FCMConfig FCM HTTP v1 推送配置
+type FCMConfig struct {
+	Endpoint       string // 默认 https://fcm.googleapis.com
+	ProjectID      string // Firebase 项目 ID，为空时使用服务账号中的 project_id
+	ServiceAccount []byte // 服务账号 JSON 内容
+}
+
+// fcmServiceAccount 服务账号 JSON 中使用到的字段
+type fcmServiceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCMProvider Firebase Cloud Messaging 推送（HTTP v1 接口，高优先级数据消息）
+type FCMProvider struct {
+	endpoint string
+	account  fcmServiceAccount
+	key      *rsa.PrivateKey
+	client   *http.Client
+	token    cachedToken
+}
+
+// NewFCMProvider 创建 FCM 推送通道
+func NewFCMProvider(cfg FCMConfig, client *http.Client) (*FCMProvider, error) {
+	var account fcmServiceAccount
+	if err := json.Unmarshal(cfg.ServiceAccount, &account); err != nil {
+		return nil, fmt.Errorf("解析 FCM 服务账号失败: %w", err)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("解析 FCM 服务账号私钥失败: %w", err)
+	}
+	if cfg.ProjectID != "" {
+		account.ProjectID = cfg.ProjectID
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://fcm.googleapis.com"
+	}
+	return &FCMProvider{
+		endpoint: strings.TrimRight(cfg.Endpoint, "/"),
+		account:  account,
+		key:      key,
+		client:   client,
+	}, nil
+}
+
+// Name 推送通道名称
+func (p *FCMProvider) Name() string {
+	return ProviderFCM
+}
+
+// Send 发送数据消息
+func (p *FCMProvider) Send(ctx context.Context, token string, n *Notification) error {
+	accessToken, err := p.token.get(func() (string, time.Duration, error) {
+		return p.fetchAccessToken(ctx)
+	})
+	if err != nil {
+		return err
+	}
+
+	android := map[string]interface{}{
+		"priority": "HIGH",
+	}
+	if n.TTL > 0 {
+		android["ttl"] = fmt.Sprintf("%ds", n.TTL)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"message": map[string]interface{}{
+			"token":   token,
+			"data":    n.Data(),
+			"android": android,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	sendURL := fmt.Sprintf("%s/v1/projects/%s/messages:send", p.endpoint, p.account.ProjectID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sendURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("FCM 请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	respBody, _ := io.ReadAll(resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusNotFound,
+		bytes.Contains(respBody, []byte("UNREGISTERED")):
+		return ErrInvalidToken
+	case resp.StatusCode == http.StatusUnauthorized:
+		p.token.invalidate()
+	}
+	return fmt.Errorf("FCM 推送失败: status=%d body=%s", resp.StatusCode, respBody)
+}
+
+// fetchAccessToken 使用服务账号签名的 JWT 换取 OAuth2 Access Token
+func (p *FCMProvider) fetchAccessToken(ctx context.Context) (string, time.Duration, error) {
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   p.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   p.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(p.key)
+	if err != nil {
+		return "", 0, fmt.Errorf("签名 FCM 服务账号断言失败: %w", err)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+	return requestAccessToken(ctx, p.client, p.account.TokenURI, form)
+}
+
+// requestAccessToken 请求 OAuth2 Access Token（FCM/HMS 共用）
+func requestAccessToken(ctx context.Context, client *http.Client, tokenURL string, form url.Values) (string, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("获取 Access Token 失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("获取 Access Token 失败: status=%d body=%s", resp.StatusCode, respBody)
+	}
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", 0, fmt.Errorf("解析 Access Token 响应失败: %w", err)
+	}
+	if result.AccessToken == "" {
+		return "", 0, fmt.Errorf("Access Token 响应缺少 access_token")
+	}
+	return result.AccessToken, time.Duration(result.ExpiresIn) * time.Second, nil
+}
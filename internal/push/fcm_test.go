@@ -0,0 +1,189 @@
+package push
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fcmStandIn 本地 FCM 替身，同时提供 OAuth2 Token 接口和发送接口
+type fcmStandIn struct {
+	mu          sync.Mutex
+	key         *rsa.PrivateKey
+	tokenCalls  int
+	sendStatus  int
+	sendBody    string
+	lastAuth    string
+	lastMessage map[string]interface{}
+}
+
+func (s *fcmStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.URL.Path {
+	case "/token":
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// 断言需由服务账号私钥签名
+		if _, err := jwt.Parse(r.PostForm.Get("assertion"), func(token *jwt.Token) (interface{}, error) {
+			return &s.key.PublicKey, nil
+		}, jwt.WithValidMethods([]string{"RS256"})); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		s.tokenCalls++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-" + strconv.Itoa(s.tokenCalls),
+			"expires_in":   3600,
+		})
+	case "/v1/projects/test-project/messages:send":
+		s.lastAuth = r.Header.Get("Authorization")
+		var body struct {
+			Message map[string]interface{} `json:"message"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		s.lastMessage = body.Message
+		if s.sendStatus != 0 {
+			w.WriteHeader(s.sendStatus)
+			_, _ = w.Write([]byte(s.sendBody))
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"projects/test-project/messages/1"}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// newTestFCM 创建指向本地 HTTP 替身的 FCM 通道
+func newTestFCM(t *testing.T) (*FCMProvider, *fcmStandIn) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成 RSA 私钥失败: %v", err)
+	}
+	standIn := &fcmStandIn{key: key}
+	srv := httptest.NewServer(standIn)
+	t.Cleanup(srv.Close)
+
+	account, _ := json.Marshal(map[string]string{
+		"project_id":   "other-project",
+		"client_email": "push@test-project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		"token_uri":    srv.URL + "/token",
+	})
+	p, err := NewFCMProvider(FCMConfig{
+		Endpoint:       srv.URL,
+		ProjectID:      "test-project",
+		ServiceAccount: account,
+	}, srv.Client())
+	if err != nil {
+		t.Fatalf("NewFCMProvider() error: %v", err)
+	}
+	return p, standIn
+}
+
+func TestFCMSend(t *testing.T) {
+	p, standIn := newTestFCM(t)
+
+	for i := 0; i < 2; i++ {
+		if err := p.Send(context.Background(), "device-token", testNotification()); err != nil {
+			t.Fatalf("Send() error: %v", err)
+		}
+	}
+
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+	// Access Token 有效期内复用
+	if standIn.tokenCalls != 1 {
+		t.Errorf("token requests = %d, want 1", standIn.tokenCalls)
+	}
+	if standIn.lastAuth != "Bearer access-1" {
+		t.Errorf("Authorization = %q, want Bearer access-1", standIn.lastAuth)
+	}
+	if standIn.lastMessage["token"] != "device-token" {
+		t.Errorf("message token = %v, want device-token", standIn.lastMessage["token"])
+	}
+	data, _ := standIn.lastMessage["data"].(map[string]interface{})
+	if data["type"] != TypeIncomingCall || data["room_id"] != "room_1" {
+		t.Errorf("message data = %v", data)
+	}
+	android, _ := standIn.lastMessage["android"].(map[string]interface{})
+	if android["priority"] != "HIGH" || android["ttl"] != "30s" {
+		t.Errorf("message android = %v", android)
+	}
+}
+
+func TestFCMInvalidToken(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"not found", http.StatusNotFound, `{"error":{"status":"NOT_FOUND"}}`},
+		{"unregistered", http.StatusBadRequest, `{"error":{"details":[{"errorCode":"UNREGISTERED"}]}}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, standIn := newTestFCM(t)
+			standIn.sendStatus, standIn.sendBody = tc.status, tc.body
+			if err := p.Send(context.Background(), "device-token", testNotification()); !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("Send() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestFCMUnauthorizedRefreshesToken(t *testing.T) {
+	p, standIn := newTestFCM(t)
+	standIn.sendStatus = http.StatusUnauthorized
+
+	err := p.Send(context.Background(), "device-token", testNotification())
+	if err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Send() error = %v, want unauthorized error", err)
+	}
+
+	standIn.mu.Lock()
+	standIn.sendStatus = 0
+	standIn.mu.Unlock()
+	if err := p.Send(context.Background(), "device-token", testNotification()); err != nil {
+		t.Fatalf("retry Send() error: %v", err)
+	}
+
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+	if standIn.tokenCalls != 2 || standIn.lastAuth != "Bearer access-2" {
+		t.Fatalf("token requests = %d, Authorization = %q, want a refreshed access token", standIn.tokenCalls, standIn.lastAuth)
+	}
+}
+
+func TestFCMServerError(t *testing.T) {
+	p, standIn := newTestFCM(t)
+	standIn.sendStatus, standIn.sendBody = http.StatusServiceUnavailable, `{"error":{"status":"UNAVAILABLE"}}`
+
+	err := p.Send(context.Background(), "device-token", testNotification())
+	if err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Send() error = %v, want server error", err)
+	}
+}
+
+func TestFCMTokenEndpointError(t *testing.T) {
+	p, _ := newTestFCM(t)
+	p.account.TokenURI += "-missing"
+
+	if err := p.Send(context.Background(), "device-token", testNotification()); err == nil {
+		t.Fatal("Send() error = nil, want access token error")
+	}
+}
@@ -0,0 +1,121 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HMS 推送接口返回码
+const (
+	hmsCodeSuccess      = "80000000" // 成功
+	hmsCodeInvalidToken = "80300007" // 全部 Token 无效
+	hmsCodeTokenExpired = "80200003" // Access Token 过期
+)
+
+// HMSConfig 华为 Push Kit 推送配置
+type HMSConfig struct {
+	Endpoint     string // 默认 https://push-api.cloud.huawei.com
+	TokenURL     string // 默认 https://oauth-login.cloud.huawei.com/oauth2/v3/token
+	AppID        string // 应用 ID（OAuth client_id）
+	ClientSecret string // 应用密钥（OAuth client_secret）
+}
+
+// HMSProvider 华为 Push Kit 推送（透传消息，紧急优先级）
+type HMSProvider struct {
+	cfg    HMSConfig
+	client *http.Client
+	token  cachedToken
+}
+
+// NewHMSProvider 创建华为推送通道
+func NewHMSProvider(cfg HMSConfig, client *http.Client) *HMSProvider {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://push-api.cloud.huawei.com"
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = "https://oauth-login.cloud.huawei.com/oauth2/v3/token"
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	return &HMSProvider{cfg: cfg, client: client}
+}
+
+// Name 推送通道名称
+func (p *HMSProvider) Name() string {
+	return ProviderHMS
+}
+
+// Send 发送透传消息
+func (p *HMSProvider) Send(ctx context.Context, token string, n *Notification) error {
+	accessToken, err := p.token.get(func() (string, time.Duration, error) {
+		form := url.Values{}
+		form.Set("grant_type", "client_credentials")
+		form.Set("client_id", p.cfg.AppID)
+		form.Set("client_secret", p.cfg.ClientSecret)
+		return requestAccessToken(ctx, p.client, p.cfg.TokenURL, form)
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(n.Data())
+	if err != nil {
+		return err
+	}
+	android := map[string]interface{}{
+		"urgency": "HIGH",
+	}
+	if n.TTL > 0 {
+		android["ttl"] = fmt.Sprintf("%ds", n.TTL)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"validate_only": false,
+		"message": map[string]interface{}{
+			"data":    string(data),
+			"token":   []string{token},
+			"android": android,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	sendURL := fmt.Sprintf("%s/v1/%s/messages:send", p.cfg.Endpoint, p.cfg.AppID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sendURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json;charset=utf-8")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HMS 请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	var result struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	_ = json.Unmarshal(respBody, &result)
+	switch result.Code {
+	case hmsCodeSuccess:
+		return nil
+	case hmsCodeInvalidToken:
+		return ErrInvalidToken
+	case hmsCodeTokenExpired:
+		p.token.invalidate()
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		p.token.invalidate()
+	}
+	return fmt.Errorf("HMS 推送失败: status=%d code=%s msg=%s", resp.StatusCode, result.Code, result.Msg)
+}
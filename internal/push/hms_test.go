@@ -0,0 +1,155 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// hmsStandIn 本地华为推送替身，同时提供 OAuth2 Token 接口和发送接口
+type hmsStandIn struct {
+	mu          sync.Mutex
+	tokenCalls  int
+	sendStatus  int
+	sendCode    string
+	lastAuth    string
+	lastMessage map[string]interface{}
+}
+
+func (s *hmsStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.URL.Path {
+	case "/oauth2/v3/token":
+		if err := r.ParseForm(); err != nil ||
+			r.PostForm.Get("grant_type") != "client_credentials" ||
+			r.PostForm.Get("client_id") != "10086" ||
+			r.PostForm.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		s.tokenCalls++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-" + strconv.Itoa(s.tokenCalls),
+			"expires_in":   3600,
+		})
+	case "/v1/10086/messages:send":
+		s.lastAuth = r.Header.Get("Authorization")
+		var body struct {
+			Message map[string]interface{} `json:"message"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		s.lastMessage = body.Message
+		code := s.sendCode
+		if code == "" {
+			code = hmsCodeSuccess
+		}
+		if s.sendStatus != 0 {
+			w.WriteHeader(s.sendStatus)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"code": code, "msg": "test"})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// newTestHMS 创建指向本地 HTTP 替身的华为推送通道
+func newTestHMS(t *testing.T) (*HMSProvider, *hmsStandIn) {
+	t.Helper()
+	standIn := &hmsStandIn{}
+	srv := httptest.NewServer(standIn)
+	t.Cleanup(srv.Close)
+
+	p := NewHMSProvider(HMSConfig{
+		Endpoint:     srv.URL + "/",
+		TokenURL:     srv.URL + "/oauth2/v3/token",
+		AppID:        "10086",
+		ClientSecret: "secret",
+	}, srv.Client())
+	return p, standIn
+}
+
+func TestHMSSend(t *testing.T) {
+	p, standIn := newTestHMS(t)
+
+	for i := 0; i < 2; i++ {
+		if err := p.Send(context.Background(), "device-token", testNotification()); err != nil {
+			t.Fatalf("Send() error: %v", err)
+		}
+	}
+
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+	if standIn.tokenCalls != 1 {
+		t.Errorf("token requests = %d, want 1", standIn.tokenCalls)
+	}
+	if standIn.lastAuth != "Bearer access-1" {
+		t.Errorf("Authorization = %q, want Bearer access-1", standIn.lastAuth)
+	}
+	tokens, _ := standIn.lastMessage["token"].([]interface{})
+	if len(tokens) != 1 || tokens[0] != "device-token" {
+		t.Errorf("message token = %v, want [device-token]", standIn.lastMessage["token"])
+	}
+	// 透传消息的 data 为 JSON 字符串
+	raw, _ := standIn.lastMessage["data"].(string)
+	var data map[string]string
+	if err := json.Unmarshal([]byte(raw), &data); err != nil || data["room_id"] != "room_1" || data["caller"] != "alice" {
+		t.Errorf("message data = %q", raw)
+	}
+}
+
+func TestHMSInvalidToken(t *testing.T) {
+	p, standIn := newTestHMS(t)
+	standIn.sendStatus, standIn.sendCode = http.StatusBadRequest, hmsCodeInvalidToken
+
+	if err := p.Send(context.Background(), "device-token", testNotification()); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Send() error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestHMSExpiredAccessToken(t *testing.T) {
+	p, standIn := newTestHMS(t)
+	standIn.sendStatus, standIn.sendCode = http.StatusUnauthorized, hmsCodeTokenExpired
+
+	err := p.Send(context.Background(), "device-token", testNotification())
+	if err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Send() error = %v, want expired token error", err)
+	}
+
+	standIn.mu.Lock()
+	standIn.sendStatus, standIn.sendCode = 0, ""
+	standIn.mu.Unlock()
+	if err := p.Send(context.Background(), "device-token", testNotification()); err != nil {
+		t.Fatalf("retry Send() error: %v", err)
+	}
+
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+	if standIn.tokenCalls != 2 || standIn.lastAuth != "Bearer access-2" {
+		t.Fatalf("token requests = %d, Authorization = %q, want a refreshed access token", standIn.tokenCalls, standIn.lastAuth)
+	}
+}
+
+func TestHMSErrorCode(t *testing.T) {
+	p, standIn := newTestHMS(t)
+	standIn.sendCode = "80100003"
+
+	err := p.Send(context.Background(), "device-token", testNotification())
+	if err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Send() error = %v, want error for code 80100003", err)
+	}
+}
+
+func TestHMSTokenEndpointError(t *testing.T) {
+	p, _ := newTestHMS(t)
+	p.cfg.ClientSecret = "wrong"
+
+	if err := p.Send(context.Background(), "device-token", testNotification()); err == nil {
+		t.Fatal("Send() error = nil, want access token error")
+	}
+}
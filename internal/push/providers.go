@@ -0,0 +1,62 @@
+package push
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"tgo-rtc-server/internal/config"
+)
+
+// NewProviders 根据配置创建已启用的推送通道（未配置的通道不启用）
+func NewProviders(cfg *config.Config) (map[string]Provider, error) {
+	client := &http.Client{Timeout: time.Duration(cfg.PushTimeout) * time.Second}
+	providers := make(map[string]Provider)
+
+	if cfg.APNsKeyID != "" && cfg.APNsPrivateKeyFile != "" {
+		key, err := os.ReadFile(cfg.APNsPrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 APNs 私钥文件失败: %w", err)
+		}
+		p, err := NewAPNsProvider(APNsConfig{
+			Endpoint:   cfg.APNsEndpoint,
+			KeyID:      cfg.APNsKeyID,
+			TeamID:     cfg.APNsTeamID,
+			BundleID:   cfg.APNsBundleID,
+			PrivateKey: key,
+		}, client)
+		if err != nil {
+			return nil, err
+		}
+		providers[p.Name()] = p
+	}
+
+	if cfg.FCMServiceAccountFile != "" {
+		account, err := os.ReadFile(cfg.FCMServiceAccountFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 FCM 服务账号文件失败: %w", err)
+		}
+		p, err := NewFCMProvider(FCMConfig{
+			Endpoint:       cfg.FCMEndpoint,
+			ProjectID:      cfg.FCMProjectID,
+			ServiceAccount: account,
+		}, client)
+		if err != nil {
+			return nil, err
+		}
+		providers[p.Name()] = p
+	}
+
+	if cfg.HMSAppID != "" && cfg.HMSClientSecret != "" {
+		p := NewHMSProvider(HMSConfig{
+			Endpoint:     cfg.HMSEndpoint,
+			TokenURL:     cfg.HMSTokenURL,
+			AppID:        cfg.HMSAppID,
+			ClientSecret: cfg.HMSClientSecret,
+		}, client)
+		providers[p.Name()] = p
+	}
+
+	return providers, nil
+}
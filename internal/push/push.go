@@ -0,0 +1,89 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 推送通道名称，对应设备 Token 注册时的 provider 字段
+const (
+	ProviderAPNs = "apns" // Apple PushKit VoIP 推送
+	ProviderFCM  = "fcm"  // Firebase Cloud Messaging
+	ProviderHMS  = "hms"  // 华为 Push Kit
+)
+
+// 推送类型
+const (
+	TypeIncomingCall = "incoming_call" // 来电
+	TypeCallEnded    = "call_ended"    // 来电已结束（取消/超时），客户端停止响铃
+)
+
+// ErrInvalidToken 设备 Token 已失效（卸载应用/Token 过期），调用方应删除该 Token
+var ErrInvalidToken = errors.New("push: 设备 token 已失效")
+
+// Notification 推送内容
+type Notification struct {
+	Type    string // 推送类型，见 Type 常量
+	RoomID  string
+	Caller  string // 呼叫发起者 UID
+	RTCType uint8  // 0: 语音, 1: 视频
+	Reason  string // 结束原因（仅 call_ended）: cancelled, missed
+	TTL     int    // 推送有效期（秒），超过有效期未送达则丢弃；0 表示仅尝试立即送达
+}
+
+// Data 推送的自定义数据（各通道统一使用字符串键值对）
+func (n *Notification) Data() map[string]string {
+	data := map[string]string{
+		"type":     n.Type,
+		"room_id":  n.RoomID,
+		"caller":   n.Caller,
+		"rtc_type": strconv.Itoa(int(n.RTCType)),
+	}
+	if n.Reason != "" {
+		data["reason"] = n.Reason
+	}
+	return data
+}
+
+// Provider 推送通道
+// 实现需并发安全；设备 Token 失效时返回 ErrInvalidToken
+type Provider interface {
+	// Name 推送通道名称
+	Name() string
+	// Send 向单个设备发送推送
+	Send(ctx context.Context, token string, n *Notification) error
+}
+
+// cachedToken 缓存通道鉴权 Token，过期前自动刷新
+type cachedToken struct {
+	mu        sync.Mutex
+	value     string
+	expiresAt time.Time
+}
+
+// get 返回未过期的 Token，否则调用 refresh 重新获取
+// refresh 返回 Token 及其有效期，提前 1 分钟刷新
+func (ct *cachedToken) get(refresh func() (string, time.Duration, error)) (string, error) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	if ct.value != "" && time.Now().Before(ct.expiresAt) {
+		return ct.value, nil
+	}
+	value, ttl, err := refresh()
+	if err != nil {
+		return "", err
+	}
+	ct.value = value
+	ct.expiresAt = time.Now().Add(ttl - time.Minute)
+	return value, nil
+}
+
+// invalidate 清除缓存的 Token（通道返回鉴权失败时调用）
+func (ct *cachedToken) invalidate() {
+	ct.mu.Lock()
+	ct.value = ""
+	ct.mu.Unlock()
+}
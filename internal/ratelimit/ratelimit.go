@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tgo-rtc-server/internal/config"
+	"tgo-rtc-server/internal/metrics"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 限流的接口动作
+const (
+	ActionCreate = "create" // 创建房间
+	ActionInvite = "invite" // 邀请参与者
+	ActionJoin   = "join"   // 加入房间
+	ActionRing   = "ring"   // 被叫来电（创建房间和邀请都会向被叫振铃）
+)
+
+// 限流维度
+const (
+	ScopeUID    = "uid"    // 按发起用户
+	ScopeIP     = "ip"     // 按客户端 IP
+	ScopeTenant = "tenant" // 按租户（请求头 RATE_LIMIT_TENANT_HEADER）
+	ScopeCallee = "callee" // 按被叫用户
+)
+
+// keyPrefix Redis 计数键前缀
+const keyPrefix = "ratelimit"
+
+// incrScript 固定窗口计数：首次计数时设置过期时间，返回当前计数和剩余毫秒数
+var incrScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {count, ttl}
+`)
+
+// Rule 限流规则：Window 时间窗口内最多允许 Limit 次
+type Rule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Enabled 规则是否生效（Limit 为 0 表示不限制）
+func (r Rule) Enabled() bool {
+	return r.Limit > 0 && r.Window > 0
+}
+
+// Result 限流检查结果
+type Result struct {
+	Allowed    bool
+	Count      int64         // 当前窗口内的请求次数（含本次）
+	RetryAfter time.Duration // 被拒绝时距离窗口重置的时间
+}
+
+// Limiter 基于 Redis 的固定窗口限流器，多实例部署时共享计数
+type Limiter struct {
+	redisClient *redis.Client
+	enabled     bool
+	rules       map[string]Rule // 键为 action:scope
+}
+
+// NewLimiter 根据配置创建限流器
+func NewLimiter(redisClient *redis.Client, cfg *config.Config) *Limiter {
+	window := time.Duration(cfg.RateLimitWindow) * time.Second
+	ringWindow := time.Duration(cfg.RateLimitRingWindow) * time.Second
+	return &Limiter{
+		redisClient: redisClient,
+		enabled:     cfg.RateLimitEnabled && redisClient != nil,
+		rules: map[string]Rule{
+			ruleKey(ActionCreate, ScopeUID):    {Limit: cfg.RateLimitCreatePerUID, Window: window},
+			ruleKey(ActionCreate, ScopeIP):     {Limit: cfg.RateLimitCreatePerIP, Window: window},
+			ruleKey(ActionCreate, ScopeTenant): {Limit: cfg.RateLimitCreatePerTenant, Window: window},
+			ruleKey(ActionInvite, ScopeUID):    {Limit: cfg.RateLimitInvitePerUID, Window: window},
+			ruleKey(ActionInvite, ScopeIP):     {Limit: cfg.RateLimitInvitePerIP, Window: window},
+			ruleKey(ActionInvite, ScopeTenant): {Limit: cfg.RateLimitInvitePerTenant, Window: window},
+			ruleKey(ActionJoin, ScopeUID):      {Limit: cfg.RateLimitJoinPerUID, Window: window},
+			ruleKey(ActionJoin, ScopeIP):       {Limit: cfg.RateLimitJoinPerIP, Window: window},
+			ruleKey(ActionJoin, ScopeTenant):   {Limit: cfg.RateLimitJoinPerTenant, Window: window},
+			ruleKey(ActionRing, ScopeCallee):   {Limit: cfg.RateLimitRingPerCallee, Window: ringWindow},
+		},
+	}
+}
+
+// Allow 对 action+scope+key 计数一次并判断是否超限
+// 限流未启用、规则未配置或 key 为空时直接放行；Redis 异常时放行并返回错误，由调用方记录日志
+func (l *Limiter) Allow(ctx context.Context, action, scope, key string) (*Result, error) {
+	allowed := &Result{Allowed: true}
+	if l == nil || !l.enabled || key == "" {
+		return allowed, nil
+	}
+	rule, ok := l.rules[ruleKey(action, scope)]
+	if !ok || !rule.Enabled() {
+		return allowed, nil
+	}
+
+	redisKey := fmt.Sprintf("%s:%s:%s:%s", keyPrefix, action, scope, key)
+	vals, err := incrScript.Run(ctx, l.redisClient, []string{redisKey}, rule.Window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return allowed, fmt.Errorf("限流计数失败: %w", err)
+	}
+	if len(vals) != 2 {
+		return allowed, fmt.Errorf("限流计数返回值异常: %v", vals)
+	}
+
+	result := &Result{
+		Count:   vals[0],
+		Allowed: vals[0] <= int64(rule.Limit),
+	}
+	if !result.Allowed {
+		result.RetryAfter = time.Duration(vals[1]) * time.Millisecond
+		metrics.RateLimitRejected.WithLabelValues(action, scope).Inc()
+	}
+	return result, nil
+}
+
+// RetryAfterSeconds Retry-After 响应头的秒数（向上取整，至少 1 秒）
+func (r *Result) RetryAfterSeconds() int {
+	seconds := int((r.RetryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// ruleKey 规则表的键
+func ruleKey(action, scope string) string {
+	return action + ":" + scope
+}
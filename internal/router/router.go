@@ -5,36 +5,94 @@ import (
 	"tgo-rtc-server/internal/handler"
 	"tgo-rtc-server/internal/livekit"
 	"tgo-rtc-server/internal/middleware"
+	"tgo-rtc-server/internal/ratelimit"
 	"tgo-rtc-server/internal/service"
+	"tgo-rtc-server/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 // SetupRouter 设置路由
 // 返回 gin.Engine、participantService 和 roomService（用于 scheduler）
-func SetupRouter(db *gorm.DB, redisClient *redis.Client, cfg *config.Config, businessWebhookService *service.BusinessWebhookService) (*gin.Engine, *service.ParticipantService, *service.RoomService) {
-	router := gin.Default()
+func SetupRouter(db *gorm.DB, redisClient *redis.Client, cfg *config.Config, businessWebhookService *service.BusinessWebhookService, realtimeService *service.RealtimeService, pushService *service.PushService, activeCallIndex *service.ActiveCallIndex) (*gin.Engine, *service.ParticipantService, *service.RoomService) {
+	// 不使用 gin.Default() 自带的文本日志，访问日志由 AccessLogMiddleware 以结构化格式输出
+	router := gin.New()
+	// 只信任配置的反向代理转发的 X-Forwarded-For，否则客户端可伪造请求头绕过按 IP 的频率限制
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		utils.GetLogger().Error("受信任代理配置无效，不信任任何代理",
+			zap.Strings("trusted_proxies", cfg.TrustedProxies),
+			zap.Error(err),
+		)
+		_ = router.SetTrustedProxies(nil)
+	}
 
+	// 添加链路追踪中间件（需在其他中间件之前，使后续处理都挂在请求 Span 下）
+	router.Use(middleware.TracingMiddleware())
+	// 添加请求 ID、访问日志和 panic 恢复中间件
+	router.Use(middleware.RequestIDMiddleware(utils.GetLogger()))
+	router.Use(middleware.AccessLogMiddleware())
+	router.Use(middleware.RecoveryMiddleware())
 	// 添加多语言中间件
 	router.Use(middleware.LanguageMiddleware())
+	// 添加 HTTP 接口耗时指标中间件
+	router.Use(middleware.MetricsMiddleware())
 
 	// 初始化 Token 生成器
 	tokenGenerator := livekit.NewTokenGenerator(cfg)
+	// LiveKit RoomService 客户端（主持人变更时同步已连接参与者的角色）
+	roomClient := livekit.NewRoomClient(cfg, nil)
 
 	// 初始化服务层
-	roomService := service.NewRoomService(db, tokenGenerator)
+	roomService := service.NewRoomService(db, redisClient, tokenGenerator)
 	participantService := service.NewParticipantService(db, tokenGenerator, businessWebhookService)
+	roomService.SetParticipantService(participantService)
+	roomService.SetPushService(pushService)
+	roomService.SetActiveCallIndex(activeCallIndex)
+	participantService.SetActiveCallIndex(activeCallIndex)
+	participantService.SetRoomClient(roomClient)
+	// 初始化幂等请求服务（创建房间、邀请、加入房间支持 Idempotency-Key 请求头）
+	idempotencyService := service.NewIdempotencyService(redisClient, cfg)
+	roomService.SetIdempotencyService(idempotencyService)
+	participantService.SetIdempotencyService(idempotencyService)
+	inviteLinkService := service.NewInviteLinkService(db, cfg, participantService)
+
+	// 初始化频率限制器（IP/租户维度在中间件检查，用户/被叫维度在业务层检查）
+	rateLimiter := ratelimit.NewLimiter(redisClient, cfg)
+	roomService.SetRateLimiter(rateLimiter)
+	participantService.SetRateLimiter(rateLimiter)
+	createLimit := middleware.RateLimitMiddleware(rateLimiter, ratelimit.ActionCreate, cfg.RateLimitTenantHeader)
+	inviteLimit := middleware.RateLimitMiddleware(rateLimiter, ratelimit.ActionInvite, cfg.RateLimitTenantHeader)
+	joinLimit := middleware.RateLimitMiddleware(rateLimiter, ratelimit.ActionJoin, cfg.RateLimitTenantHeader)
+
+	// 初始化呼叫策略服务（屏蔽列表 + 可选的业务后端呼叫策略接口）
+	callPolicyService := service.NewCallPolicyService(db, redisClient, cfg)
+	roomService.SetCallPolicyService(callPolicyService)
+	participantService.SetCallPolicyService(callPolicyService)
+
+	// 初始化用户可用状态服务（免打扰/离线）
+	presenceService := service.NewPresenceService(db, cfg)
+	roomService.SetPresenceService(presenceService)
+	participantService.SetPresenceService(presenceService)
 
 	// 初始化处理器
 	roomHandler := handler.NewRoomHandler(roomService)
 	participantHandler := handler.NewParticipantHandler(participantService)
+	inviteLinkHandler := handler.NewInviteLinkHandler(inviteLinkService)
+	deviceHandler := handler.NewDeviceHandler(pushService)
+	blockHandler := handler.NewBlockHandler(callPolicyService)
+	presenceHandler := handler.NewPresenceHandler(presenceService)
+	healthHandler := handler.NewHealthHandler(service.NewHealthService(db, redisClient, cfg))
 
 	// 初始化 webhook 服务和处理器
 	webhookService := service.NewWebhookService(db, redisClient, cfg)
+	webhookService.SetActiveCallIndex(activeCallIndex)
+	webhookService.SetRoomClient(roomClient)
 	webhookValidator := livekit.NewWebhookValidator(cfg.LiveKitAPIKey, cfg.LiveKitAPISecret)
 	webhookHandler := handler.NewWebhookHandler(webhookService, webhookValidator)
 	webhookLogHandler := handler.NewWebhookLogHandler(businessWebhookService)
@@ -50,13 +108,19 @@ func SetupRouter(db *gorm.DB, redisClient *redis.Client, cfg *config.Config, bus
 			"status": "ok",
 		})
 	})
+	router.GET("/livez", healthHandler.Livez)   // 存活检查
+	router.GET("/readyz", healthHandler.Readyz) // 就绪检查（数据库、Redis、迁移状态，可选 LiveKit 和 webhook 端点）
+
+	// Prometheus 指标
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	// Swagger 文档路由
+	docsHandler := handler.NewDocsHandler(cfg)
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(
 		swaggerFiles.Handler,
 		ginSwagger.URL("/api/docs/swagger.json"), // 使用自定义的 swagger.json
 	))
-	router.GET("/api/docs/swagger.json", handler.GetSwaggerJSON)
+	router.GET("/api/docs/swagger.json", docsHandler.GetSwaggerJSON)
 
 	// API 路由组
 	api := router.Group("/api/v1")
@@ -64,11 +128,60 @@ func SetupRouter(db *gorm.DB, redisClient *redis.Client, cfg *config.Config, bus
 		// 房间相关接口
 		rooms := api.Group("/rooms")
 		{
-			rooms.POST("", roomHandler.CreateRoom)                                // 创建房间
-			rooms.GET("/sync", participantHandler.GetUserAvailableRooms)          // 同步用户可加入的房间列表
-			rooms.POST("/:room_id/invite", participantHandler.InviteParticipants) // 邀请参与者
-			rooms.POST("/:room_id/join", participantHandler.JoinRoom)             // 加入房间
-			rooms.POST("/:room_id/leave", participantHandler.LeaveRoom)           // 离开房间
+			rooms.POST("", createLimit, roomHandler.CreateRoom)                                // 创建房间
+			rooms.GET("/sync", participantHandler.GetUserAvailableRooms)                       // 同步用户可加入的房间列表
+			rooms.POST("/:room_id/invite", inviteLimit, participantHandler.InviteParticipants) // 邀请参与者
+			rooms.POST("/:room_id/join", joinLimit, participantHandler.JoinRoom)               // 加入房间
+			rooms.POST("/:room_id/leave", participantHandler.LeaveRoom)                        // 离开房间
+			rooms.GET("/:room_id/lobby", participantHandler.GetLobbyParticipants)              // 获取等候室列表
+			rooms.POST("/:room_id/lobby/admit", participantHandler.AdmitLobbyParticipants)     // 准入等候室用户
+			rooms.POST("/:room_id/lobby/deny", participantHandler.DenyLobbyParticipants)       // 拒绝等候室用户
+			rooms.POST("/:room_id/invite-links", inviteLinkHandler.CreateInviteLink)           // 创建邀请链接
+			rooms.POST("/:room_id/host", participantHandler.TransferHost)                      // 转移主持人
+		}
+
+		// 邀请链接相关接口
+		inviteLinks := api.Group("/invite-links")
+		{
+			inviteLinks.POST("/redeem", joinLimit, inviteLinkHandler.RedeemInviteLink) // 使用邀请码加入房间
+		}
+
+		// 频道相关接口
+		channels := api.Group("/channels")
+		{
+			channels.GET("/:channel_id/active-room", roomHandler.GetChannelActiveRoom) // 获取频道进行中的房间
+		}
+
+		// 设备推送 Token 相关接口
+		devices := api.Group("/devices")
+		{
+			devices.POST("/token", deviceHandler.RegisterDeviceToken)     // 注册设备推送 Token
+			devices.DELETE("/token", deviceHandler.UnregisterDeviceToken) // 注销设备推送 Token
+		}
+
+		// 用户屏蔽列表相关接口
+		blocks := api.Group("/blocks")
+		{
+			blocks.POST("", blockHandler.BlockUser)     // 屏蔽用户
+			blocks.DELETE("", blockHandler.UnblockUser) // 取消屏蔽用户
+			blocks.GET("", blockHandler.GetBlockList)   // 获取屏蔽列表
+		}
+
+		// 用户可用状态相关接口
+		presence := api.Group("/presence")
+		{
+			presence.PUT("", presenceHandler.SetPresence) // 设置用户可用状态
+			presence.GET("", presenceHandler.GetPresence) // 批量获取用户可用状态
+		}
+
+		// 客户端实时事件流接口
+		if realtimeService.Enabled() {
+			eventStreamHandler := handler.NewEventStreamHandler(realtimeService, cfg.EventStreamHeartbeat)
+			events := api.Group("/events")
+			{
+				events.POST("/token", middleware.ServerKeyAuthMiddleware(cfg.EventStreamIssuerKey), eventStreamHandler.IssueToken) // 签发事件流 Token（仅限业务服务端）
+				events.GET("/stream", eventStreamHandler.Stream)                                                                   // 订阅事件流（SSE）
+			}
 		}
 
 		// Webhook 相关接口
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tgo-rtc-server/internal/metrics"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Redis 中的通话索引 key
+// 同步脚本会访问未在 KEYS 中声明的用户索引 key，仅支持单节点 Redis（启动时 database.InitRedis 拒绝 Redis Cluster）
+const (
+	activeRoomKeyPrefix  = "rtc:active:room:" // 房间 -> 活跃参与者 hash（uid -> "参与者ID:状态"）
+	activeUIDKeyPrefix   = "rtc:active:uid:"  // 用户 -> 活跃房间 set
+	activeInvitingKey    = "rtc:active:inviting"
+	activeReadyKey       = "rtc:active:ready"
+	activeRebuildLockKey = "rtc:active:rebuild:lock"
+
+	// activeRebuildLockTTL 重建锁有效期，重建只读取活跃参与者，正常远小于该时间
+	activeRebuildLockTTL = 60 * time.Second
+)
+
+// activeParticipantStatuses 视为“通话中”的参与者状态（邀请中、已加入）
+var activeParticipantStatuses = []int{models.ParticipantStatusInviting, models.ParticipantStatusJoined}
+
+// syncRoomScript 用数据库中的最新快照替换一个房间的索引
+// KEYS[1]: 房间 hash, KEYS[2]: 邀请中房间 zset
+// ARGV[1]: room_id, ARGV[2]: 用户索引 key 前缀, ARGV[3]: 最早邀请时间（无邀请中参与者时为空）, ARGV[4..]: uid、值交替
+var syncRoomScript = redis.NewScript(`
+local keep = {}
+for i = 4, #ARGV, 2 do
+	keep[ARGV[i]] = true
+end
+for _, uid in ipairs(redis.call("HKEYS", KEYS[1])) do
+	if not keep[uid] then
+		redis.call("SREM", ARGV[2] .. uid, ARGV[1])
+	end
+end
+redis.call("DEL", KEYS[1])
+for i = 4, #ARGV, 2 do
+	redis.call("HSET", KEYS[1], ARGV[i], ARGV[i + 1])
+	redis.call("SADD", ARGV[2] .. ARGV[i], ARGV[1])
+end
+if ARGV[3] == "" then
+	redis.call("ZREM", KEYS[2], ARGV[1])
+else
+	redis.call("ZADD", KEYS[2], ARGV[3], ARGV[1])
+end
+return 0
+`)
+
+// ActiveCallIndex 基于 Redis 的通话索引：用户 -> 活跃房间、房间 -> 活跃参与者、邀请中房间 -> 最早邀请时间
+// 数据库是唯一的数据来源，索引在每次参与者状态变更后按房间从数据库重新同步，服务启动时全量重建
+// 索引未就绪（重建中、Redis 被清空、同步失败）或 Redis 异常时，所有查询回退到数据库
+type ActiveCallIndex struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+}
+
+// NewActiveCallIndex 创建通话索引，未配置 Redis 或未启用时返回 nil（所有查询直接走数据库）
+func NewActiveCallIndex(db *gorm.DB, redisClient *redis.Client, enabled bool) *ActiveCallIndex {
+	if redisClient == nil || !enabled {
+		return nil
+	}
+	return &ActiveCallIndex{db: db, redisClient: redisClient}
+}
+
+// activeRoomValue 房间 hash 中参与者的值，保存参与者 ID 以便按加入顺序返回
+func activeRoomValue(p models.Participant) string {
+	return strconv.Itoa(p.ID) + ":" + strconv.Itoa(int(p.Status))
+}
+
+// SyncRoom 从数据库重新同步一个房间的索引，在参与者状态变更后调用
+// 同步失败时将索引标记为未就绪，查询回退到数据库，由兜底轮询触发重建
+func (aci *ActiveCallIndex) SyncRoom(ctx context.Context, roomID string) {
+	if aci == nil || roomID == "" {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	var participants []models.Participant
+	if err := aci.db.WithContext(ctx).
+		Select("id", "uid", "status", "created_at").
+		Where("room_id = ? AND status IN ?", roomID, activeParticipantStatuses).
+		Order("id").
+		Find(&participants).Error; err != nil {
+		aci.invalidate(ctx, "同步房间通话索引时查询参与者失败", roomID, err)
+		return
+	}
+	if err := aci.syncRoom(ctx, roomID, participants); err != nil {
+		aci.invalidate(ctx, "同步房间通话索引失败", roomID, err)
+	}
+}
+
+// syncRoom 将房间的活跃参与者快照写入索引
+func (aci *ActiveCallIndex) syncRoom(ctx context.Context, roomID string, participants []models.Participant) error {
+	keys, args := syncRoomScriptArgs(roomID, participants)
+	return syncRoomScript.Run(ctx, aci.redisClient, keys, args...).Err()
+}
+
+// syncRoomScriptArgs 构造同步脚本的参数
+func syncRoomScriptArgs(roomID string, participants []models.Participant) ([]string, []interface{}) {
+	var inviting int64
+	args := make([]interface{}, 0, 3+2*len(participants))
+	args = append(args, roomID, activeUIDKeyPrefix, "")
+	for _, p := range participants {
+		args = append(args, p.UID, activeRoomValue(p))
+		if p.Status == models.ParticipantStatusInviting {
+			if createdAt := p.CreatedAt.Unix(); inviting == 0 || createdAt < inviting {
+				inviting = createdAt
+			}
+		}
+	}
+	if inviting > 0 {
+		args[2] = strconv.FormatInt(inviting, 10)
+	}
+	return []string{activeRoomKeyPrefix + roomID, activeInvitingKey}, args
+}
+
+// invalidate 标记索引未就绪
+func (aci *ActiveCallIndex) invalidate(ctx context.Context, msg, roomID string, err error) {
+	utils.LoggerFromContext(ctx).Warn(msg+"，通话索引回退到数据库",
+		zap.String("room_id", roomID),
+		zap.Error(err),
+	)
+	aci.redisClient.Del(ctx, activeReadyKey)
+}
+
+// Rebuild 从数据库全量重建索引，服务启动时调用；其他实例正在重建时直接返回
+func (aci *ActiveCallIndex) Rebuild(ctx context.Context) error {
+	if aci == nil {
+		return nil
+	}
+	logger := utils.GetLogger()
+
+	token := generateSessionID()
+	ok, err := aci.redisClient.SetNX(ctx, activeRebuildLockKey, token, activeRebuildLockTTL).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		logger.Info("其他实例正在重建通话索引，跳过")
+		return nil
+	}
+	defer releaseLockScript.Run(context.WithoutCancel(ctx), aci.redisClient, []string{activeRebuildLockKey}, token)
+
+	start := time.Now()
+	// 重建期间查询回退到数据库
+	if err := aci.redisClient.Del(ctx, activeReadyKey, activeInvitingKey).Err(); err != nil {
+		return err
+	}
+	for _, pattern := range []string{activeRoomKeyPrefix + "*", activeUIDKeyPrefix + "*"} {
+		iter := aci.redisClient.Scan(ctx, 0, pattern, 500).Iterator()
+		var keys []string
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+			if len(keys) >= 500 {
+				if err := aci.redisClient.Del(ctx, keys...).Err(); err != nil {
+					return err
+				}
+				keys = keys[:0]
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := aci.redisClient.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+		}
+	}
+
+	var participants []models.Participant
+	if err := aci.db.WithContext(ctx).
+		Select("id", "room_id", "uid", "status", "created_at").
+		Where("status IN ?", activeParticipantStatuses).
+		Order("id").
+		Find(&participants).Error; err != nil {
+		return err
+	}
+	byRoom := make(map[string][]models.Participant)
+	for _, p := range participants {
+		byRoom[p.RoomID] = append(byRoom[p.RoomID], p)
+	}
+
+	if err := syncRoomScript.Load(ctx, aci.redisClient).Err(); err != nil {
+		return err
+	}
+	pipe := aci.redisClient.Pipeline()
+	for roomID, roomParticipants := range byRoom {
+		keys, args := syncRoomScriptArgs(roomID, roomParticipants)
+		syncRoomScript.EvalSha(ctx, pipe, keys, args...)
+		if pipe.Len() >= 500 {
+			if _, err := pipe.Exec(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	if err := aci.redisClient.Set(ctx, activeReadyKey, "1", 0).Err(); err != nil {
+		return err
+	}
+	logger.Info("通话索引重建完成",
+		zap.Int("rooms", len(byRoom)),
+		zap.Int("participants", len(participants)),
+		zap.Duration("elapsed", time.Since(start)),
+	)
+	return nil
+}
+
+// EnsureReady 索引未就绪时重建（由兜底轮询定期调用，Redis 被清空或同步失败后自动恢复）
+func (aci *ActiveCallIndex) EnsureReady(ctx context.Context) {
+	if aci == nil {
+		return
+	}
+	n, err := aci.redisClient.Exists(ctx, activeReadyKey).Result()
+	if err != nil || n > 0 {
+		return
+	}
+	if err := aci.Rebuild(ctx); err != nil {
+		utils.GetLogger().Warn("重建通话索引失败", zap.Error(err))
+	}
+}
+
+// FirstBusyUID 返回 uids 中第一个通话中（邀请中/已加入）的用户，均空闲时返回空字符串
+// 索引可用时只对索引中通话中的用户查询数据库确认，索引不可用时直接查询数据库
+// db 为调用方的数据库连接（携带请求上下文）
+func (aci *ActiveCallIndex) FirstBusyUID(ctx context.Context, db *gorm.DB, uids []string) (string, error) {
+	if len(uids) == 0 {
+		return "", nil
+	}
+
+	candidates := uids
+	if indexed, ok := aci.busyCandidates(ctx, uids); ok {
+		if len(indexed) == 0 {
+			metrics.ActiveCallIndexLookups.WithLabelValues("index").Inc()
+			return "", nil
+		}
+		metrics.ActiveCallIndexLookups.WithLabelValues("db_verify").Inc()
+		candidates = indexed
+	} else {
+		metrics.ActiveCallIndexLookups.WithLabelValues("db_fallback").Inc()
+	}
+
+	var participant models.Participant
+	if err := db.Select("uid").
+		Where("uid IN ? AND status IN ?", candidates, activeParticipantStatuses).
+		First(&participant).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return participant.UID, nil
+}
+
+// busyCandidates 从索引中查询通话中的用户，索引不可用时 ok 为 false
+func (aci *ActiveCallIndex) busyCandidates(ctx context.Context, uids []string) ([]string, bool) {
+	if aci == nil {
+		return nil, false
+	}
+	pipe := aci.redisClient.Pipeline()
+	ready := pipe.Exists(ctx, activeReadyKey)
+	counts := make([]*redis.IntCmd, len(uids))
+	for i, uid := range uids {
+		counts[i] = pipe.SCard(ctx, activeUIDKeyPrefix+uid)
+	}
+	if _, err := pipe.Exec(ctx); err != nil || ready.Val() == 0 {
+		return nil, false
+	}
+
+	var busy []string
+	for i, uid := range uids {
+		if counts[i].Val() > 0 {
+			busy = append(busy, uid)
+		}
+	}
+	return busy, true
+}
+
+// UserRoomIDs 查询用户通话中（邀请中/已加入）的房间 ID，索引不可用时 ok 为 false
+func (aci *ActiveCallIndex) UserRoomIDs(ctx context.Context, uid string) ([]string, bool) {
+	if aci == nil {
+		return nil, false
+	}
+	pipe := aci.redisClient.Pipeline()
+	ready := pipe.Exists(ctx, activeReadyKey)
+	members := pipe.SMembers(ctx, activeUIDKeyPrefix+uid)
+	if _, err := pipe.Exec(ctx); err != nil || ready.Val() == 0 {
+		return nil, false
+	}
+	roomIDs := members.Val()
+	sort.Strings(roomIDs)
+	return roomIDs, true
+}
+
+// RoomRosters 查询房间中通话中（邀请中/已加入）的参与者 uid，按参与者 ID（加入顺序）排序
+// 索引不可用时 ok 为 false
+func (aci *ActiveCallIndex) RoomRosters(ctx context.Context, roomIDs []string) (map[string][]string, bool) {
+	if aci == nil {
+		return nil, false
+	}
+	pipe := aci.redisClient.Pipeline()
+	ready := pipe.Exists(ctx, activeReadyKey)
+	rosters := make([]*redis.StringStringMapCmd, len(roomIDs))
+	for i, roomID := range roomIDs {
+		rosters[i] = pipe.HGetAll(ctx, activeRoomKeyPrefix+roomID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil || ready.Val() == 0 {
+		return nil, false
+	}
+
+	result := make(map[string][]string, len(roomIDs))
+	for i, roomID := range roomIDs {
+		type member struct {
+			uid string
+			id  int
+		}
+		members := make([]member, 0, len(rosters[i].Val()))
+		for uid, value := range rosters[i].Val() {
+			id, _ := strconv.Atoi(strings.SplitN(value, ":", 2)[0])
+			members = append(members, member{uid: uid, id: id})
+		}
+		sort.Slice(members, func(a, b int) bool { return members[a].id < members[b].id })
+		uids := make([]string, len(members))
+		for j, m := range members {
+			uids[j] = m.uid
+		}
+		result[roomID] = uids
+	}
+	return result, true
+}
+
+// InvitingRoomIDs 查询最早邀请时间早于 before 的房间（可能存在超时的邀请中参与者），索引不可用时 ok 为 false
+func (aci *ActiveCallIndex) InvitingRoomIDs(ctx context.Context, before time.Time) ([]string, bool) {
+	if aci == nil {
+		return nil, false
+	}
+	pipe := aci.redisClient.Pipeline()
+	ready := pipe.Exists(ctx, activeReadyKey)
+	rooms := pipe.ZRangeByScore(ctx, activeInvitingKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(before.Unix(), 10),
+	})
+	if _, err := pipe.Exec(ctx); err != nil || ready.Val() == 0 {
+		return nil, false
+	}
+	return rooms.Val(), true
+}
@@ -0,0 +1,661 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"tgo-rtc-server/internal/archive"
+	"tgo-rtc-server/internal/config"
+	"tgo-rtc-server/internal/metrics"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 归档方式
+const (
+	ArchiveModeTable = "table" // 移入同库的归档表
+	ArchiveModeFile  = "file"  // 导出为 gzip 压缩的 NDJSON 文件
+)
+
+const (
+	// archiveRoomsLockKey 房间归档任务锁，多实例部署时同一时间只有一个实例执行
+	archiveRoomsLockKey = "archive:lock:rooms"
+	// archiveWebhookLogsLockKey webhook 日志归档任务锁
+	archiveWebhookLogsLockKey = "archive:lock:webhook_logs"
+)
+
+// archivableRoomStatuses 可归档的房间状态（终态）
+var archivableRoomStatuses = []int{
+	models.RoomStatusFinished,
+	models.RoomStatusCancelled,
+	models.RoomStatusRejected,
+	models.RoomStatusBusy,
+	models.RoomStatusMissed,
+}
+
+// archivedRoom file 模式下每行写入的房间记录
+type archivedRoom struct {
+	Room         models.Room          `json:"room"`
+	Participants []models.Participant `json:"participants"`
+}
+
+// archivedSession file 模式下每行写入的持久房间会话记录
+type archivedSession struct {
+	Session      models.RoomSession              `json:"session"`
+	Participants []models.RoomSessionParticipant `json:"participants"`
+}
+
+// ArchiveService 冷数据归档服务
+// 定期将结束超过保留期的一次性房间及其参与者、持久房间的历史会话及其参与者移出热表，写入归档表或归档文件
+type ArchiveService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	config      *config.Config
+	store       archive.Store // file 模式的归档文件存储
+
+	// 归档表的字段列表（与源表模型一致）
+	roomColumns        string
+	participantColumns string
+	webhookLogColumns  string
+	sessionColumns     string
+	sessionPartColumns string
+
+	ticker  *time.Ticker
+	done    chan bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+}
+
+// NewArchiveService 创建冷数据归档服务
+func NewArchiveService(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) (*ArchiveService, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	as := &ArchiveService{
+		db:          db,
+		redisClient: redisClient,
+		config:      cfg,
+		done:        make(chan bool),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	if !cfg.ArchiveEnabled {
+		return as, nil
+	}
+
+	if cfg.ArchiveBatchSize <= 0 {
+		return nil, fmt.Errorf("ARCHIVE_BATCH_SIZE 必须大于 0")
+	}
+	if cfg.ArchiveInterval <= 0 {
+		return nil, fmt.Errorf("ARCHIVE_INTERVAL 必须大于 0")
+	}
+
+	switch cfg.ArchiveMode {
+	case ArchiveModeTable:
+		var err error
+		if as.roomColumns, err = quotedColumns(db, &models.Room{}); err != nil {
+			return nil, err
+		}
+		if as.participantColumns, err = quotedColumns(db, &models.Participant{}); err != nil {
+			return nil, err
+		}
+		if as.webhookLogColumns, err = quotedColumns(db, &models.BusinessWebhookLog{}); err != nil {
+			return nil, err
+		}
+		if as.sessionColumns, err = quotedColumns(db, &models.RoomSession{}); err != nil {
+			return nil, err
+		}
+		if as.sessionPartColumns, err = quotedColumns(db, &models.RoomSessionParticipant{}); err != nil {
+			return nil, err
+		}
+	case ArchiveModeFile:
+		store, err := archive.NewStore(cfg)
+		if err != nil {
+			return nil, err
+		}
+		as.store = store
+	default:
+		return nil, fmt.Errorf("不支持的归档方式: %s（可选 table、file）", cfg.ArchiveMode)
+	}
+	return as, nil
+}
+
+// Enabled 是否启用归档
+func (as *ArchiveService) Enabled() bool {
+	return as.config.ArchiveEnabled
+}
+
+// Start 启动归档定时器
+func (as *ArchiveService) Start() {
+	logger := utils.GetLogger()
+
+	if !as.config.ArchiveEnabled {
+		logger.Info("冷数据归档已禁用")
+		return
+	}
+
+	interval := time.Duration(as.config.ArchiveInterval) * time.Second
+	as.ticker = time.NewTicker(interval)
+
+	as.running.Add(1)
+	go func() {
+		defer as.running.Done()
+
+		// 立即执行一次，然后定期执行
+		as.archive()
+		for {
+			select {
+			case <-as.ticker.C:
+				as.archive()
+			case <-as.done:
+				return
+			}
+		}
+	}()
+
+	storeName := ""
+	if as.store != nil {
+		storeName = as.store.Name()
+	}
+	logger.Info("冷数据归档定时器已启动",
+		zap.String("mode", as.config.ArchiveMode),
+		zap.String("store", storeName),
+		zap.Int("interval_seconds", as.config.ArchiveInterval),
+		zap.Int("archive_after_days", as.config.ArchiveAfterDays),
+		zap.Int("batch_size", as.config.ArchiveBatchSize),
+	)
+}
+
+// Stop 停止归档定时器，正在执行的批次完成后退出
+func (as *ArchiveService) Stop() {
+	if as.ticker == nil {
+		return
+	}
+	as.ticker.Stop()
+	as.cancel()
+	as.done <- true
+	as.running.Wait()
+
+	utils.GetLogger().Info("冷数据归档定时器已停止")
+}
+
+// archive 执行一次房间归档
+func (as *ArchiveService) archive() {
+	logger := utils.GetLogger()
+
+	unlock, ok := as.tryLock(as.ctx, archiveRoomsLockKey)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	cutoff := time.Now().AddDate(0, 0, -as.config.ArchiveAfterDays)
+	start := time.Now()
+	rooms, participants, err := as.ArchiveRooms(as.ctx, cutoff)
+	if err != nil {
+		logger.Error("归档房间失败",
+			zap.Int64("archived_rooms", rooms),
+			zap.Int64("archived_participants", participants),
+			zap.Error(err),
+		)
+		return
+	}
+	if rooms > 0 {
+		logger.Info("房间归档完成",
+			zap.Int64("archived_rooms", rooms),
+			zap.Int64("archived_participants", participants),
+			zap.Time("cutoff_time", cutoff),
+			zap.Duration("elapsed", time.Since(start)),
+		)
+	}
+
+	start = time.Now()
+	sessions, sessionParticipants, err := as.ArchiveSessions(as.ctx, cutoff)
+	if err != nil {
+		logger.Error("归档房间会话失败",
+			zap.Int64("archived_sessions", sessions),
+			zap.Int64("archived_session_participants", sessionParticipants),
+			zap.Error(err),
+		)
+		return
+	}
+	if sessions > 0 {
+		logger.Info("房间会话归档完成",
+			zap.Int64("archived_sessions", sessions),
+			zap.Int64("archived_session_participants", sessionParticipants),
+			zap.Time("cutoff_time", cutoff),
+			zap.Duration("elapsed", time.Since(start)),
+		)
+	}
+}
+
+// ArchiveRooms 分批归档 cutoff 之前结束的一次性房间及其参与者，返回归档的房间数和参与者数
+// 持久房间可使用同一个 room_id 重新开始，房间记录不归档，其历史会话由 ArchiveSessions 归档
+func (as *ArchiveService) ArchiveRooms(ctx context.Context, cutoff time.Time) (int64, int64, error) {
+	var totalRooms, totalParticipants int64
+	for batch := 0; as.config.ArchiveMaxBatches == 0 || batch < as.config.ArchiveMaxBatches; batch++ {
+		if batch > 0 && !as.pause(ctx) {
+			break
+		}
+
+		var rooms []models.Room
+		if err := as.db.WithContext(ctx).
+			Where("status IN ? AND persistent = ? AND updated_at < ?", archivableRoomStatuses, models.RoomOneTime, cutoff).
+			Order("id").
+			Limit(as.config.ArchiveBatchSize).
+			Find(&rooms).Error; err != nil {
+			return totalRooms, totalParticipants, fmt.Errorf("查询待归档房间失败: %w", err)
+		}
+		if len(rooms) == 0 {
+			break
+		}
+
+		var (
+			participants int64
+			err          error
+		)
+		if as.config.ArchiveMode == ArchiveModeFile {
+			participants, err = as.archiveRoomsToFile(ctx, rooms)
+		} else {
+			participants, err = as.archiveRoomsToTable(ctx, rooms)
+		}
+		if err != nil {
+			return totalRooms, totalParticipants, err
+		}
+
+		totalRooms += int64(len(rooms))
+		totalParticipants += participants
+		metrics.ArchivedRows.WithLabelValues("rooms").Add(float64(len(rooms)))
+		metrics.ArchivedRows.WithLabelValues("participants").Add(float64(participants))
+
+		if len(rooms) < as.config.ArchiveBatchSize {
+			break
+		}
+	}
+	return totalRooms, totalParticipants, nil
+}
+
+// archiveRoomsToTable 在一个事务内将房间及其参与者复制到归档表并从热表删除
+func (as *ArchiveService) archiveRoomsToTable(ctx context.Context, rooms []models.Room) (int64, error) {
+	ids, roomIDs := roomKeys(rooms)
+	now := time.Now()
+
+	var participants int64
+	err := as.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf("INSERT INTO rtc_room_archive (%s, archived_at) SELECT %s, ? FROM rtc_room WHERE id IN ?",
+			as.roomColumns, as.roomColumns), now, ids).Error; err != nil {
+			return fmt.Errorf("写入房间归档表失败: %w", err)
+		}
+		if err := tx.Exec(fmt.Sprintf("INSERT INTO rtc_participant_archive (%s, archived_at) SELECT %s, ? FROM rtc_participant WHERE room_id IN ?",
+			as.participantColumns, as.participantColumns), now, roomIDs).Error; err != nil {
+			return fmt.Errorf("写入参与者归档表失败: %w", err)
+		}
+
+		result := tx.Where("room_id IN ?", roomIDs).Delete(&models.Participant{})
+		if result.Error != nil {
+			return fmt.Errorf("删除已归档参与者失败: %w", result.Error)
+		}
+		participants = result.RowsAffected
+
+		if err := tx.Where("id IN ?", ids).Delete(&models.Room{}).Error; err != nil {
+			return fmt.Errorf("删除已归档房间失败: %w", err)
+		}
+		return nil
+	})
+	return participants, err
+}
+
+// archiveRoomsToFile 将房间及其参与者写入归档文件，写入成功后再从热表删除
+func (as *ArchiveService) archiveRoomsToFile(ctx context.Context, rooms []models.Room) (int64, error) {
+	ids, roomIDs := roomKeys(rooms)
+
+	var participants []models.Participant
+	if err := as.db.WithContext(ctx).Where("room_id IN ?", roomIDs).Order("id").Find(&participants).Error; err != nil {
+		return 0, fmt.Errorf("查询待归档参与者失败: %w", err)
+	}
+	byRoom := make(map[string][]models.Participant, len(rooms))
+	participantIDs := make([]int, 0, len(participants))
+	for _, p := range participants {
+		byRoom[p.RoomID] = append(byRoom[p.RoomID], p)
+		participantIDs = append(participantIDs, p.ID)
+	}
+
+	records := make([]interface{}, 0, len(rooms))
+	for _, room := range rooms {
+		records = append(records, archivedRoom{Room: room, Participants: byRoom[room.RoomID]})
+	}
+	if err := as.putFile(ctx, "rooms", int64(ids[0]), int64(ids[len(ids)-1]), records); err != nil {
+		return 0, err
+	}
+
+	// 只删除已写入文件的记录
+	err := as.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(participantIDs) > 0 {
+			if err := tx.Where("id IN ?", participantIDs).Delete(&models.Participant{}).Error; err != nil {
+				return fmt.Errorf("删除已归档参与者失败: %w", err)
+			}
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&models.Room{}).Error; err != nil {
+			return fmt.Errorf("删除已归档房间失败: %w", err)
+		}
+		return nil
+	})
+	return int64(len(participants)), err
+}
+
+// ArchiveSessions 分批归档 cutoff 之前结束的持久房间会话及其参与者历史，返回归档的会话数和参与者数
+// 房间当前的会话不归档：其参与者仍在 rtc_participant 中，重新开始时才会移入会话参与者历史表
+func (as *ArchiveService) ArchiveSessions(ctx context.Context, cutoff time.Time) (int64, int64, error) {
+	var totalSessions, totalParticipants int64
+	for batch := 0; as.config.ArchiveMaxBatches == 0 || batch < as.config.ArchiveMaxBatches; batch++ {
+		if batch > 0 && !as.pause(ctx) {
+			break
+		}
+
+		db := as.db.WithContext(ctx)
+		var sessions []models.RoomSession
+		if err := db.
+			Where("finished_at IS NOT NULL AND finished_at < ?", cutoff).
+			Where("session_id NOT IN (?)", db.Model(&models.Room{}).
+				Select("session_id").
+				Where("persistent = ?", models.RoomPersistent)).
+			Order("id").
+			Limit(as.config.ArchiveBatchSize).
+			Find(&sessions).Error; err != nil {
+			return totalSessions, totalParticipants, fmt.Errorf("查询待归档会话失败: %w", err)
+		}
+		if len(sessions) == 0 {
+			break
+		}
+
+		var (
+			participants int64
+			err          error
+		)
+		if as.config.ArchiveMode == ArchiveModeFile {
+			participants, err = as.archiveSessionsToFile(ctx, sessions)
+		} else {
+			participants, err = as.archiveSessionsToTable(ctx, sessions)
+		}
+		if err != nil {
+			return totalSessions, totalParticipants, err
+		}
+
+		totalSessions += int64(len(sessions))
+		totalParticipants += participants
+		metrics.ArchivedRows.WithLabelValues("sessions").Add(float64(len(sessions)))
+		metrics.ArchivedRows.WithLabelValues("session_participants").Add(float64(participants))
+
+		if len(sessions) < as.config.ArchiveBatchSize {
+			break
+		}
+	}
+	return totalSessions, totalParticipants, nil
+}
+
+// archiveSessionsToTable 在一个事务内将会话及其参与者历史复制到归档表并从热表删除
+func (as *ArchiveService) archiveSessionsToTable(ctx context.Context, sessions []models.RoomSession) (int64, error) {
+	ids, sessionIDs := sessionKeys(sessions)
+	now := time.Now()
+
+	var participants int64
+	err := as.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf("INSERT INTO rtc_room_session_archive (%s, archived_at) SELECT %s, ? FROM rtc_room_session WHERE id IN ?",
+			as.sessionColumns, as.sessionColumns), now, ids).Error; err != nil {
+			return fmt.Errorf("写入会话归档表失败: %w", err)
+		}
+		if err := tx.Exec(fmt.Sprintf("INSERT INTO rtc_room_session_participant_archive (%s, archived_at) SELECT %s, ? FROM rtc_room_session_participant WHERE session_id IN ?",
+			as.sessionPartColumns, as.sessionPartColumns), now, sessionIDs).Error; err != nil {
+			return fmt.Errorf("写入会话参与者归档表失败: %w", err)
+		}
+
+		result := tx.Where("session_id IN ?", sessionIDs).Delete(&models.RoomSessionParticipant{})
+		if result.Error != nil {
+			return fmt.Errorf("删除已归档会话参与者失败: %w", result.Error)
+		}
+		participants = result.RowsAffected
+
+		if err := tx.Where("id IN ?", ids).Delete(&models.RoomSession{}).Error; err != nil {
+			return fmt.Errorf("删除已归档会话失败: %w", err)
+		}
+		return nil
+	})
+	return participants, err
+}
+
+// archiveSessionsToFile 将会话及其参与者历史写入归档文件，写入成功后再从热表删除
+func (as *ArchiveService) archiveSessionsToFile(ctx context.Context, sessions []models.RoomSession) (int64, error) {
+	ids, sessionIDs := sessionKeys(sessions)
+
+	var participants []models.RoomSessionParticipant
+	if err := as.db.WithContext(ctx).Where("session_id IN ?", sessionIDs).Order("id").Find(&participants).Error; err != nil {
+		return 0, fmt.Errorf("查询待归档会话参与者失败: %w", err)
+	}
+	bySession := make(map[string][]models.RoomSessionParticipant, len(sessions))
+	participantIDs := make([]int, 0, len(participants))
+	for _, p := range participants {
+		bySession[p.SessionID] = append(bySession[p.SessionID], p)
+		participantIDs = append(participantIDs, p.ID)
+	}
+
+	records := make([]interface{}, 0, len(sessions))
+	for _, session := range sessions {
+		records = append(records, archivedSession{Session: session, Participants: bySession[session.SessionID]})
+	}
+	if err := as.putFile(ctx, "sessions", int64(ids[0]), int64(ids[len(ids)-1]), records); err != nil {
+		return 0, err
+	}
+
+	// 只删除已写入文件的记录
+	err := as.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(participantIDs) > 0 {
+			if err := tx.Where("id IN ?", participantIDs).Delete(&models.RoomSessionParticipant{}).Error; err != nil {
+				return fmt.Errorf("删除已归档会话参与者失败: %w", err)
+			}
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&models.RoomSession{}).Error; err != nil {
+			return fmt.Errorf("删除已归档会话失败: %w", err)
+		}
+		return nil
+	})
+	return int64(len(participants)), err
+}
+
+// ArchiveWebhookLogs 分批归档 cutoff 之前的业务 webhook 日志，返回归档的日志数
+// 供 webhook 日志清理任务在 ARCHIVE_WEBHOOK_LOGS=true 时替代直接删除；其他实例正在归档时直接返回
+func (as *ArchiveService) ArchiveWebhookLogs(ctx context.Context, cutoff time.Time) (int64, error) {
+	unlock, ok := as.tryLock(ctx, archiveWebhookLogsLockKey)
+	if !ok {
+		return 0, nil
+	}
+	defer unlock()
+
+	var total int64
+	for batch := 0; as.config.ArchiveMaxBatches == 0 || batch < as.config.ArchiveMaxBatches; batch++ {
+		if batch > 0 && !as.pause(ctx) {
+			break
+		}
+
+		var logs []models.BusinessWebhookLog
+		if err := as.db.WithContext(ctx).
+			Where("created_at < ?", cutoff).
+			Order("id").
+			Limit(as.config.ArchiveBatchSize).
+			Find(&logs).Error; err != nil {
+			return total, fmt.Errorf("查询待归档 webhook 日志失败: %w", err)
+		}
+		if len(logs) == 0 {
+			break
+		}
+
+		ids := make([]int64, len(logs))
+		for i, l := range logs {
+			ids[i] = l.ID
+		}
+
+		var err error
+		if as.config.ArchiveMode == ArchiveModeFile {
+			err = as.archiveWebhookLogsToFile(ctx, logs, ids)
+		} else {
+			err = as.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(fmt.Sprintf("INSERT INTO business_webhook_log_archive (%s, archived_at) SELECT %s, ? FROM business_webhook_log WHERE id IN ?",
+					as.webhookLogColumns, as.webhookLogColumns), time.Now(), ids).Error; err != nil {
+					return fmt.Errorf("写入 webhook 日志归档表失败: %w", err)
+				}
+				return tx.Where("id IN ?", ids).Delete(&models.BusinessWebhookLog{}).Error
+			})
+		}
+		if err != nil {
+			return total, err
+		}
+
+		total += int64(len(logs))
+		metrics.ArchivedRows.WithLabelValues("webhook_logs").Add(float64(len(logs)))
+
+		if len(logs) < as.config.ArchiveBatchSize {
+			break
+		}
+	}
+	return total, nil
+}
+
+// archiveWebhookLogsToFile 将 webhook 日志写入归档文件，写入成功后再从热表删除
+func (as *ArchiveService) archiveWebhookLogsToFile(ctx context.Context, logs []models.BusinessWebhookLog, ids []int64) error {
+	records := make([]interface{}, len(logs))
+	for i := range logs {
+		records[i] = logs[i]
+	}
+	if err := as.putFile(ctx, "webhook_logs", ids[0], ids[len(ids)-1], records); err != nil {
+		return err
+	}
+	if err := as.db.WithContext(ctx).Where("id IN ?", ids).Delete(&models.BusinessWebhookLog{}).Error; err != nil {
+		return fmt.Errorf("删除已归档 webhook 日志失败: %w", err)
+	}
+	return nil
+}
+
+// putFile 将记录编码为 gzip 压缩的 NDJSON（每行一条记录）并写入归档存储
+// key 格式: {prefix}{kind}/YYYY/MM/DD/{kind}-{时间}-{起始ID}-{结束ID}.ndjson.gz
+func (as *ArchiveService) putFile(ctx context.Context, kind string, firstID, lastID int64, records []interface{}) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(gz)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("编码归档记录失败: %w", err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("压缩归档文件失败: %w", err)
+	}
+
+	prefix := as.config.ArchivePrefix
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	now := time.Now().UTC()
+	key := fmt.Sprintf("%s%s/%s/%s-%s-%d-%d.ndjson.gz",
+		prefix, kind, now.Format("2006/01/02"), kind, now.Format("20060102T150405Z"), firstID, lastID)
+
+	if err := as.store.Put(ctx, key, buf.Bytes()); err != nil {
+		return fmt.Errorf("写入归档文件 %s 失败: %w", key, err)
+	}
+	utils.GetLogger().Debug("归档文件已写入",
+		zap.String("store", as.store.Name()),
+		zap.String("key", key),
+		zap.Int("records", len(records)),
+	)
+	return nil
+}
+
+// tryLock 获取归档任务锁，锁的有效期为归档间隔，未配置 Redis 时不加锁
+// 锁被其他实例持有时返回 false
+func (as *ArchiveService) tryLock(ctx context.Context, key string) (func(), bool) {
+	if as.redisClient == nil {
+		return func() {}, true
+	}
+
+	token := generateSessionID()
+	ttl := time.Duration(as.config.ArchiveInterval) * time.Second
+	lockCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	ok, err := as.redisClient.SetNX(lockCtx, key, token, ttl).Result()
+	cancel()
+	if err != nil {
+		utils.GetLogger().Warn("获取归档任务锁失败，跳过本次归档",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	return func() {
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		releaseLockScript.Run(unlockCtx, as.redisClient, []string{key}, token)
+	}, true
+}
+
+// pause 批次之间暂停，限制对数据库的压力；ctx 取消时返回 false
+func (as *ArchiveService) pause(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if as.config.ArchiveBatchPause <= 0 {
+		return true
+	}
+	timer := time.NewTimer(time.Duration(as.config.ArchiveBatchPause) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// roomKeys 返回房间的主键和 room_id 列表
+func roomKeys(rooms []models.Room) ([]int, []string) {
+	ids := make([]int, len(rooms))
+	roomIDs := make([]string, len(rooms))
+	for i, room := range rooms {
+		ids[i] = room.ID
+		roomIDs[i] = room.RoomID
+	}
+	return ids, roomIDs
+}
+
+// sessionKeys 返回会话的主键和 session_id 列表
+func sessionKeys(sessions []models.RoomSession) ([]int, []string) {
+	ids := make([]int, len(sessions))
+	sessionIDs := make([]string, len(sessions))
+	for i, session := range sessions {
+		ids[i] = session.ID
+		sessionIDs[i] = session.SessionID
+	}
+	return ids, sessionIDs
+}
+
+// quotedColumns 返回模型对应表的字段列表（已按方言加引号），用于 INSERT ... SELECT
+func quotedColumns(db *gorm.DB, model interface{}) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return "", fmt.Errorf("解析模型失败: %w", err)
+	}
+	columns := make([]string, len(stmt.Schema.DBNames))
+	for i, name := range stmt.Schema.DBNames {
+		columns[i] = stmt.Quote(name)
+	}
+	return strings.Join(columns, ", "), nil
+}
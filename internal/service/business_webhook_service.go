@@ -11,13 +11,20 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"tgo-rtc-server/internal/config"
+	"tgo-rtc-server/internal/metrics"
 	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/tracing"
 	"tgo-rtc-server/internal/utils"
 
 	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -28,6 +35,11 @@ type BusinessWebhookService struct {
 	redisClient *redis.Client
 	config      *config.Config
 	client      *http.Client
+
+	realtimeService *RealtimeService // 客户端实时事件推送（可选）
+	pushService     *PushService     // 移动端来电推送（可选）
+
+	inflight sync.WaitGroup // 正在投递中的 webhook 请求（优雅关闭时等待完成）
 }
 
 // NewBusinessWebhookService 创建业务 webhook 服务
@@ -42,14 +54,20 @@ func NewBusinessWebhookService(db *gorm.DB, redisClient *redis.Client, cfg *conf
 	}
 }
 
-// SendEvent 发送业务 webhook 事件
-func (bws *BusinessWebhookService) SendEvent(eventType string, data interface{}) error {
-	logger := utils.GetLogger()
+// SetRealtimeService 设置客户端实时事件推送服务
+func (bws *BusinessWebhookService) SetRealtimeService(rts *RealtimeService) {
+	bws.realtimeService = rts
+}
 
-	// 检查是否配置了业务 webhook 端点（如果没有配置则不发送）
-	if len(bws.config.BusinessWebhookEndpoints) == 0 {
-		return nil
-	}
+// SetPushService 设置移动端来电推送服务
+func (bws *BusinessWebhookService) SetPushService(ps *PushService) {
+	bws.pushService = ps
+}
+
+// SendEvent 发送业务 webhook 事件
+// 同一事件同时推送给订阅了实时事件流的客户端
+func (bws *BusinessWebhookService) SendEvent(ctx context.Context, eventType string, data interface{}) error {
+	logger := utils.LoggerFromContext(ctx)
 
 	// 创建事件
 	event := &models.BusinessWebhookEvent{
@@ -60,6 +78,22 @@ func (bws *BusinessWebhookService) SendEvent(eventType string, data interface{})
 		Retry:     0,
 	}
 
+	ctx, span := tracing.Start(ctx, "business_webhook.send_event",
+		tracing.AttrEventType.String(eventType),
+		tracing.AttrEventID.String(event.EventID),
+		tracing.AttrRoomID.String(eventRoomID(data)),
+	)
+	defer span.End()
+
+	if bws.realtimeService != nil {
+		bws.realtimeService.Publish(ctx, event)
+	}
+
+	// 检查是否配置了业务 webhook 端点（如果没有配置则不发送）
+	if len(bws.config.BusinessWebhookEndpoints) == 0 {
+		return nil
+	}
+
 	// 序列化事件
 	payload, err := json.Marshal(data)
 	if err != nil {
@@ -71,31 +105,65 @@ func (bws *BusinessWebhookService) SendEvent(eventType string, data interface{})
 	}
 
 	// 异步发送到所有配置的端点
+	// 投递不随请求结束而取消，链路仍挂在触发事件的请求下
+	deliverCtx := context.WithoutCancel(ctx)
 	for _, endpoint := range bws.config.BusinessWebhookEndpoints {
-		go bws.sendToEndpoint(endpoint, event, payload)
+		bws.inflight.Add(1)
+		go func(endpoint config.WebhookEndpoint) {
+			defer bws.inflight.Done()
+			bws.sendToEndpoint(deliverCtx, endpoint, event, payload)
+		}(endpoint)
 	}
 
 	return nil
 }
 
-// SendRoomFinishedEventOnce 发送房间完成事件（确保同一个房间只发送一次）
-// 使用 Redis 记录已发送的房间ID，避免重复发送
-func (bws *BusinessWebhookService) SendRoomFinishedEventOnce(roomID string, data *models.RoomEventData) error {
-	logger := utils.GetLogger()
+// eventRoomID 获取事件所属的房间 ID
+func eventRoomID(data interface{}) string {
+	switch d := data.(type) {
+	case *models.ParticipantEventData:
+		return d.RoomID
+	case *models.RoomHostChangedEventData:
+		return d.RoomID
+	case *models.RoomEventData:
+		return d.RoomID
+	}
+	return ""
+}
 
-	// 检查是否配置了业务 webhook 端点（如果没有配置则不发送）
-	if len(bws.config.BusinessWebhookEndpoints) == 0 {
+// Drain 等待所有正在投递的 webhook 请求完成（优雅关闭时调用）
+// ctx 超时后返回错误，未完成的投递随进程退出丢失
+func (bws *BusinessWebhookService) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		bws.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
 		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
+
+// SendRoomFinishedEventOnce 发送房间完成事件（确保同一个房间只发送一次）
+// 使用 Redis 记录已发送的房间ID，避免重复发送；持久房间按会话区分
+// 未配置事件接收方时也会记录，保证房间最终状态指标只统计一次
+func (bws *BusinessWebhookService) SendRoomFinishedEventOnce(ctx context.Context, roomID string, data *models.RoomEventData) error {
+	logger := utils.LoggerFromContext(ctx)
 
 	// 构建 Redis key
 	redisKey := fmt.Sprintf("room:finished:sent:%s", roomID)
+	if data.SessionID != "" {
+		redisKey = fmt.Sprintf("room:finished:sent:%s:%s", roomID, data.SessionID)
+	}
 
 	// 检查是否已经发送过
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	exists, err := bws.redisClient.Exists(ctx, redisKey).Result()
+	exists, err := bws.redisClient.Exists(checkCtx, redisKey).Result()
 	if err != nil {
 		logger.Error("检查 Redis key 失败",
 			zap.String("room_id", roomID),
@@ -112,7 +180,7 @@ func (bws *BusinessWebhookService) SendRoomFinishedEventOnce(roomID string, data
 	}
 
 	// 发送事件
-	if err := bws.SendEvent(models.BusinessEventRoomFinished, data); err != nil {
+	if err := bws.SendEvent(ctx, models.BusinessEventRoomFinished, data); err != nil {
 		logger.Error("发送房间完成事件失败",
 			zap.String("room_id", roomID),
 			zap.Error(err),
@@ -120,36 +188,54 @@ func (bws *BusinessWebhookService) SendRoomFinishedEventOnce(roomID string, data
 		return err
 	}
 
-	// 标记为已发送（设置 24 小时过期）
-	ctx2, cancel2 := context.WithTimeout(context.Background(), 5*time.Second)
+	// 标记为已发送（设置 24 小时过期），标记成功的实例统计房间最终状态，发送失败重试时不会重复统计
+	ctx2, cancel2 := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel2()
 
-	if err := bws.redisClient.Set(ctx2, redisKey, "1", 24*time.Hour).Err(); err != nil {
+	marked, err := bws.redisClient.SetNX(ctx2, redisKey, "1", 24*time.Hour).Result()
+	if err != nil {
 		logger.Warn("标记房间完成事件已发送失败",
 			zap.String("room_id", roomID),
 			zap.String("redis_key", redisKey),
 			zap.Error(err),
 		)
 		// 不返回错误，因为事件已经发送成功
+		marked = true
+	}
+	if marked {
+		metrics.RoomsFinished.WithLabelValues(metrics.RoomStatusLabel(data.Status)).Inc()
 	}
 
 	return nil
 }
 
 // sendToEndpoint 发送事件到指定端点
-func (bws *BusinessWebhookService) sendToEndpoint(endpoint config.WebhookEndpoint, event *models.BusinessWebhookEvent, payload []byte) {
-	logger := utils.GetLogger()
+func (bws *BusinessWebhookService) sendToEndpoint(ctx context.Context, endpoint config.WebhookEndpoint, event *models.BusinessWebhookEvent, payload []byte) {
+	logger := utils.LoggerFromContext(ctx)
+
+	endpointLabel := metrics.EndpointLabel(endpoint.URL)
+
+	ctx, span := tracing.Tracer().Start(ctx, "business_webhook.deliver",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("url.full", endpointLabel),
+			tracing.AttrEventType.String(event.EventType),
+			tracing.AttrEventID.String(event.EventID),
+		),
+	)
+	defer span.End()
 
 	// 构建带有 event 参数的 URL
 	// 格式: baseURL?event=room_started
 	u, err := url.Parse(endpoint.URL)
 	if err != nil {
+		metrics.BusinessWebhookFailures.WithLabelValues(endpointLabel, "request").Inc()
 		logger.Error("解析 webhook URL 失败",
 			zap.String("url", endpoint.URL),
 			zap.String("event_id", event.EventID),
 			zap.Error(err),
 		)
-		bws.logWebhookAttempt(event, endpoint.URL, 0, "", err.Error())
+		bws.logWebhookAttempt(ctx, event, endpoint.URL, 0, "", err.Error())
 		return
 	}
 
@@ -161,11 +247,11 @@ func (bws *BusinessWebhookService) sendToEndpoint(endpoint config.WebhookEndpoin
 	finalURL := u.String()
 
 	// 创建带超时的上下文
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(endpoint.Timeout)*time.Second)
+	reqCtx, cancel := context.WithTimeout(ctx, time.Duration(endpoint.Timeout)*time.Second)
 	defer cancel()
 
 	// 创建请求
-	req, err := http.NewRequestWithContext(ctx, "POST", finalURL, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(reqCtx, "POST", finalURL, bytes.NewBuffer(payload))
 	if err != nil {
 		logger.Error("创建 webhook 请求失败",
 			zap.String("url", finalURL),
@@ -173,7 +259,8 @@ func (bws *BusinessWebhookService) sendToEndpoint(endpoint config.WebhookEndpoin
 			zap.Error(err),
 		)
 		// 记录请求创建失败
-		bws.logWebhookAttempt(event, finalURL, 0, "", err.Error())
+		metrics.BusinessWebhookFailures.WithLabelValues(endpointLabel, "request").Inc()
+		bws.logWebhookAttempt(ctx, event, finalURL, 0, "", err.Error())
 		return
 	}
 
@@ -186,20 +273,27 @@ func (bws *BusinessWebhookService) sendToEndpoint(endpoint config.WebhookEndpoin
 	// 计算签名（使用该端点对应的密钥）
 	signature := bws.calculateSignatureWithSecret(payload, endpoint.Secret)
 	req.Header.Set("X-Signature", signature)
+	// 透传链路上下文（traceparent），接收方可延续同一条链路
+	tracing.Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	// 发送请求（使用端点配置的超时时间）
+	start := time.Now()
 	resp, err := bws.client.Do(req)
+	metrics.BusinessWebhookDuration.WithLabelValues(endpointLabel).Observe(time.Since(start).Seconds())
 	if err != nil {
+		tracing.RecordError(span, err)
+		metrics.BusinessWebhookFailures.WithLabelValues(endpointLabel, "network").Inc()
 		logger.Error("发送 webhook 请求失败",
 			zap.String("url", finalURL),
 			zap.String("event_id", event.EventID),
 			zap.Error(err),
 		)
 		// 记录网络错误
-		bws.logWebhookAttempt(event, finalURL, 0, "", err.Error())
+		bws.logWebhookAttempt(ctx, event, finalURL, 0, "", err.Error())
 		return
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 
 	// 读取响应
 	respBody, err := io.ReadAll(resp.Body)
@@ -210,7 +304,8 @@ func (bws *BusinessWebhookService) sendToEndpoint(endpoint config.WebhookEndpoin
 			zap.Error(err),
 		)
 		// 记录响应读取失败
-		bws.logWebhookAttempt(event, finalURL, resp.StatusCode, "", err.Error())
+		metrics.BusinessWebhookFailures.WithLabelValues(endpointLabel, "network").Inc()
+		bws.logWebhookAttempt(ctx, event, finalURL, resp.StatusCode, "", err.Error())
 		return
 	}
 
@@ -226,7 +321,9 @@ func (bws *BusinessWebhookService) sendToEndpoint(endpoint config.WebhookEndpoin
 			zap.String("response", string(respBody)),
 		)
 		// 只记录失败的请求
-		bws.logWebhookAttempt(event, finalURL, resp.StatusCode, string(respBody), "HTTP "+fmt.Sprintf("%d", resp.StatusCode))
+		span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", resp.StatusCode))
+		metrics.BusinessWebhookFailures.WithLabelValues(endpointLabel, "status").Inc()
+		bws.logWebhookAttempt(ctx, event, finalURL, resp.StatusCode, string(respBody), "HTTP "+fmt.Sprintf("%d", resp.StatusCode))
 	}
 }
 
@@ -247,8 +344,9 @@ func (bws *BusinessWebhookService) calculateSignatureWithSecret(payload []byte,
 }
 
 // logWebhookAttempt 记录 webhook 发送尝试
-func (bws *BusinessWebhookService) logWebhookAttempt(event *models.BusinessWebhookEvent, url string, statusCode int, response, errMsg string) {
-	logger := utils.GetLogger()
+func (bws *BusinessWebhookService) logWebhookAttempt(ctx context.Context, event *models.BusinessWebhookEvent, url string, statusCode int, response, errMsg string) {
+	db := bws.db.WithContext(ctx)
+	logger := utils.LoggerFromContext(ctx)
 
 	payload, _ := json.Marshal(event)
 
@@ -265,7 +363,7 @@ func (bws *BusinessWebhookService) logWebhookAttempt(event *models.BusinessWebho
 		UpdatedAt: time.Now(),
 	}
 
-	if err := bws.db.Create(log).Error; err != nil {
+	if err := db.Create(log).Error; err != nil {
 		logger.Error("记录 webhook 日志失败",
 			zap.String("event_id", event.EventID),
 			zap.Error(err),
@@ -343,20 +441,3 @@ func (bws *BusinessWebhookService) GetLogStats() (map[string]interface{}, error)
 
 	return stats, nil
 }
-
-// ArchiveOldLogs 归档旧日志到另一个表（可选）
-// 这个方法可以用于将旧日志移到归档表中
-func (bws *BusinessWebhookService) ArchiveOldLogs(retentionDays int) error {
-	if retentionDays <= 0 {
-		retentionDays = 90 // 默认保留 90 天后归档
-	}
-
-	// 计算截断时间
-	// cutoffTime := time.Now().AddDate(0, 0, -retentionDays)
-
-	// 这里可以实现将旧日志复制到归档表的逻辑
-	// 例如：INSERT INTO business_webhook_log_archive SELECT * FROM business_webhook_log WHERE created_at < ?
-	_ = retentionDays // 占位符，待实现归档逻辑时使用
-
-	return nil
-}
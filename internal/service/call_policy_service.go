@@ -0,0 +1,327 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"tgo-rtc-server/internal/config"
+	"tgo-rtc-server/internal/errors"
+	"tgo-rtc-server/internal/i18n"
+	"tgo-rtc-server/internal/metrics"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/tracing"
+	"tgo-rtc-server/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// callPolicyCacheKeyPrefix 呼叫策略接口结果缓存键前缀，完整键为 call_policy:{caller}:{callee}
+const callPolicyCacheKeyPrefix = "call_policy"
+
+// callPolicyAllowed 缓存中表示允许呼叫的值，拦截时缓存 "0:" + 原因
+const callPolicyAllowed = "1"
+
+// callPolicyUnavailableReason 呼叫策略接口不可用且配置为拒绝时的拦截原因
+const callPolicyUnavailableReason = "policy_unavailable"
+
+// callPolicyDecision 单个被叫的拦截结果
+type callPolicyDecision struct {
+	source string
+	reason string
+}
+
+// CallPolicyService 呼叫策略服务
+// 呼叫/邀请前依次检查被叫的本地屏蔽列表和业务后端呼叫策略接口（可选），被拦截的被叫静默处理
+type CallPolicyService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	config      *config.Config
+	httpClient  *http.Client
+}
+
+// NewCallPolicyService 创建呼叫策略服务
+func NewCallPolicyService(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *CallPolicyService {
+	return &CallPolicyService{
+		db:          db,
+		redisClient: redisClient,
+		config:      cfg,
+		httpClient:  &http.Client{},
+	}
+}
+
+// BlockUser 屏蔽用户（重复屏蔽不报错）
+func (cps *CallPolicyService) BlockUser(ctx context.Context, req *models.BlockUserRequest) error {
+	if req.UID == req.BlockedUID {
+		return errors.NewBusinessErrorWithKey(i18n.BlockSelfNotAllowed)
+	}
+	block := models.UserBlock{
+		UID:        req.UID,
+		BlockedUID: req.BlockedUID,
+	}
+	if err := cps.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error; err != nil {
+		return errors.NewBusinessErrorWithKey(i18n.BlockListUpdateFailed, err.Error())
+	}
+	return nil
+}
+
+// UnblockUser 取消屏蔽用户
+func (cps *CallPolicyService) UnblockUser(ctx context.Context, req *models.BlockUserRequest) error {
+	if err := cps.db.WithContext(ctx).
+		Where("uid = ? AND blocked_uid = ?", req.UID, req.BlockedUID).
+		Delete(&models.UserBlock{}).Error; err != nil {
+		return errors.NewBusinessErrorWithKey(i18n.BlockListUpdateFailed, err.Error())
+	}
+	return nil
+}
+
+// GetBlockList 获取用户的屏蔽列表
+func (cps *CallPolicyService) GetBlockList(ctx context.Context, uid string) (*models.BlockListResp, error) {
+	blockedUIDs := make([]string, 0)
+	if err := cps.db.WithContext(ctx).Model(&models.UserBlock{}).
+		Where("uid = ?", uid).
+		Order("id ASC").
+		Pluck("blocked_uid", &blockedUIDs).Error; err != nil {
+		return nil, errors.NewBusinessErrorWithKey(i18n.BlockListQueryFailed, err.Error())
+	}
+	return &models.BlockListResp{
+		UID:         uid,
+		BlockedUIDs: blockedUIDs,
+	}, nil
+}
+
+// FilterCallees 按呼叫策略过滤被叫，返回允许振铃的被叫和被拦截的被叫
+// 被拦截的被叫由调用方静默处理（不振铃、不推送、不发送事件），主叫侧表现为无人接听；拦截结果记录到 rtc_call_policy_log
+func (cps *CallPolicyService) FilterCallees(ctx context.Context, room *models.Room, caller string, callees []string) (allowed, blocked []string) {
+	if cps == nil || len(callees) == 0 {
+		return callees, nil
+	}
+	ctx, span := tracing.Start(ctx, "call_policy.filter",
+		tracing.AttrRoomID.String(room.RoomID),
+		tracing.AttrUID.String(caller),
+		attribute.Int("call_policy.callees", len(callees)),
+	)
+	defer span.End()
+	logger := utils.LoggerFromContext(ctx)
+
+	decisions := make(map[string]callPolicyDecision)
+
+	// 1. 本地屏蔽列表：被叫屏蔽了主叫
+	var blockers []string
+	if err := cps.db.WithContext(ctx).Model(&models.UserBlock{}).
+		Where("uid IN ? AND blocked_uid = ?", callees, caller).
+		Pluck("uid", &blockers).Error; err != nil {
+		logger.Warn("查询屏蔽列表失败，跳过屏蔽检查",
+			zap.String("room_id", room.RoomID),
+			zap.String("caller", caller),
+			zap.Error(err),
+		)
+	}
+	for _, uid := range blockers {
+		decisions[uid] = callPolicyDecision{source: models.CallPolicySourceBlockList}
+	}
+
+	// 2. 业务后端呼叫策略接口
+	if cps.config.CallPolicyURL != "" {
+		pending := make([]string, 0, len(callees))
+		for _, uid := range callees {
+			if _, ok := decisions[uid]; !ok {
+				pending = append(pending, uid)
+			}
+		}
+		for uid, reason := range cps.checkCallout(ctx, room, caller, pending) {
+			decisions[uid] = callPolicyDecision{source: models.CallPolicySourceCallout, reason: reason}
+		}
+	}
+
+	if len(decisions) == 0 {
+		return callees, nil
+	}
+
+	allowed = make([]string, 0, len(callees))
+	logs := make([]models.CallPolicyLog, 0, len(decisions))
+	for _, uid := range callees {
+		decision, ok := decisions[uid]
+		if !ok {
+			allowed = append(allowed, uid)
+			continue
+		}
+		blocked = append(blocked, uid)
+		logs = append(logs, models.CallPolicyLog{
+			RoomID: room.RoomID,
+			Caller: caller,
+			Callee: uid,
+			Source: decision.source,
+			Reason: decision.reason,
+		})
+		metrics.CallPolicyBlocked.WithLabelValues(decision.source).Inc()
+	}
+	if err := cps.db.WithContext(ctx).Create(&logs).Error; err != nil {
+		logger.Error("记录呼叫策略拦截结果失败",
+			zap.String("room_id", room.RoomID),
+			zap.Error(err),
+		)
+	}
+	logger.Info("呼叫被呼叫策略拦截",
+		zap.String("room_id", room.RoomID),
+		zap.String("caller", caller),
+		zap.Strings("blocked", blocked),
+	)
+	span.SetAttributes(attribute.StringSlice("call_policy.blocked", blocked))
+	return allowed, blocked
+}
+
+// checkCallout 查询业务后端呼叫策略接口，返回被拒绝的被叫及原因
+// 结果按主叫/被叫缓存 CALL_POLICY_CACHE_TTL 秒；接口异常时按 CALL_POLICY_FAIL_OPEN 决定放行或拦截
+func (cps *CallPolicyService) checkCallout(ctx context.Context, room *models.Room, caller string, callees []string) map[string]string {
+	denied := make(map[string]string)
+	if len(callees) == 0 {
+		return denied
+	}
+	logger := utils.LoggerFromContext(ctx)
+	cacheTTL := time.Duration(cps.config.CallPolicyCacheTTL) * time.Second
+	useCache := cps.redisClient != nil && cacheTTL > 0
+
+	uncached := callees
+	if useCache {
+		keys := make([]string, 0, len(callees))
+		for _, uid := range callees {
+			keys = append(keys, callPolicyCacheKey(caller, uid))
+		}
+		values, err := cps.redisClient.MGet(ctx, keys...).Result()
+		if err != nil {
+			logger.Warn("读取呼叫策略缓存失败", zap.Error(err))
+		} else {
+			uncached = make([]string, 0, len(callees))
+			for i, uid := range callees {
+				value, ok := values[i].(string)
+				if !ok {
+					uncached = append(uncached, uid)
+					continue
+				}
+				if value != callPolicyAllowed {
+					denied[uid] = strings.TrimPrefix(value, "0:")
+				}
+			}
+		}
+	}
+	if len(uncached) == 0 {
+		return denied
+	}
+
+	results, err := cps.callout(ctx, &models.CallPolicyRequest{
+		RoomID:  room.RoomID,
+		Caller:  caller,
+		Callees: uncached,
+		RTCType: room.RTCType,
+	})
+	if err != nil {
+		logger.Error("调用呼叫策略接口失败",
+			zap.String("room_id", room.RoomID),
+			zap.String("caller", caller),
+			zap.Bool("fail_open", cps.config.CallPolicyFailOpen),
+			zap.Error(err),
+		)
+		if !cps.config.CallPolicyFailOpen {
+			for _, uid := range uncached {
+				denied[uid] = callPolicyUnavailableReason
+			}
+		}
+		return denied
+	}
+
+	// 接口未返回的被叫视为允许
+	values := make(map[string]string, len(uncached))
+	for _, uid := range uncached {
+		values[uid] = callPolicyAllowed
+		if result, ok := results[uid]; ok && !result.Allowed {
+			denied[uid] = result.Reason
+			values[uid] = "0:" + result.Reason
+		}
+	}
+	if useCache {
+		pipe := cps.redisClient.Pipeline()
+		for uid, value := range values {
+			pipe.Set(ctx, callPolicyCacheKey(caller, uid), value, cacheTTL)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			logger.Warn("写入呼叫策略缓存失败", zap.Error(err))
+		}
+	}
+	return denied
+}
+
+// callout 调用业务后端呼叫策略接口（签名方式与业务 webhook 相同：X-Signature 为请求体的 HMAC-SHA256）
+func (cps *CallPolicyService) callout(ctx context.Context, reqBody *models.CallPolicyRequest) (map[string]models.CallPolicyResult, error) {
+	payload, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, time.Duration(cps.config.CallPolicyTimeout)*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, cps.config.CallPolicyURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cps.config.CallPolicySecret != "" {
+		h := hmac.New(sha256.New, []byte(cps.config.CallPolicySecret))
+		h.Write(payload)
+		req.Header.Set("X-Signature", hex.EncodeToString(h.Sum(nil)))
+	}
+	tracing.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := cps.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("响应状态码 %d: %s", resp.StatusCode, string(body))
+	}
+
+	var policyResp models.CallPolicyResponse
+	if err := json.Unmarshal(body, &policyResp); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	results := make(map[string]models.CallPolicyResult, len(policyResp.Results))
+	for _, result := range policyResp.Results {
+		results[result.UID] = result
+	}
+	return results, nil
+}
+
+// visibleUIDs 提取参与者 UID，排除被呼叫策略拦截的参与者（拦截对主叫/其他成员静默，事件中不出现）
+func visibleUIDs(participants []models.Participant) []string {
+	uids := make([]string, 0, len(participants))
+	for _, p := range participants {
+		if p.Status == models.ParticipantStatusBlocked {
+			continue
+		}
+		uids = append(uids, p.UID)
+	}
+	return uids
+}
+
+// callPolicyCacheKey 呼叫策略缓存键
+func callPolicyCacheKey(caller, callee string) string {
+	return fmt.Sprintf("%s:%s:%s", callPolicyCacheKeyPrefix, caller, callee)
+}
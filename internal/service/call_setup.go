@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"tgo-rtc-server/internal/errors"
+	"tgo-rtc-server/internal/i18n"
+	"tgo-rtc-server/internal/metrics"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// callSetupLockTTL 呼叫建立锁有效期（防止持有者异常退出后锁无法释放）
+	callSetupLockTTL = 10 * time.Second
+	// callSetupLockWait 等待其他请求释放呼叫建立锁的最长时间
+	callSetupLockWait = 5 * time.Second
+)
+
+// acquireLocksScript 同时获取多个锁：任一 key 已被持有则不获取任何锁，避免部分加锁导致死锁
+// ARGV[1]: 锁的值, ARGV[2]: 有效期（毫秒）
+var acquireLocksScript = redis.NewScript(`
+for _, key in ipairs(KEYS) do
+	if redis.call("EXISTS", key) == 1 then
+		return 0
+	end
+end
+for _, key in ipairs(KEYS) do
+	redis.call("SET", key, ARGV[1], "PX", ARGV[2])
+end
+return 1
+`)
+
+// lockCallUIDs 获取主叫和所有被叫的呼叫建立锁，保证同一用户同时只参与一个建立中的呼叫
+// 忙线检查和创建房间在锁内完成，避免两个同时发起的呼叫都通过忙线检查；未配置 Redis 时不加锁
+func (rs *RoomService) lockCallUIDs(ctx context.Context, uids []string) (func(), error) {
+	if rs.redisClient == nil || len(uids) == 0 {
+		return func() {}, nil
+	}
+
+	uids = utils.NewParticipantDeduplicator().DeduplicateUIDs(uids)
+	sort.Strings(uids)
+	keys := make([]string, len(uids))
+	for i, uid := range uids {
+		keys[i] = fmt.Sprintf("call:setup:lock:%s", uid)
+	}
+
+	token := generateSessionID()
+	deadline := time.Now().Add(callSetupLockWait)
+	for {
+		lockCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		ok, err := acquireLocksScript.Run(lockCtx, rs.redisClient, keys, token, callSetupLockTTL.Milliseconds()).Int()
+		cancel()
+		if err != nil {
+			// Redis 失败不影响建房，退化为不加锁
+			utils.LoggerFromContext(ctx).Warn("获取呼叫建立锁失败，继续处理",
+				zap.Strings("uids", uids),
+				zap.Error(err),
+			)
+			return func() {}, nil
+		}
+		if ok == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.NewConflictError(i18n.CallSetupBusy)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return func() {
+		unlockCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		for _, key := range keys {
+			releaseLockScript.Run(unlockCtx, rs.redisClient, []string{key}, token)
+		}
+	}, nil
+}
+
+// findGlareRoom 查找对呼（glare）的房间：被叫之一刚刚呼叫了发起者，发起者在该房间中处于邀请中，
+// 且本次呼叫的所有被叫都已在该房间中（邀请中或已加入），合并后不会遗漏任何人
+func (rs *RoomService) findGlareRoom(ctx context.Context, creator string, callees []string) (*models.Room, error) {
+	if len(callees) == 0 {
+		return nil, nil
+	}
+	db := rs.db.WithContext(ctx)
+
+	var room models.Room
+	if err := db.Where("status = ? AND creator IN ? AND room_id IN (?)",
+		models.RoomStatusNotStarted, callees,
+		db.Model(&models.Participant{}).Select("room_id").
+			Where("uid = ? AND status = ?", creator, models.ParticipantStatusInviting)).
+		Order("id DESC").
+		First(&room).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var inRoom int64
+	if err := db.Model(&models.Participant{}).
+		Where("room_id = ? AND uid IN ? AND status IN ?", room.RoomID, callees, activeParticipantStatuses).
+		Count(&inRoom).Error; err != nil {
+		return nil, err
+	}
+	if int(inRoom) != len(callees) {
+		return nil, nil
+	}
+	return &room, nil
+}
+
+// joinGlareRoom 对呼时发起者直接加入对方已创建的房间，相当于接听对方的呼叫
+func (rs *RoomService) joinGlareRoom(ctx context.Context, room *models.Room, req *models.CreateRoomRequest) (*models.CreateRoomResponse, error) {
+	utils.LoggerFromContext(ctx).Info("检测到对呼，发起者加入对方的房间",
+		zap.String("room_id", room.RoomID),
+		zap.String("creator", req.Creator),
+		zap.String("peer", room.Creator),
+	)
+	metrics.CallGlareMerged.Inc()
+	return rs.participantService.JoinRoom(ctx, &models.JoinRoomRequest{
+		RoomID:     room.RoomID,
+		UID:        req.Creator,
+		DeviceType: req.DeviceType,
+		Invited:    true,
+	})
+}
+
+// lockRoom 锁定房间行（SELECT ... FOR UPDATE），需在事务内调用
+func lockRoom(tx *gorm.DB, roomID string) error {
+	var room models.Room
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("room_id = ?", roomID).
+		First(&room).Error
+}
+
+// lockRoomAndCountActive 锁定房间行并统计通话中（邀请中/已加入）的参与者数，不含 excludeUIDs
+// 需在事务内调用：同一房间的加入/邀请请求在此串行执行，人数检查与写入参与者在同一事务内完成
+// SQLite 不支持行锁，由单连接保证写入串行
+func lockRoomAndCountActive(tx *gorm.DB, roomID string, excludeUIDs ...string) (int64, error) {
+	if err := lockRoom(tx, roomID); err != nil {
+		return 0, err
+	}
+
+	query := tx.Model(&models.Participant{}).
+		Where("room_id = ? AND status IN ?", roomID, activeParticipantStatuses)
+	if len(excludeUIDs) > 0 {
+		query = query.Where("uid NOT IN ?", excludeUIDs)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
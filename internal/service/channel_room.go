@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tgo-rtc-server/internal/errors"
+	"tgo-rtc-server/internal/i18n"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// channelRoomLockTTL 频道建房锁的过期时间，防止持有者异常退出后锁无法释放
+	channelRoomLockTTL = 10 * time.Second
+	// channelRoomLockWait 等待其他请求释放频道建房锁的最长时间
+	channelRoomLockWait = 5 * time.Second
+)
+
+// releaseLockScript 仅当锁的值与持有者一致时才删除，避免误删其他请求的锁
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// lockChannel 获取频道建房锁，减少同一频道并发建房时的数据库锁等待
+// 返回释放锁的函数；未配置 Redis 时不加锁，一致性由 lockChannelRow 保证
+func (rs *RoomService) lockChannel(ctx context.Context, channelID string) (func(), error) {
+	if rs.redisClient == nil {
+		return func() {}, nil
+	}
+
+	key := fmt.Sprintf("channel:room:lock:%s", channelID)
+	token := generateSessionID()
+	deadline := time.Now().Add(channelRoomLockWait)
+	for {
+		lockCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		ok, err := rs.redisClient.SetNX(lockCtx, key, token, channelRoomLockTTL).Result()
+		cancel()
+		if err != nil {
+			// Redis 失败不影响建房，由建房事务内的数据库频道锁保证同一频道只有一个进行中的房间
+			utils.LoggerFromContext(ctx).Warn("获取频道建房锁失败，由数据库频道锁保证串行",
+				zap.String("channel_id", channelID),
+				zap.Error(err),
+			)
+			return func() {}, nil
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.NewConflictError(i18n.ChannelRoomBusy)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return func() {
+		// 请求已取消时仍需释放锁，否则锁要等到过期才释放
+		unlockCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		releaseLockScript.Run(unlockCtx, rs.redisClient, []string{key}, token)
+	}, nil
+}
+
+// lockChannelRow 在事务内锁定频道行（不存在时先创建），同一频道的建房事务在数据库层串行执行
+// Redis 建房锁过期或不可用时，由此保证事务内检查到的进行中房间不会被并发请求改变
+// SQLite 不支持行锁，由单连接保证写入串行
+func lockChannelRow(tx *gorm.DB, channelID string) error {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.ChannelLock{ChannelID: channelID}).Error; err != nil {
+		return err
+	}
+	var lock models.ChannelLock
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("channel_id = ?", channelID).
+		First(&lock).Error
+}
+
+// findActiveChannelRoom 查询频道当前进行中（未开始/进行中）的房间
+func findActiveChannelRoom(db *gorm.DB, channelID string) (*models.Room, error) {
+	var room models.Room
+	if err := db.Where("channel_id = ? AND status IN ?", channelID,
+		[]int{models.RoomStatusNotStarted, models.RoomStatusInProgress}).
+		Order("id DESC").
+		First(&room).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &room, nil
+}
+
+// joinChannelRoom 频道已有进行中的房间时，发起者直接加入该房间而不是创建新的通话
+func (rs *RoomService) joinChannelRoom(ctx context.Context, room *models.Room, req *models.CreateRoomRequest) (*models.CreateRoomResponse, error) {
+	if rs.participantService == nil {
+		return nil, errors.NewConflictError(i18n.ChannelHasActiveRoom)
+	}
+	return rs.participantService.JoinRoom(ctx, &models.JoinRoomRequest{
+		RoomID:     room.RoomID,
+		UID:        req.Creator,
+		DeviceType: req.DeviceType,
+	})
+}
+
+// GetChannelActiveRoom 获取频道当前进行中的房间
+// 只返回房间信息，不生成 Token；加入房间需调用 JoinRoom（校验仅限邀请、等候室和人数上限）
+func (rs *RoomService) GetChannelActiveRoom(ctx context.Context, channelID string) (*models.RoomResp, error) {
+	db := rs.db.WithContext(ctx)
+	room, err := findActiveChannelRoom(db, channelID)
+	if err != nil {
+		return nil, errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, err.Error())
+	}
+	if room == nil {
+		return nil, errors.NewBusinessErrorWithKey(i18n.ChannelNoActiveRoom, channelID)
+	}
+
+	var uids []string
+	if err := db.Model(&models.Participant{}).
+		Where("room_id = ? AND status IN ?", room.RoomID, []int{models.ParticipantStatusInviting, models.ParticipantStatusJoined}).
+		Pluck("uid", &uids).Error; err != nil {
+		return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
+	}
+	if uids == nil {
+		uids = []string{}
+	}
+
+	return &models.RoomResp{
+		RoomID:          room.RoomID,
+		Creator:         room.Creator,
+		Host:            room.HostUID(),
+		RTCType:         room.RTCType,
+		Status:          room.Status,
+		CreatedAt:       rs.timeFormatter.FormatDateTime(room.CreatedAt),
+		MaxParticipants: room.MaxParticipants,
+		UIDs:            uids,
+		SessionID:       room.SessionID,
+		ChannelID:       room.ChannelID,
+	}, nil
+}
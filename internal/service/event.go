@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"tgo-rtc-server/internal/models"
 	"tgo-rtc-server/internal/utils"
 	"time"
@@ -10,8 +11,9 @@ import (
 
 // checkAndFinishRoom 检查房间的所有参与者是否都已结束，如果是则将房间状态改为完成
 // room 参数会被更新，调用者可以使用更新后的 room.Status
-func (ps *BusinessWebhookService) checkAndFinishRoom(room *models.Room) {
-	logger := utils.GetLogger()
+func (ps *BusinessWebhookService) checkAndFinishRoom(ctx context.Context, room *models.Room) {
+	db := ps.db.WithContext(ctx)
+	logger := utils.LoggerFromContext(ctx)
 	isSendWebhook := false
 	// 如果房间已经是完成状态或拒绝状态，跳过
 	if room.Status > models.RoomStatusInProgress {
@@ -19,7 +21,7 @@ func (ps *BusinessWebhookService) checkAndFinishRoom(room *models.Room) {
 	}
 	var duration int64 = 0
 	var participants []models.Participant
-	if err := ps.db.Where("room_id = ?", room.RoomID).Find(&participants).Error; err != nil {
+	if err := db.Where("room_id = ?", room.RoomID).Find(&participants).Error; err != nil {
 		logger.Error("checkAndFinishRoom: 查询房间参与者失败",
 			zap.String("room_id", room.RoomID),
 			zap.Error(err),
@@ -64,7 +66,7 @@ func (ps *BusinessWebhookService) checkAndFinishRoom(room *models.Room) {
 
 		if allFinished {
 			room.Status = uint8(roomStatus)
-			if err := ps.db.Model(&models.Room{}).
+			if err := db.Model(&models.Room{}).
 				Where("room_id = ?", room.RoomID).
 				Update("status", room.Status).Error; err != nil {
 				logger.Error("checkAndFinishRoom: 更新房间状态为完成失败",
@@ -101,21 +103,20 @@ func (ps *BusinessWebhookService) checkAndFinishRoom(room *models.Room) {
 			duration = 0
 		}
 
-		uids := make([]string, 0, len(participants))
-		for _, p := range participants {
-			uids = append(uids, p.UID)
-		}
 		// 发送房间完成事件
-		ps.sendRoomFinished(room, duration, uids)
+		ps.sendRoomFinished(ctx, room, duration, visibleUIDs(participants))
 	}
 }
 
 // 发送房间开始事件
-func (bws *BusinessWebhookService) sendRoomStarted(room *models.Room) {
-	logger := utils.GetLogger()
+func (bws *BusinessWebhookService) sendRoomStarted(ctx context.Context, room *models.Room) {
+	logger := utils.LoggerFromContext(ctx)
 	eventData := &models.RoomEventData{
 		RoomID:          room.RoomID,
+		SessionID:       room.SessionID,
+		ChannelID:       room.ChannelID,
 		Creator:         room.Creator,
+		Host:            room.HostUID(),
 		RTCType:         room.RTCType,
 		InviteOn:        room.InviteOn,
 		Status:          models.RoomStatusInProgress,
@@ -123,12 +124,12 @@ func (bws *BusinessWebhookService) sendRoomStarted(room *models.Room) {
 		CreatedAt:       room.CreatedAt.Unix(),
 		UpdatedAt:       room.UpdatedAt.Unix(),
 	}
-	uids, err := bws.getRoomParticipantsUids(room.RoomID)
+	uids, err := bws.getRoomParticipantsUids(ctx, room.RoomID)
 	if err != nil {
 		return
 	}
 	eventData.Uids = uids
-	if err := bws.SendEvent(models.BusinessEventRoomStarted, eventData); err != nil {
+	if err := bws.SendEvent(ctx, models.BusinessEventRoomStarted, eventData); err != nil {
 		logger.Error("发送业务 webhook 事件失败",
 			zap.String("room_id", room.RoomID),
 			zap.String("event_type", models.BusinessEventRoomStarted),
@@ -139,13 +140,16 @@ func (bws *BusinessWebhookService) sendRoomStarted(room *models.Room) {
 
 // sendRoomFinished 发送房间完成事件
 // 使用 Redis 确保同一个房间只发送一次
-func (bws *BusinessWebhookService) sendRoomFinished(room *models.Room, duration int64, uids []string) {
-	logger := utils.GetLogger()
+func (bws *BusinessWebhookService) sendRoomFinished(ctx context.Context, room *models.Room, duration int64, uids []string) {
+	logger := utils.LoggerFromContext(ctx)
 
 	// 构建事件数据
 	eventData := &models.RoomEventData{
 		RoomID:          room.RoomID,
+		SessionID:       room.SessionID,
+		ChannelID:       room.ChannelID,
 		Creator:         room.Creator,
+		Host:            room.HostUID(),
 		RTCType:         room.RTCType,
 		InviteOn:        room.InviteOn,
 		Status:          room.Status,
@@ -155,8 +159,11 @@ func (bws *BusinessWebhookService) sendRoomFinished(room *models.Room, duration
 		UpdatedAt:       room.UpdatedAt.Unix(),
 		Duration:        duration,
 	}
+	// 记录持久房间会话的最终状态
+	bws.finishRoomSession(ctx, room, duration)
+
 	// 发送业务 webhook 通知（确保同一个房间只发送一次）
-	if err := bws.SendRoomFinishedEventOnce(room.RoomID, eventData); err != nil {
+	if err := bws.SendRoomFinishedEventOnce(ctx, room.RoomID, eventData); err != nil {
 		logger.Error("发送房间完成事件失败",
 			zap.String("room_id", room.RoomID),
 			zap.Error(err),
@@ -166,12 +173,15 @@ func (bws *BusinessWebhookService) sendRoomFinished(room *models.Room, duration
 }
 
 // 发送参与者加入事件
-func (bws *BusinessWebhookService) sendParticipantJoined(room *models.Room, uid string, deviceType string) {
-	logger := utils.GetLogger()
+func (bws *BusinessWebhookService) sendParticipantJoined(ctx context.Context, room *models.Room, uid string, deviceType string) {
+	logger := utils.LoggerFromContext(ctx)
 	eventData := &models.ParticipantEventData{
 		RoomEventData: models.RoomEventData{
 			RoomID:          room.RoomID,
+			SessionID:       room.SessionID,
+			ChannelID:       room.ChannelID,
 			Creator:         room.Creator,
+			Host:            room.HostUID(),
 			RTCType:         room.RTCType,
 			InviteOn:        room.InviteOn,
 			Status:          room.Status,
@@ -182,13 +192,13 @@ func (bws *BusinessWebhookService) sendParticipantJoined(room *models.Room, uid
 		UID:        uid,        // 加入者 UID
 		DeviceType: deviceType, // 设备类型
 	}
-	uids, err := bws.getRoomParticipantsUids(room.RoomID)
+	uids, err := bws.getRoomParticipantsUids(ctx, room.RoomID)
 	if err != nil {
 		return
 	}
 	eventData.Uids = uids
 	// 发送 webhook 事件
-	if err := bws.SendEvent(models.BusinessEventParticipantJoined, eventData); err != nil {
+	if err := bws.SendEvent(ctx, models.BusinessEventParticipantJoined, eventData); err != nil {
 		logger.Error("发送业务 webhook 事件失败",
 			zap.String("room_id", room.RoomID),
 			zap.String("uid", uid),
@@ -200,12 +210,15 @@ func (bws *BusinessWebhookService) sendParticipantJoined(room *models.Room, uid
 }
 
 // 发送参与者离开事件
-func (bws *BusinessWebhookService) sendParticipantLeft(room *models.Room, uid string, uids []string) {
-	logger := utils.GetLogger()
+func (bws *BusinessWebhookService) sendParticipantLeft(ctx context.Context, room *models.Room, uid string, uids []string) {
+	logger := utils.LoggerFromContext(ctx)
 	eventData := &models.ParticipantEventData{
 		RoomEventData: models.RoomEventData{
 			RoomID:          room.RoomID,
+			SessionID:       room.SessionID,
+			ChannelID:       room.ChannelID,
 			Creator:         room.Creator,
+			Host:            room.HostUID(),
 			RTCType:         room.RTCType,
 			InviteOn:        room.InviteOn,
 			Status:          room.Status,
@@ -217,7 +230,7 @@ func (bws *BusinessWebhookService) sendParticipantLeft(room *models.Room, uid st
 		UID: uid, // 离开者是当前离开的参与者
 	}
 	// 发送一次 webhook 事件
-	if err := bws.SendEvent(models.BusinessEventParticipantLeft, eventData); err != nil {
+	if err := bws.SendEvent(ctx, models.BusinessEventParticipantLeft, eventData); err != nil {
 		logger.Error("发送业务 webhook 事件失败",
 			zap.String("room_id", room.RoomID),
 			zap.String("event_type", models.BusinessEventParticipantLeft),
@@ -227,12 +240,15 @@ func (bws *BusinessWebhookService) sendParticipantLeft(room *models.Room, uid st
 }
 
 // 发送参与者拒绝事件
-func (bws *BusinessWebhookService) sendParticipantRejected(room *models.Room, uid string, uids []string) {
-	logger := utils.GetLogger()
+func (bws *BusinessWebhookService) sendParticipantRejected(ctx context.Context, room *models.Room, uid string, uids []string) {
+	logger := utils.LoggerFromContext(ctx)
 	eventData := &models.ParticipantEventData{
 		RoomEventData: models.RoomEventData{
 			RoomID:          room.RoomID,
+			SessionID:       room.SessionID,
+			ChannelID:       room.ChannelID,
 			Creator:         room.Creator,
+			Host:            room.HostUID(),
 			RTCType:         room.RTCType,
 			Uids:            uids,
 			InviteOn:        room.InviteOn,
@@ -245,7 +261,7 @@ func (bws *BusinessWebhookService) sendParticipantRejected(room *models.Room, ui
 	}
 
 	// 发送一次 webhook 事件
-	if err := bws.SendEvent(models.BusinessEventParticipantRejected, eventData); err != nil {
+	if err := bws.SendEvent(ctx, models.BusinessEventParticipantRejected, eventData); err != nil {
 		logger.Error("发送业务 webhook 事件失败",
 			zap.String("room_id", room.RoomID),
 			zap.String("event_type", models.BusinessEventParticipantRejected),
@@ -255,12 +271,15 @@ func (bws *BusinessWebhookService) sendParticipantRejected(room *models.Room, ui
 }
 
 // 发送参与者超时事件
-func (bws *BusinessWebhookService) sendParticipantMissed(room *models.Room, uids []string) {
-	logger := utils.GetLogger()
+func (bws *BusinessWebhookService) sendParticipantMissed(ctx context.Context, room *models.Room, uids []string) {
+	logger := utils.LoggerFromContext(ctx)
 	eventData := &models.ParticipantEventData{
 		RoomEventData: models.RoomEventData{
 			RoomID:          room.RoomID,
+			SessionID:       room.SessionID,
+			ChannelID:       room.ChannelID,
 			Creator:         room.Creator,
+			Host:            room.HostUID(),
 			RTCType:         room.RTCType,
 			InviteOn:        room.InviteOn,
 			Status:          room.Status,
@@ -270,13 +289,16 @@ func (bws *BusinessWebhookService) sendParticipantMissed(room *models.Room, uids
 		},
 		MissedUIDs: uids,
 	}
-	uids, err := bws.getRoomParticipantsUids(room.RoomID)
+	if bws.pushService != nil {
+		bws.pushService.NotifyCallEnded(ctx, room, uids, pushReasonMissed)
+	}
+	uids, err := bws.getRoomParticipantsUids(ctx, room.RoomID)
 	if err != nil {
 		return
 	}
 	eventData.Uids = uids
 	// 发送 participant.missed 事件
-	if err := bws.SendEvent(models.BusinessEventParticipantMissed, eventData); err != nil {
+	if err := bws.SendEvent(ctx, models.BusinessEventParticipantMissed, eventData); err != nil {
 		logger.Error("发送业务 webhook 事件失败",
 			zap.String("room_id", room.RoomID),
 			zap.String("event_type", models.BusinessEventParticipantMissed),
@@ -288,13 +310,16 @@ func (bws *BusinessWebhookService) sendParticipantMissed(room *models.Room, uids
 }
 
 // 发送参与者取消事件
-func (bws *BusinessWebhookService) sendParticipantCancelled(room *models.Room, uids []string) {
-	logger := utils.GetLogger()
+func (bws *BusinessWebhookService) sendParticipantCancelled(ctx context.Context, room *models.Room, uids []string) {
+	logger := utils.LoggerFromContext(ctx)
 	// 构建事件数据
 	eventData := &models.ParticipantEventData{
 		RoomEventData: models.RoomEventData{
 			RoomID:          room.RoomID,
+			SessionID:       room.SessionID,
+			ChannelID:       room.ChannelID,
 			Creator:         room.Creator,
+			Host:            room.HostUID(),
 			RTCType:         room.RTCType,
 			InviteOn:        room.InviteOn,
 			Status:          models.RoomStatusCancelled,
@@ -305,8 +330,17 @@ func (bws *BusinessWebhookService) sendParticipantCancelled(room *models.Room, u
 		},
 		UID: room.Creator, // 取消者是房间创建者
 	}
+	if bws.pushService != nil {
+		callees := make([]string, 0, len(uids))
+		for _, uid := range uids {
+			if uid != room.Creator {
+				callees = append(callees, uid)
+			}
+		}
+		bws.pushService.NotifyCallEnded(ctx, room, callees, pushReasonCancelled)
+	}
 	// 发送一次 webhook 事件
-	if err := bws.SendEvent(models.BusinessEventParticipantCancelled, eventData); err != nil {
+	if err := bws.SendEvent(ctx, models.BusinessEventParticipantCancelled, eventData); err != nil {
 		logger.Error("发送业务 webhook 事件失败",
 			zap.String("room_id", room.RoomID),
 			zap.String("event_type", models.BusinessEventParticipantCancelled),
@@ -316,12 +350,16 @@ func (bws *BusinessWebhookService) sendParticipantCancelled(room *models.Room, u
 }
 
 // 发送参与者邀请事件
-func (bws *BusinessWebhookService) sendParticipantInvited(room *models.Room, uids []string, invitedUids []string) {
-	logger := utils.GetLogger()
+// silentUids 为 invitedUids 中免打扰、静默邀请的用户，不推送来电
+func (bws *BusinessWebhookService) sendParticipantInvited(ctx context.Context, room *models.Room, uids []string, invitedUids []string, silentUids []string) {
+	logger := utils.LoggerFromContext(ctx)
 	eventData := &models.ParticipantEventData{
 		RoomEventData: models.RoomEventData{
 			RoomID:          room.RoomID,
+			SessionID:       room.SessionID,
+			ChannelID:       room.ChannelID,
 			Creator:         room.Creator,
+			Host:            room.HostUID(),
 			RTCType:         room.RTCType,
 			InviteOn:        room.InviteOn,
 			Status:          models.RoomStatusInProgress,
@@ -332,9 +370,25 @@ func (bws *BusinessWebhookService) sendParticipantInvited(room *models.Room, uid
 		},
 		UID:         room.Creator, // 邀请者是房间创建者
 		InvitedUIDs: invitedUids,
+		SilentUIDs:  silentUids,
+	}
+	if bws.pushService != nil {
+		silent := make(map[string]bool, len(silentUids))
+		for _, uid := range silentUids {
+			silent[uid] = true
+		}
+		ringUids := make([]string, 0, len(invitedUids))
+		for _, uid := range invitedUids {
+			if !silent[uid] {
+				ringUids = append(ringUids, uid)
+			}
+		}
+		if len(ringUids) > 0 {
+			bws.pushService.NotifyIncomingCall(ctx, room, ringUids)
+		}
 	}
 	// 发送一次 webhook 事件
-	if err := bws.SendEvent(models.BusinessEventParticipantInvited, eventData); err != nil {
+	if err := bws.SendEvent(ctx, models.BusinessEventParticipantInvited, eventData); err != nil {
 		logger.Error("发送业务 webhook 事件失败",
 			zap.String("room_id", room.RoomID),
 			zap.String("event_type", models.BusinessEventParticipantInvited),
@@ -344,21 +398,125 @@ func (bws *BusinessWebhookService) sendParticipantInvited(room *models.Room, uid
 }
 
 // 获取房间所有参与者的 UID 列表
-func (bws *BusinessWebhookService) getRoomParticipantsUids(roomID string) ([]string, error) {
-	logger := utils.GetLogger()
+func (bws *BusinessWebhookService) getRoomParticipantsUids(ctx context.Context, roomID string) ([]string, error) {
+	db := bws.db.WithContext(ctx)
+	logger := utils.LoggerFromContext(ctx)
 	// 查询所有参与者
 	var participants []models.Participant
-	if err := bws.db.Where("room_id = ?", roomID).Find(&participants).Error; err != nil {
+	if err := db.Where("room_id = ?", roomID).Find(&participants).Error; err != nil {
 		logger.Error("查询参与者失败",
 			zap.String("room_id", roomID),
 			zap.Error(err),
 		)
 		return nil, err
 	}
-	uids := make([]string, 0, len(participants))
-	for _, p := range participants {
-		uids = append(uids, p.UID)
+	return visibleUIDs(participants), nil
+
+}
+
+// 发送参与者进入等候室事件（通知主持人处理）
+func (bws *BusinessWebhookService) sendParticipantLobby(ctx context.Context, room *models.Room, uid string, deviceType string) {
+	logger := utils.LoggerFromContext(ctx)
+	eventData := &models.ParticipantEventData{
+		RoomEventData: models.RoomEventData{
+			RoomID:          room.RoomID,
+			SessionID:       room.SessionID,
+			ChannelID:       room.ChannelID,
+			Creator:         room.Creator,
+			Host:            room.HostUID(),
+			RTCType:         room.RTCType,
+			InviteOn:        room.InviteOn,
+			Status:          room.Status,
+			MaxParticipants: room.MaxParticipants,
+			CreatedAt:       room.CreatedAt.Unix(),
+			UpdatedAt:       time.Now().Unix(),
+		},
+		UID:        uid, // 进入等候室的用户
+		DeviceType: deviceType,
+	}
+	uids, err := bws.getRoomParticipantsUids(ctx, room.RoomID)
+	if err != nil {
+		return
+	}
+	eventData.Uids = uids
+	if err := bws.SendEvent(ctx, models.BusinessEventParticipantLobby, eventData); err != nil {
+		logger.Error("发送业务 webhook 事件失败",
+			zap.String("room_id", room.RoomID),
+			zap.String("uid", uid),
+			zap.String("event_type", models.BusinessEventParticipantLobby),
+			zap.Error(err),
+		)
+	}
+}
+
+// 发送等候室准入/拒绝事件
+func (bws *BusinessWebhookService) sendLobbyDecision(ctx context.Context, eventType string, room *models.Room, hostUID string, lobbyUIDs []string) {
+	logger := utils.LoggerFromContext(ctx)
+	eventData := &models.ParticipantEventData{
+		RoomEventData: models.RoomEventData{
+			RoomID:          room.RoomID,
+			SessionID:       room.SessionID,
+			ChannelID:       room.ChannelID,
+			Creator:         room.Creator,
+			Host:            room.HostUID(),
+			RTCType:         room.RTCType,
+			InviteOn:        room.InviteOn,
+			Status:          room.Status,
+			MaxParticipants: room.MaxParticipants,
+			CreatedAt:       room.CreatedAt.Unix(),
+			UpdatedAt:       time.Now().Unix(),
+		},
+		UID:       hostUID, // 操作者是主持人
+		LobbyUIDs: lobbyUIDs,
+	}
+	uids, err := bws.getRoomParticipantsUids(ctx, room.RoomID)
+	if err != nil {
+		return
+	}
+	eventData.Uids = uids
+	if err := bws.SendEvent(ctx, eventType, eventData); err != nil {
+		logger.Error("发送业务 webhook 事件失败",
+			zap.String("room_id", room.RoomID),
+			zap.String("event_type", eventType),
+			zap.Strings("lobby_uids", lobbyUIDs),
+			zap.Error(err),
+		)
 	}
-	return uids, nil
+}
 
+// 发送房间主持人变更事件
+func (bws *BusinessWebhookService) sendRoomHostChanged(ctx context.Context, room *models.Room, previousHost string, reason string, participantsUpdated bool) {
+	logger := utils.LoggerFromContext(ctx)
+	eventData := &models.RoomHostChangedEventData{
+		RoomEventData: models.RoomEventData{
+			RoomID:          room.RoomID,
+			SessionID:       room.SessionID,
+			ChannelID:       room.ChannelID,
+			Creator:         room.Creator,
+			Host:            room.HostUID(),
+			RTCType:         room.RTCType,
+			InviteOn:        room.InviteOn,
+			Status:          room.Status,
+			MaxParticipants: room.MaxParticipants,
+			CreatedAt:       room.CreatedAt.Unix(),
+			UpdatedAt:       time.Now().Unix(),
+		},
+		PreviousHost:        previousHost,
+		Reason:              reason,
+		ParticipantsUpdated: participantsUpdated,
+	}
+	uids, err := bws.getRoomParticipantsUids(ctx, room.RoomID)
+	if err != nil {
+		return
+	}
+	eventData.Uids = uids
+	if err := bws.SendEvent(ctx, models.BusinessEventRoomHostChanged, eventData); err != nil {
+		logger.Error("发送业务 webhook 事件失败",
+			zap.String("room_id", room.RoomID),
+			zap.String("host", room.HostUID()),
+			zap.String("previous_host", previousHost),
+			zap.String("event_type", models.BusinessEventRoomHostChanged),
+			zap.Error(err),
+		)
+	}
 }
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"tgo-rtc-server/internal/config"
+	"tgo-rtc-server/internal/database"
+	"tgo-rtc-server/internal/metrics"
+	"tgo-rtc-server/internal/models"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// HealthService 依赖健康检查服务
+// 数据库（MySQL/PostgreSQL/SQLite）、Redis、数据库迁移为关键依赖；LiveKit 可选检查且为关键依赖；业务 webhook 端点可选检查，异常时仅降级
+type HealthService struct {
+	db               *gorm.DB
+	redisClient      *redis.Client
+	config           *config.Config
+	migrationScripts []database.MigrationScript
+	migrationLoadErr error
+	client           *http.Client
+}
+
+// NewHealthService 创建健康检查服务
+func NewHealthService(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *HealthService {
+	// 迁移脚本编译进二进制（或来自 MIGRATIONS_DIR），启动时加载一次，就绪检查时与迁移记录对比
+	scripts, err := database.LoadMigrations(cfg)
+	return &HealthService{
+		db:               db,
+		redisClient:      redisClient,
+		config:           cfg,
+		migrationScripts: scripts,
+		migrationLoadErr: err,
+		client: &http.Client{
+			// 探测时不跟随跳转，收到任何响应即视为可达
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// CheckReadiness 并发检查所有依赖，返回就绪检查结果
+func (hs *HealthService) CheckReadiness(ctx context.Context) *models.HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(hs.config.HealthCheckTimeout)*time.Second)
+	defer cancel()
+
+	checks := map[string]func(context.Context) *models.HealthCheck{
+		"database":   hs.checkDatabase,
+		"redis":      hs.checkRedis,
+		"migrations": hs.checkMigrations,
+		"livekit":    hs.checkLiveKit,
+		"webhooks":   hs.checkWebhooks,
+	}
+
+	report := &models.HealthReport{
+		Status:    models.HealthStatusOK,
+		Timestamp: time.Now().Unix(),
+		Checks:    make(map[string]*models.HealthCheck, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) *models.HealthCheck) {
+			defer wg.Done()
+			start := time.Now()
+			result := check(ctx)
+			if result.Status != models.HealthStatusSkipped {
+				result.LatencyMs = time.Since(start).Milliseconds()
+			}
+			mu.Lock()
+			report.Checks[name] = result
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != models.HealthStatusUnhealthy {
+			continue
+		}
+		if result.Critical {
+			report.Status = models.HealthStatusUnhealthy
+			break
+		}
+		report.Status = models.HealthStatusDegraded
+	}
+	return report
+}
+
+// checkDatabase 检查数据库连接
+func (hs *HealthService) checkDatabase(ctx context.Context) *models.HealthCheck {
+	result := &models.HealthCheck{Status: models.HealthStatusOK, Critical: true}
+	sqlDB, err := hs.db.DB()
+	if err == nil {
+		err = sqlDB.PingContext(ctx)
+	}
+	if err != nil {
+		result.Status = models.HealthStatusUnhealthy
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// checkRedis 检查 Redis 连接
+func (hs *HealthService) checkRedis(ctx context.Context) *models.HealthCheck {
+	result := &models.HealthCheck{Status: models.HealthStatusOK, Critical: true}
+	if err := hs.redisClient.Ping(ctx).Err(); err != nil {
+		result.Status = models.HealthStatusUnhealthy
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// checkMigrations 检查数据库迁移状态（存在失败或未执行的迁移时不可接收流量）
+// 对比迁移脚本与迁移记录，DB_AUTO_MIGRATE=false 且未执行 migrate up 的实例也视为未就绪
+func (hs *HealthService) checkMigrations(ctx context.Context) *models.HealthCheck {
+	result := &models.HealthCheck{Status: models.HealthStatusOK, Critical: true}
+	if hs.migrationLoadErr != nil {
+		result.Status = models.HealthStatusUnhealthy
+		result.Error = hs.migrationLoadErr.Error()
+		return result
+	}
+	states, err := database.NewMigrationManager(hs.db.WithContext(ctx)).Status(hs.migrationScripts)
+	if err != nil {
+		result.Status = models.HealthStatusUnhealthy
+		result.Error = err.Error()
+		return result
+	}
+
+	counts := map[string]int{
+		database.MigrationStateApplied:  0,
+		database.MigrationStatePending:  0,
+		database.MigrationStateFailed:   0,
+		database.MigrationStateModified: 0,
+		database.MigrationStateMissing:  0,
+	}
+	for _, state := range states {
+		counts[state.State]++
+	}
+	result.Details = map[string]interface{}{
+		"total":    len(hs.migrationScripts),
+		"applied":  counts[database.MigrationStateApplied],
+		"pending":  counts[database.MigrationStatePending],
+		"failed":   counts[database.MigrationStateFailed],
+		"modified": counts[database.MigrationStateModified],
+		"missing":  counts[database.MigrationStateMissing],
+	}
+	if failed := counts[database.MigrationStateFailed]; failed > 0 {
+		result.Status = models.HealthStatusUnhealthy
+		result.Error = fmt.Sprintf("存在 %d 个执行失败的迁移", failed)
+	} else if pending := counts[database.MigrationStatePending]; pending > 0 {
+		result.Status = models.HealthStatusUnhealthy
+		result.Error = fmt.Sprintf("存在 %d 个未执行的迁移", pending)
+	}
+	return result
+}
+
+// checkLiveKit 检查 LiveKit API 是否可达（需开启 HEALTH_CHECK_LIVEKIT）
+func (hs *HealthService) checkLiveKit(ctx context.Context) *models.HealthCheck {
+	result := &models.HealthCheck{Status: models.HealthStatusSkipped, Critical: true}
+	if !hs.config.HealthCheckLiveKit {
+		return result
+	}
+	// LiveKit 地址可能配置为 ws(s)://，探测时使用对应的 http(s)://
+	url := hs.config.LiveKitURL
+	url = strings.Replace(url, "wss://", "https://", 1)
+	url = strings.Replace(url, "ws://", "http://", 1)
+
+	statusCode, err := hs.probe(ctx, http.MethodGet, url)
+	if err == nil && statusCode >= http.StatusInternalServerError {
+		err = fmt.Errorf("LiveKit 返回状态码 %d", statusCode)
+	}
+	result.Status = models.HealthStatusOK
+	if err != nil {
+		result.Status = models.HealthStatusUnhealthy
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// checkWebhooks 检查业务 webhook 端点是否可达（需开启 HEALTH_CHECK_WEBHOOKS）
+// 端点不可达不影响本服务处理通话，只标记为降级
+func (hs *HealthService) checkWebhooks(ctx context.Context) *models.HealthCheck {
+	result := &models.HealthCheck{Status: models.HealthStatusSkipped, Critical: false}
+	if !hs.config.HealthCheckWebhooks || len(hs.config.BusinessWebhookEndpoints) == 0 {
+		return result
+	}
+
+	endpoints := make([]*models.WebhookEndpointHealth, len(hs.config.BusinessWebhookEndpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range hs.config.BusinessWebhookEndpoints {
+		wg.Add(1)
+		go func(i int, endpoint config.WebhookEndpoint) {
+			defer wg.Done()
+			// 只返回不含查询参数的地址，避免泄露 URL 中携带的凭证
+			item := &models.WebhookEndpointHealth{URL: metrics.EndpointLabel(endpoint.URL), Status: models.HealthStatusOK}
+			// 使用 HEAD 探测，只要端点有响应（即使是 404/405）即视为可达
+			statusCode, err := hs.probe(ctx, http.MethodHead, endpoint.URL)
+			item.StatusCode = statusCode
+			if err == nil && statusCode >= http.StatusInternalServerError {
+				err = fmt.Errorf("返回状态码 %d", statusCode)
+			}
+			if err != nil {
+				item.Status = models.HealthStatusUnhealthy
+				item.Error = err.Error()
+			}
+			endpoints[i] = item
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	result.Status = models.HealthStatusOK
+	result.Details = endpoints
+	for _, item := range endpoints {
+		if item.Status == models.HealthStatusUnhealthy {
+			result.Status = models.HealthStatusUnhealthy
+			result.Error = "部分 webhook 端点不可达"
+			break
+		}
+	}
+	return result
+}
+
+// probe 发起 HTTP 探测请求，返回状态码
+func (hs *HealthService) probe(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := hs.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
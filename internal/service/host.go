@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"tgo-rtc-server/internal/errors"
+	"tgo-rtc-server/internal/i18n"
+	"tgo-rtc-server/internal/livekit"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/tracing"
+	"tgo-rtc-server/internal/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// participantMetadata 构建参与者 Token 元数据，房间主持人获得 host 角色
+func participantMetadata(room *models.Room, uid, deviceType string) livekit.ParticipantMetadata {
+	metadata := livekit.ParticipantMetadata{DeviceType: deviceType}
+	if uid == room.HostUID() {
+		metadata.Role = livekit.RoleHost
+	}
+	return metadata
+}
+
+// TransferHost 主持人将主持人身份转移给房间内其他已加入的参与者
+func (ps *ParticipantService) TransferHost(ctx context.Context, req *models.TransferHostRequest) error {
+	tracing.SetRoom(ctx, req.RoomID, req.UID)
+	db := ps.db.WithContext(ctx)
+	var room models.Room
+	if err := db.Where("room_id = ?", req.RoomID).First(&room).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NewBusinessErrorWithKey(i18n.RoomNotFound, req.RoomID)
+		}
+		return errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, err.Error())
+	}
+	if room.Status != models.RoomStatusNotStarted && room.Status != models.RoomStatusInProgress {
+		return errors.NewBusinessErrorWithKey(i18n.RoomNotActive)
+	}
+	if room.HostUID() != req.UID {
+		return errors.NewBusinessErrorWithKey(i18n.NotRoomHost)
+	}
+	if req.NewHost == req.UID {
+		return errors.NewBusinessErrorWithKey(i18n.HostTransferTargetInvalid, req.NewHost)
+	}
+
+	var count int64
+	if err := db.Model(&models.Participant{}).
+		Where("room_id = ? AND uid = ? AND status = ?", req.RoomID, req.NewHost, models.ParticipantStatusJoined).
+		Count(&count).Error; err != nil {
+		return errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
+	}
+	if count == 0 {
+		return errors.NewBusinessErrorWithKey(i18n.HostTransferTargetInvalid, req.NewHost)
+	}
+
+	changed, err := transferRoomHost(ctx, db, ps.businessWebhookService, ps.roomClient, &room, req.NewHost, models.HostChangeReasonTransfer)
+	if err != nil {
+		return errors.NewBusinessErrorWithKey(i18n.HostTransferFailed, err.Error())
+	}
+	if !changed {
+		// 主持人已被并发修改（如同时触发了自动顺延）
+		return errors.NewBusinessErrorWithKey(i18n.NotRoomHost)
+	}
+	return nil
+}
+
+// promoteNextHost 主持人离开房间后，按加入顺序将主持人身份顺延给下一位仍在房间中的参与者
+// 离开者不是当前主持人或房间内已没有其他参与者时不做处理
+func promoteNextHost(ctx context.Context, db *gorm.DB, bws *BusinessWebhookService, roomClient *livekit.RoomClient, room *models.Room, leftUID string) {
+	logger := utils.LoggerFromContext(ctx)
+	if room.HostUID() != leftUID {
+		return
+	}
+
+	var next models.Participant
+	if err := db.Where("room_id = ? AND uid != ? AND status = ?", room.RoomID, leftUID, models.ParticipantStatusJoined).
+		Order("join_time ASC, id ASC").
+		First(&next).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			logger.Error("查询下一位主持人失败",
+				zap.String("room_id", room.RoomID),
+				zap.Error(err),
+			)
+		}
+		return
+	}
+
+	if _, err := transferRoomHost(ctx, db, bws, roomClient, room, next.UID, models.HostChangeReasonAuto); err != nil {
+		logger.Error("自动顺延主持人失败",
+			zap.String("room_id", room.RoomID),
+			zap.String("previous_host", leftUID),
+			zap.String("new_host", next.UID),
+			zap.Error(err),
+		)
+	}
+}
+
+// transferRoomHost 更新房间主持人，同步已连接参与者的 LiveKit 角色，并发送 room.host_changed 事件
+// 仅当主持人未被并发修改时更新，返回是否更新成功
+func transferRoomHost(ctx context.Context, db *gorm.DB, bws *BusinessWebhookService, roomClient *livekit.RoomClient, room *models.Room, newHost, reason string) (bool, error) {
+	logger := utils.LoggerFromContext(ctx)
+	previousHost := room.HostUID()
+
+	result := db.Model(&models.Room{}).
+		Where("id = ? AND host = ?", room.ID, room.Host).
+		Update("host", newHost)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	room.Host = newHost
+
+	logger.Info("房间主持人已变更",
+		zap.String("room_id", room.RoomID),
+		zap.String("previous_host", previousHost),
+		zap.String("new_host", newHost),
+		zap.String("reason", reason),
+	)
+	participantsUpdated := syncLiveKitHost(ctx, roomClient, room.RoomID, previousHost, newHost)
+	if bws != nil {
+		bws.sendRoomHostChanged(ctx, room, previousHost, reason, participantsUpdated)
+	}
+	return true, nil
+}
+
+// syncLiveKitHost 将新旧主持人在 LiveKit 中已连接会话的元数据角色对调，返回是否全部更新成功
+// 已签发 Token 中的 RoomAdmin 授权无法修改：新主持人重新获取 Token 后才拥有，原主持人的授权保留到 Token 过期
+// 主持人操作（准入、转移等）由本服务按房间当前主持人校验，不依赖 Token 中的授权
+func syncLiveKitHost(ctx context.Context, roomClient *livekit.RoomClient, roomID, previousHost, newHost string) bool {
+	if roomClient == nil {
+		return false
+	}
+	logger := utils.LoggerFromContext(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	updated := true
+	for _, change := range []struct {
+		uid  string
+		role string
+	}{
+		{previousHost, livekit.RoleParticipant},
+		{newHost, livekit.RoleHost},
+	} {
+		if _, err := roomClient.UpdateParticipantRole(ctx, roomID, change.uid, change.role); err != nil {
+			updated = false
+			logger.Warn("更新 LiveKit 参与者角色失败",
+				zap.String("room_id", roomID),
+				zap.String("uid", change.uid),
+				zap.String("role", change.role),
+				zap.Error(err),
+			)
+		}
+	}
+	return updated
+}
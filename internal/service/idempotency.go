@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"tgo-rtc-server/internal/config"
+	"tgo-rtc-server/internal/errors"
+	"tgo-rtc-server/internal/i18n"
+	"tgo-rtc-server/internal/livekit"
+	"tgo-rtc-server/internal/metrics"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// idempotencyPendingTTL 处理中标记的有效期（防止处理请求的实例异常退出后重试一直等待）
+	idempotencyPendingTTL = 30 * time.Second
+	// idempotencyWait 重试请求等待首次请求处理完成的最长时间
+	idempotencyWait = 10 * time.Second
+)
+
+// idempotencyRecord Redis 中保存的幂等记录
+type idempotencyRecord struct {
+	Fingerprint string          `json:"fingerprint"`        // 请求体摘要，相同 Key 对应不同请求时拒绝
+	Done        bool            `json:"done"`               // 首次请求是否已处理完成
+	Response    json.RawMessage `json:"response,omitempty"` // 首次请求的响应
+}
+
+// IdempotencyService 幂等请求服务
+// 客户端携带 Idempotency-Key 重试创建房间、邀请、加入房间时，直接返回首次请求的响应，不再重复执行
+type IdempotencyService struct {
+	redisClient *redis.Client
+	ttl         time.Duration
+}
+
+// NewIdempotencyService 创建幂等请求服务，未配置 Redis 或 IDEMPOTENCY_TTL=0 时返回 nil（不启用）
+func NewIdempotencyService(redisClient *redis.Client, cfg *config.Config) *IdempotencyService {
+	if redisClient == nil || cfg.IdempotencyTTL <= 0 {
+		return nil
+	}
+	return &IdempotencyService{
+		redisClient: redisClient,
+		ttl:         time.Duration(cfg.IdempotencyTTL) * time.Second,
+	}
+}
+
+// Begin 开始处理幂等请求，action 为接口类型，subject 为 Key 的归属（用户或房间）
+// 返回 replayed=true 时 out 已填充首次请求的响应；否则调用方处理完成后需调用 finish：
+// 成功时保存响应，失败（或 resp 为 nil，表示不保存）时删除记录以便客户端重试。key 为空、服务未启用或 Redis 异常时按普通请求处理
+func (is *IdempotencyService) Begin(ctx context.Context, action, subject, key string, req, out interface{}) (bool, func(resp interface{}, err error), error) {
+	noop := func(interface{}, error) {}
+	if is == nil || key == "" {
+		return false, noop, nil
+	}
+	logger := utils.LoggerFromContext(ctx)
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return false, noop, nil
+	}
+	sum := sha256.Sum256(body)
+	fingerprint := hex.EncodeToString(sum[:])
+	redisKey := idempotencyKey(action, subject, key)
+
+	pending, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+	deadline := time.Now().Add(idempotencyWait)
+	for {
+		acquired, err := is.redisClient.SetNX(ctx, redisKey, pending, idempotencyPendingTTL).Result()
+		if err != nil {
+			logger.Warn("幂等记录写入失败，按普通请求处理",
+				zap.String("action", action),
+				zap.Error(err),
+			)
+			return false, noop, nil
+		}
+		if acquired {
+			return false, is.finisher(ctx, action, redisKey, fingerprint), nil
+		}
+
+		raw, err := is.redisClient.Get(ctx, redisKey).Bytes()
+		if err == redis.Nil {
+			// 首次请求失败已删除记录，重新获取
+			continue
+		}
+		if err != nil {
+			logger.Warn("幂等记录读取失败，按普通请求处理",
+				zap.String("action", action),
+				zap.Error(err),
+			)
+			return false, noop, nil
+		}
+		var record idempotencyRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return false, noop, nil
+		}
+		if record.Fingerprint != fingerprint {
+			return false, noop, errors.NewBusinessErrorWithKey(i18n.IdempotencyKeyReused)
+		}
+		if record.Done {
+			if err := json.Unmarshal(record.Response, out); err != nil {
+				return false, noop, nil
+			}
+			logger.Info("幂等请求重放首次响应",
+				zap.String("action", action),
+				zap.String("subject", subject),
+			)
+			metrics.IdempotencyReplays.WithLabelValues(action).Inc()
+			return true, noop, nil
+		}
+		// 首次请求仍在处理中，等待其完成
+		if time.Now().After(deadline) {
+			return false, noop, errors.NewConflictError(i18n.IdempotencyInProgress)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// finisher 返回保存首次请求处理结果的函数
+func (is *IdempotencyService) finisher(ctx context.Context, action, redisKey, fingerprint string) func(resp interface{}, err error) {
+	return func(resp interface{}, err error) {
+		// 客户端超时断开后请求上下文已取消，仍需保存结果供重试使用
+		saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if err != nil || resp == nil {
+			is.redisClient.Del(saveCtx, redisKey)
+			return
+		}
+		body, err := json.Marshal(resp)
+		if err == nil {
+			var record []byte
+			record, err = json.Marshal(idempotencyRecord{Fingerprint: fingerprint, Done: true, Response: body})
+			if err == nil {
+				err = is.redisClient.Set(saveCtx, redisKey, record, is.ttl).Err()
+			}
+		}
+		if err != nil {
+			utils.LoggerFromContext(ctx).Warn("保存幂等响应失败",
+				zap.String("action", action),
+				zap.Error(err),
+			)
+			is.redisClient.Del(saveCtx, redisKey)
+		}
+	}
+}
+
+// idempotencyKey 幂等记录的 Redis key，Key 由客户端生成，长度不定，取摘要
+func idempotencyKey(action, subject, key string) string {
+	sum := sha256.Sum256([]byte(subject + "\n" + key))
+	return fmt.Sprintf("idempotency:%s:%s", action, hex.EncodeToString(sum[:]))
+}
+
+// refreshReplayedToken 重放首次请求的响应时刷新房间状态，房间仍在进行中则重新生成 Token（首次生成的可能已过期）
+func refreshReplayedToken(ctx context.Context, db *gorm.DB, tokenGenerator *livekit.TokenGenerator, resp *models.RoomResp, uid, deviceType string) *models.RoomResp {
+	if resp.Token == "" {
+		return resp
+	}
+	if resp.UID != "" {
+		uid = resp.UID
+	}
+	var room models.Room
+	if err := db.WithContext(ctx).Where("room_id = ?", resp.RoomID).First(&room).Error; err != nil {
+		utils.LoggerFromContext(ctx).Warn("重放响应时查询房间失败，返回首次响应",
+			zap.String("room_id", resp.RoomID),
+			zap.Error(err),
+		)
+		return resp
+	}
+	resp.Status = room.Status
+	resp.Host = room.HostUID()
+	if room.Status != models.RoomStatusNotStarted && room.Status != models.RoomStatusInProgress {
+		return resp
+	}
+	tokenResult, err := tokenGenerator.GenerateTokenWithMetadata(room.RoomID, uid, participantMetadata(&room, uid, deviceType))
+	if err != nil {
+		utils.LoggerFromContext(ctx).Warn("重放响应时生成 Token 失败，返回首次响应",
+			zap.String("room_id", resp.RoomID),
+			zap.Error(err),
+		)
+		return resp
+	}
+	resp.Token = tokenResult.Token
+	resp.URL = tokenResult.URL
+	return resp
+}
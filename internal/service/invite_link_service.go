@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"tgo-rtc-server/internal/config"
+	"tgo-rtc-server/internal/errors"
+	"tgo-rtc-server/internal/i18n"
+	"tgo-rtc-server/internal/livekit"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// guestUIDPrefix 访客 UID 前缀，用于区分业务体系外的用户
+const guestUIDPrefix = "guest_"
+
+// InviteLinkService 邀请链接服务
+// 邀请码格式: <link_id>.<过期时间戳>.<HMAC-SHA256 签名>
+// 签名保证邀请码不可伪造，过期时间无需查库即可校验；使用次数和撤销状态记录在 rtc_invite_link 表中
+type InviteLinkService struct {
+	db                 *gorm.DB
+	participantService *ParticipantService
+	timeFormatter      *utils.TimeFormatter
+	secret             []byte
+	baseURL            string
+	defaultTTL         int
+}
+
+// NewInviteLinkService 创建邀请链接服务
+func NewInviteLinkService(db *gorm.DB, cfg *config.Config, participantService *ParticipantService) *InviteLinkService {
+	return &InviteLinkService{
+		db:                 db,
+		participantService: participantService,
+		timeFormatter:      utils.NewTimeFormatter(),
+		secret:             []byte(cfg.InviteLinkSecret),
+		baseURL:            cfg.InviteLinkBaseURL,
+		defaultTTL:         cfg.InviteLinkDefaultTTL,
+	}
+}
+
+// CreateInviteLink 创建房间邀请链接（仅房间主持人可创建）
+func (ils *InviteLinkService) CreateInviteLink(ctx context.Context, req *models.CreateInviteLinkRequest) (*models.InviteLinkResp, error) {
+	db := ils.db.WithContext(ctx)
+	logger := utils.LoggerFromContext(ctx)
+
+	if len(ils.secret) == 0 {
+		return nil, errors.NewBusinessErrorWithKey(i18n.InviteLinkCreateFailed, "签名密钥未配置")
+	}
+
+	role := req.Role
+	if role == "" {
+		role = livekit.RoleParticipant
+	}
+	if role != livekit.RoleParticipant && role != livekit.RoleViewer {
+		return nil, errors.NewBusinessErrorWithKey(i18n.InvalidParameters)
+	}
+	if req.MaxUses < 0 || req.ExpiresIn < 0 {
+		return nil, errors.NewBusinessErrorWithKey(i18n.InvalidParameters)
+	}
+
+	var room models.Room
+	if err := db.Where("room_id = ?", req.RoomID).First(&room).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewBusinessErrorWithKey(i18n.RoomNotFound, req.RoomID)
+		}
+		return nil, errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, err.Error())
+	}
+	if room.HostUID() != req.UID {
+		return nil, errors.NewBusinessErrorWithKey(i18n.NotRoomHost)
+	}
+	if room.Status == models.RoomStatusFinished || room.Status == models.RoomStatusCancelled {
+		return nil, errors.NewBusinessErrorWithKey(i18n.RoomNotActive)
+	}
+
+	ttl := req.ExpiresIn
+	if ttl == 0 {
+		ttl = ils.defaultTTL
+	}
+	expiresAt := time.Now().Add(time.Duration(ttl) * time.Second)
+
+	link := models.InviteLink{
+		LinkID:    generateSessionID(),
+		RoomID:    req.RoomID,
+		Creator:   req.UID,
+		Role:      role,
+		MaxUses:   req.MaxUses,
+		Status:    models.InviteLinkActive,
+		ExpiresAt: expiresAt,
+	}
+	if err := db.Create(&link).Error; err != nil {
+		return nil, errors.NewBusinessErrorWithKey(i18n.InviteLinkCreateFailed, err.Error())
+	}
+
+	code := ils.signCode(link.LinkID, expiresAt.Unix())
+	logger.Info("创建邀请链接",
+		zap.String("room_id", req.RoomID),
+		zap.String("creator", req.UID),
+		zap.String("link_id", link.LinkID),
+		zap.String("role", role),
+		zap.Int("max_uses", req.MaxUses),
+		zap.Time("expires_at", expiresAt),
+	)
+
+	resp := &models.InviteLinkResp{
+		Code:      code,
+		RoomID:    req.RoomID,
+		Role:      role,
+		MaxUses:   req.MaxUses,
+		ExpiresAt: ils.timeFormatter.FormatDateTime(expiresAt),
+	}
+	if ils.baseURL != "" {
+		resp.URL = ils.baseURL + code
+	}
+	return resp, nil
+}
+
+// RedeemInviteLink 使用邀请码加入房间
+// 持有邀请码的一方未经身份认证，一律以访客身份加入，系统分配访客 UID 并在响应中返回
+func (ils *InviteLinkService) RedeemInviteLink(ctx context.Context, req *models.RedeemInviteLinkRequest) (*models.RoomResp, error) {
+	db := ils.db.WithContext(ctx)
+	logger := utils.LoggerFromContext(ctx)
+
+	linkID, expiresAt, ok := ils.verifyCode(req.Code)
+	if !ok {
+		return nil, errors.NewBusinessErrorWithKey(i18n.InviteLinkInvalid)
+	}
+	if time.Now().Unix() > expiresAt {
+		return nil, errors.NewBusinessErrorWithKey(i18n.InviteLinkExpired)
+	}
+
+	var link models.InviteLink
+	if err := db.Where("link_id = ?", linkID).First(&link).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewBusinessErrorWithKey(i18n.InviteLinkInvalid)
+		}
+		return nil, errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, err.Error())
+	}
+	if link.Status != models.InviteLinkActive {
+		return nil, errors.NewBusinessErrorWithKey(i18n.InviteLinkInvalid)
+	}
+
+	// 原子占用一次使用次数，避免并发兑换超出上限
+	result := db.Model(&models.InviteLink{}).
+		Where("id = ? AND (max_uses = 0 OR used_count < max_uses)", link.ID).
+		Update("used_count", gorm.Expr("used_count + 1"))
+	if result.Error != nil {
+		return nil, errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.NewBusinessErrorWithKey(i18n.InviteLinkExhausted)
+	}
+
+	uid := generateGuestUID()
+	resp, err := ils.participantService.JoinRoom(ctx, &models.JoinRoomRequest{
+		RoomID:     link.RoomID,
+		UID:        uid,
+		DeviceType: req.DeviceType,
+		Invited:    true,
+		Role:       link.Role,
+		Name:       req.Name,
+		Guest:      true,
+	})
+	if err != nil {
+		// 加入失败，归还占用的使用次数
+		if rbErr := db.Model(&models.InviteLink{}).
+			Where("id = ? AND used_count > 0", link.ID).
+			Update("used_count", gorm.Expr("used_count - 1")).Error; rbErr != nil {
+			logger.Error("归还邀请链接使用次数失败",
+				zap.String("link_id", link.LinkID),
+				zap.Error(rbErr),
+			)
+		}
+		return nil, err
+	}
+
+	logger.Info("通过邀请链接加入房间",
+		zap.String("room_id", link.RoomID),
+		zap.String("link_id", link.LinkID),
+		zap.String("uid", uid),
+	)
+	return resp, nil
+}
+
+// signCode 生成带签名的邀请码
+func (ils *InviteLinkService) signCode(linkID string, expiresAt int64) string {
+	payload := linkID + "." + strconv.FormatInt(expiresAt, 10)
+	return payload + "." + ils.sign(payload)
+}
+
+// verifyCode 校验邀请码签名，返回 link_id 和过期时间戳
+func (ils *InviteLinkService) verifyCode(code string) (string, int64, bool) {
+	parts := strings.Split(code, ".")
+	if len(parts) != 3 || len(ils.secret) == 0 {
+		return "", 0, false
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(ils.sign(payload))) {
+		return "", 0, false
+	}
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return parts[0], expiresAt, true
+}
+
+// sign 计算 HMAC-SHA256 签名（base64url 编码）
+func (ils *InviteLinkService) sign(payload string) string {
+	mac := hmac.New(sha256.New, ils.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// generateGuestUID 生成访客 UID
+func generateGuestUID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return guestUIDPrefix + strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return guestUIDPrefix + hex.EncodeToString(b)
+}
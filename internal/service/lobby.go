@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"tgo-rtc-server/internal/errors"
+	"tgo-rtc-server/internal/i18n"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// enterLobby 未获准入（未被邀请/准入且未加入）的用户进入等候室，并通知主持人
+// 等候室中的用户不返回 LiveKit Token，主持人准入后再次调用加入房间接口获取
+// 用户已获准入时返回 (nil, nil)，由调用方继续加入房间
+func (ps *ParticipantService) enterLobby(ctx context.Context, room *models.Room, req *models.JoinRoomRequest) (*models.JoinRoomResponse, error) {
+	db := ps.db.WithContext(ctx)
+	logger := utils.LoggerFromContext(ctx)
+
+	// 锁定房间行，与主持人准入串行执行，避免准入结果被并发的进入等候室请求覆盖
+	admitted, entered := false, false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockRoom(tx, room.RoomID); err != nil {
+			return errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, err.Error())
+		}
+		var existing models.Participant
+		if err := tx.Where("room_id = ? AND uid = ?", room.RoomID, req.UID).First(&existing).Error; err == nil {
+			switch existing.Status {
+			case models.ParticipantStatusInviting, models.ParticipantStatusJoined:
+				admitted = true
+				return nil
+			case models.ParticipantStatusLobby:
+				return nil
+			case models.ParticipantStatusDenied:
+				// 已被主持人拒绝，不再进入等候室，避免反复通知主持人
+				return errors.NewBusinessErrorWithKey(i18n.LobbyEntryDenied)
+			}
+			if err := tx.Model(&existing).Updates(map[string]interface{}{
+				"status":      models.ParticipantStatusLobby,
+				"device_type": req.DeviceType,
+			}).Error; err != nil {
+				return errors.NewBusinessErrorWithKey(i18n.ParticipantStatusUpdateFailed, err.Error())
+			}
+		} else if err == gorm.ErrRecordNotFound {
+			participant := models.Participant{
+				RoomID:     room.RoomID,
+				UID:        req.UID,
+				DeviceType: req.DeviceType,
+				Status:     models.ParticipantStatusLobby,
+			}
+			if err := tx.Create(&participant).Error; err != nil {
+				return errors.NewBusinessErrorWithKey(i18n.ParticipantAddFailed, err.Error())
+			}
+		} else {
+			return errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
+		}
+		entered = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if admitted {
+		return nil, nil
+	}
+
+	// 重复请求不重复通知主持人
+	if entered {
+		logger.Info("参与者进入等候室",
+			zap.String("room_id", room.RoomID),
+			zap.String("uid", req.UID),
+		)
+		if ps.businessWebhookService != nil {
+			ps.businessWebhookService.sendParticipantLobby(ctx, room, req.UID, req.DeviceType)
+		}
+	}
+
+	var uids []string
+	if err := db.Model(&models.Participant{}).
+		Where("room_id = ? AND status IN ?", room.RoomID, []int{models.ParticipantStatusInviting, models.ParticipantStatusJoined}).
+		Pluck("uid", &uids).Error; err != nil {
+		return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
+	}
+	if uids == nil {
+		uids = []string{}
+	}
+
+	return &models.JoinRoomResponse{
+		RoomID:          room.RoomID,
+		Creator:         room.Creator,
+		Host:            room.HostUID(),
+		RTCType:         room.RTCType,
+		Status:          room.Status,
+		CreatedAt:       ps.timeFormatter.FormatDateTime(room.CreatedAt),
+		MaxParticipants: room.MaxParticipants,
+		UIDs:            uids,
+		SessionID:       room.SessionID,
+		ChannelID:       room.ChannelID,
+		InLobby:         true,
+	}, nil
+}
+
+// loadLobbyRoom 查询房间并校验操作者为主持人
+func (ps *ParticipantService) loadLobbyRoom(ctx context.Context, roomID, hostUID string) (*models.Room, error) {
+	db := ps.db.WithContext(ctx)
+	var room models.Room
+	if err := db.Where("room_id = ?", roomID).First(&room).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewBusinessErrorWithKey(i18n.RoomNotFound, roomID)
+		}
+		return nil, errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, err.Error())
+	}
+	if room.Status != models.RoomStatusNotStarted && room.Status != models.RoomStatusInProgress {
+		return nil, errors.NewBusinessErrorWithKey(i18n.RoomNotActive)
+	}
+	if room.HostUID() != hostUID {
+		return nil, errors.NewBusinessErrorWithKey(i18n.NotRoomHost)
+	}
+	return &room, nil
+}
+
+// AdmitLobbyParticipants 主持人准入等候室中的用户
+// 准入后参与者状态变为邀请中，用户再次调用加入房间接口即可获取 Token
+func (ps *ParticipantService) AdmitLobbyParticipants(ctx context.Context, req *models.LobbyDecisionRequest) error {
+	db := ps.db.WithContext(ctx)
+	room, err := ps.loadLobbyRoom(ctx, req.RoomID, req.UID)
+	if err != nil {
+		return err
+	}
+
+	// 人数检查和准入在同一事务内完成，与并发的准入/加入/邀请串行执行，不会超过最大人数
+	var lobbyUIDs []string
+	err = db.Transaction(func(tx *gorm.DB) error {
+		participantCount, err := lockRoomAndCountActive(tx, req.RoomID)
+		if err != nil {
+			return errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
+		}
+		if err := tx.Model(&models.Participant{}).
+			Where("room_id = ? AND uid IN ? AND status = ?", req.RoomID, req.UIDs, models.ParticipantStatusLobby).
+			Pluck("uid", &lobbyUIDs).Error; err != nil {
+			return errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
+		}
+		if len(lobbyUIDs) == 0 {
+			return nil
+		}
+		if int(participantCount)+len(lobbyUIDs) > room.MaxParticipants {
+			return errors.NewBusinessErrorWithKey(i18n.RoomFull)
+		}
+
+		// 重置 created_at 以便超时检查重新计时
+		if err := tx.Model(&models.Participant{}).
+			Where("room_id = ? AND uid IN ? AND status = ?", req.RoomID, lobbyUIDs, models.ParticipantStatusLobby).
+			Updates(map[string]interface{}{
+				"status":     models.ParticipantStatusInviting,
+				"created_at": time.Now(),
+			}).Error; err != nil {
+			return errors.NewBusinessErrorWithKey(i18n.ParticipantStatusUpdateFailed, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(lobbyUIDs) == 0 {
+		return nil
+	}
+	ps.activeCallIndex.SyncRoom(ctx, req.RoomID)
+
+	// 准入的用户需要在超时时间内加入房间
+	if ps.schedulerService != nil {
+		for _, uid := range lobbyUIDs {
+			ps.schedulerService.ScheduleParticipantTimeout(req.RoomID, uid)
+		}
+	}
+
+	if ps.businessWebhookService != nil {
+		ps.businessWebhookService.sendLobbyDecision(ctx, models.BusinessEventParticipantAdmitted, room, req.UID, lobbyUIDs)
+	}
+	return nil
+}
+
+// DenyLobbyParticipants 主持人拒绝等候室中的用户，被拒绝的用户不能再次进入等候室
+func (ps *ParticipantService) DenyLobbyParticipants(ctx context.Context, req *models.LobbyDecisionRequest) error {
+	db := ps.db.WithContext(ctx)
+	room, err := ps.loadLobbyRoom(ctx, req.RoomID, req.UID)
+	if err != nil {
+		return err
+	}
+
+	// 与准入、进入等候室在同一房间行锁上串行执行，拒绝结果不会被并发请求覆盖
+	var lobbyUIDs []string
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := lockRoom(tx, req.RoomID); err != nil {
+			return errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, err.Error())
+		}
+		if err := tx.Model(&models.Participant{}).
+			Where("room_id = ? AND uid IN ? AND status = ?", req.RoomID, req.UIDs, models.ParticipantStatusLobby).
+			Pluck("uid", &lobbyUIDs).Error; err != nil {
+			return errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
+		}
+		if len(lobbyUIDs) == 0 {
+			return nil
+		}
+		if err := tx.Model(&models.Participant{}).
+			Where("room_id = ? AND uid IN ? AND status = ?", req.RoomID, lobbyUIDs, models.ParticipantStatusLobby).
+			Update("status", models.ParticipantStatusDenied).Error; err != nil {
+			return errors.NewBusinessErrorWithKey(i18n.ParticipantStatusUpdateFailed, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(lobbyUIDs) == 0 {
+		return nil
+	}
+
+	if ps.businessWebhookService != nil {
+		ps.businessWebhookService.sendLobbyDecision(ctx, models.BusinessEventParticipantDenied, room, req.UID, lobbyUIDs)
+	}
+	return nil
+}
+
+// GetLobbyParticipants 获取房间等候室中的用户
+func (ps *ParticipantService) GetLobbyParticipants(ctx context.Context, roomID, hostUID string) ([]models.GetParticipantsResponse, error) {
+	db := ps.db.WithContext(ctx)
+	if _, err := ps.loadLobbyRoom(ctx, roomID, hostUID); err != nil {
+		return nil, err
+	}
+
+	var participants []models.Participant
+	if err := db.Where("room_id = ? AND status = ?", roomID, models.ParticipantStatusLobby).
+		Order("updated_at ASC").
+		Find(&participants).Error; err != nil {
+		return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantListQueryFailed, err.Error())
+	}
+
+	result := make([]models.GetParticipantsResponse, 0, len(participants))
+	for _, p := range participants {
+		result = append(result, models.GetParticipantsResponse{
+			ID:         p.ID,
+			RoomID:     p.RoomID,
+			UID:        p.UID,
+			DeviceType: p.DeviceType,
+			Status:     int16(p.Status),
+			JoinTime:   p.JoinTime,
+			LeaveTime:  p.LeaveTime,
+			CreatedAt:  ps.timeFormatter.FormatDateTime(p.CreatedAt),
+			UpdatedAt:  ps.timeFormatter.FormatDateTime(p.UpdatedAt),
+		})
+	}
+	return result, nil
+}
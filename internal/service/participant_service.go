@@ -1,12 +1,15 @@
 package service
 
 import (
+	"context"
 	"time"
 
 	"tgo-rtc-server/internal/errors"
 	"tgo-rtc-server/internal/i18n"
 	"tgo-rtc-server/internal/livekit"
 	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/ratelimit"
+	"tgo-rtc-server/internal/tracing"
 	"tgo-rtc-server/internal/utils"
 
 	"go.uber.org/zap"
@@ -20,6 +23,12 @@ type ParticipantService struct {
 	timeFormatter          *utils.TimeFormatter
 	businessWebhookService *BusinessWebhookService
 	schedulerService       *SchedulerService
+	rateLimiter            *ratelimit.Limiter
+	callPolicyService      *CallPolicyService
+	presenceService        *PresenceService
+	activeCallIndex        *ActiveCallIndex
+	idempotencyService     *IdempotencyService
+	roomClient             *livekit.RoomClient
 }
 
 // NewParticipantService 创建参与者服务
@@ -32,16 +41,72 @@ func NewParticipantService(db *gorm.DB, tokenGenerator *livekit.TokenGenerator,
 	}
 }
 
+// SetActiveCallIndex 设置通话索引
+func (ps *ParticipantService) SetActiveCallIndex(aci *ActiveCallIndex) {
+	ps.activeCallIndex = aci
+}
+
+// SetRoomClient 设置 LiveKit RoomService 客户端（主持人变更时同步已连接参与者的角色）
+func (ps *ParticipantService) SetRoomClient(rc *livekit.RoomClient) {
+	ps.roomClient = rc
+}
+
+// SetIdempotencyService 设置幂等请求服务
+func (ps *ParticipantService) SetIdempotencyService(is *IdempotencyService) {
+	ps.idempotencyService = is
+}
+
 // SetSchedulerService 设置调度器服务
 func (ps *ParticipantService) SetSchedulerService(ss *SchedulerService) {
 	ps.schedulerService = ss
 }
 
+// SetRateLimiter 设置频率限制器
+func (ps *ParticipantService) SetRateLimiter(l *ratelimit.Limiter) {
+	ps.rateLimiter = l
+}
+
+// SetCallPolicyService 设置呼叫策略服务（屏蔽列表/业务呼叫策略）
+func (ps *ParticipantService) SetCallPolicyService(cps *CallPolicyService) {
+	ps.callPolicyService = cps
+}
+
+// SetPresenceService 设置用户可用状态服务（免打扰/离线，同步接口返回参与者可用状态）
+func (ps *ParticipantService) SetPresenceService(prs *PresenceService) {
+	ps.presenceService = prs
+}
+
 // JoinRoom 参与者加入房间
-func (ps *ParticipantService) JoinRoom(req *models.JoinRoomRequest) (*models.JoinRoomResponse, error) {
+// 携带 Idempotency-Key 的重试请求返回首次加入的响应（房间仍在进行中时重新生成 Token）
+func (ps *ParticipantService) JoinRoom(ctx context.Context, req *models.JoinRoomRequest) (*models.JoinRoomResponse, error) {
+	var replayResp models.JoinRoomResponse
+	replayed, finish, err := ps.idempotencyService.Begin(ctx, ratelimit.ActionJoin, req.UID, req.IdempotencyKey, req, &replayResp)
+	if err != nil {
+		return nil, err
+	}
+	if replayed {
+		return refreshReplayedToken(ctx, ps.db, ps.tokenGenerator, &replayResp, req.UID, req.DeviceType), nil
+	}
+	resp, err := ps.joinRoom(ctx, req)
+	if err == nil && resp.InLobby {
+		// 在等候室中等待时不保存响应，重试时重新检查是否已获准入
+		finish(nil, nil)
+		return resp, nil
+	}
+	finish(resp, err)
+	return resp, err
+}
+
+// joinRoom 参与者加入房间
+func (ps *ParticipantService) joinRoom(ctx context.Context, req *models.JoinRoomRequest) (*models.JoinRoomResponse, error) {
+	tracing.SetRoom(ctx, req.RoomID, req.UID)
+	db := ps.db.WithContext(ctx)
+	if err := checkRateLimit(ctx, ps.rateLimiter, ratelimit.ActionJoin, req.UID); err != nil {
+		return nil, err
+	}
 	// 检查房间是否存在
 	var room models.Room
-	if err := ps.db.Where("room_id = ?", req.RoomID).First(&room).Error; err != nil {
+	if err := db.Where("room_id = ?", req.RoomID).First(&room).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, errors.NewBusinessErrorWithKey(i18n.RoomNotFound, req.RoomID)
 		}
@@ -51,22 +116,22 @@ func (ps *ParticipantService) JoinRoom(req *models.JoinRoomRequest) (*models.Joi
 		return nil, errors.NewBusinessErrorWithKey(i18n.RoomNotActive)
 	}
 
-	// 检查房间参与者人数是否已达到最大值（包括邀请中和已加入的）
-	var participantCount int64
-	if err := ps.db.Model(&models.Participant{}).
-		Where("room_id = ? AND status IN ?", req.RoomID, []int{models.ParticipantStatusInviting, models.ParticipantStatusJoined}).
-		Count(&participantCount).Error; err != nil {
-		return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
-	}
-
-	if int(participantCount) >= room.MaxParticipants {
-		return nil, errors.NewBusinessErrorWithKey(i18n.RoomFull)
+	// 房间开启了等候室，未获准入的用户进入等候室，由主持人准入后才能获取 Token
+	// 持有有效邀请链接的用户视为已获准入
+	if room.InviteOn == models.InviteLobby && !req.Invited {
+		lobbyResp, err := ps.enterLobby(ctx, &room, req)
+		if err != nil {
+			return nil, err
+		}
+		if lobbyResp != nil {
+			return lobbyResp, nil
+		}
 	}
 
 	// 如果房间开启了邀请，检查该用户是否被邀请
-	if room.InviteOn == models.InviteEnabled {
+	if room.InviteOn == models.InviteEnabled && !req.Invited {
 		var invitedParticipant models.Participant
-		if err := ps.db.Where("room_id = ? AND uid = ? AND status = ?", req.RoomID, req.UID, models.ParticipantStatusInviting).First(&invitedParticipant).Error; err != nil {
+		if err := db.Where("room_id = ? AND uid = ? AND status = ?", req.RoomID, req.UID, models.ParticipantStatusInviting).First(&invitedParticipant).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantNotInvited)
 			}
@@ -74,57 +139,82 @@ func (ps *ParticipantService) JoinRoom(req *models.JoinRoomRequest) (*models.Joi
 		}
 	}
 
-	// 检查参与者是否已存在
-	var existingParticipant models.Participant
-	if err := ps.db.Where("room_id = ? AND uid = ?", req.RoomID, req.UID).First(&existingParticipant).Error; err == nil {
-		// 参与者已存在，更新状态为已加入
-		if err := ps.db.Model(&existingParticipant).Updates(map[string]interface{}{
-			"status":      models.ParticipantStatusJoined,
-			"join_time":   time.Now().Unix(),
-			"device_type": req.DeviceType,
-		}).Error; err != nil {
-			return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantStatusUpdateFailed, err.Error())
-		}
-		// 取消超时定时器
-		if ps.schedulerService != nil {
-			ps.schedulerService.CancelParticipantTimeout(req.RoomID, req.UID)
+	// 人数检查和写入参与者在同一事务内完成，并发加入时不会超过最大人数
+	existed := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 检查房间参与者人数是否已达到最大值（包括邀请中和已加入的，不含自己）
+		participantCount, err := lockRoomAndCountActive(tx, req.RoomID, req.UID)
+		if err != nil {
+			return errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
 		}
-	} else if err == gorm.ErrRecordNotFound {
-		// 创建新的参与者记录
-		participant := models.Participant{
-			RoomID:     req.RoomID,
-			UID:        req.UID,
-			DeviceType: req.DeviceType,
-			Status:     models.ParticipantStatusJoined,
-			JoinTime:   time.Now().Unix(),
+		if int(participantCount) >= room.MaxParticipants {
+			return errors.NewBusinessErrorWithKey(i18n.RoomFull)
 		}
-		if err := ps.db.Create(&participant).Error; err != nil {
-			return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantAddFailed, err.Error())
+
+		// 检查参与者是否已存在
+		var existingParticipant models.Participant
+		if err := tx.Where("room_id = ? AND uid = ?", req.RoomID, req.UID).First(&existingParticipant).Error; err == nil {
+			existed = true
+			// 参与者已存在，更新状态为已加入
+			if err := tx.Model(&existingParticipant).Updates(map[string]interface{}{
+				"status":      models.ParticipantStatusJoined,
+				"join_time":   time.Now().Unix(),
+				"device_type": req.DeviceType,
+			}).Error; err != nil {
+				return errors.NewBusinessErrorWithKey(i18n.ParticipantStatusUpdateFailed, err.Error())
+			}
+		} else if err == gorm.ErrRecordNotFound {
+			// 创建新的参与者记录
+			participant := models.Participant{
+				RoomID:     req.RoomID,
+				UID:        req.UID,
+				DeviceType: req.DeviceType,
+				Status:     models.ParticipantStatusJoined,
+				JoinTime:   time.Now().Unix(),
+			}
+			if err := tx.Create(&participant).Error; err != nil {
+				return errors.NewBusinessErrorWithKey(i18n.ParticipantAddFailed, err.Error())
+			}
+		} else {
+			return errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
 		}
-	} else {
-		return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	// 取消超时定时器
+	if existed && ps.schedulerService != nil {
+		ps.schedulerService.CancelParticipantTimeout(req.RoomID, req.UID)
+	}
+	ps.activeCallIndex.SyncRoom(ctx, req.RoomID)
 
 	// 生成 Token 和获取配置信息
-	tokenResult, err := ps.tokenGenerator.GenerateTokenWithConfig(req.RoomID, req.UID, req.DeviceType)
+	// 指定了角色（如邀请链接）时以该角色为准，不因主持人身份提升权限
+	metadata := participantMetadata(&room, req.UID, req.DeviceType)
+	if req.Role != "" {
+		metadata.Role = req.Role
+	}
+	metadata.Name = req.Name
+	metadata.Guest = req.Guest
+	tokenResult, err := ps.tokenGenerator.GenerateTokenWithMetadata(req.RoomID, req.UID, metadata)
 	if err != nil {
 		return nil, errors.NewBusinessErrorWithKey(i18n.TokenGenerationFailed, err.Error())
 	}
 
 	// 获取所有参与者的 UIDs
 	var participants []models.Participant
-	if err := ps.db.Where("room_id = ?", req.RoomID).Find(&participants).Error; err != nil {
+	if err := db.Where("room_id = ?", req.RoomID).Find(&participants).Error; err != nil {
 		return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
 	}
 
-	uids := make([]string, 0, len(participants))
-	for _, p := range participants {
-		uids = append(uids, p.UID)
-	}
+	uids := visibleUIDs(participants)
 
 	return &models.JoinRoomResponse{
 		RoomID:          req.RoomID,
+		UID:             req.UID,
 		Creator:         room.Creator,
+		Host:            room.HostUID(),
 		Token:           tokenResult.Token,
 		URL:             tokenResult.URL,
 		RTCType:         room.RTCType,
@@ -133,15 +223,19 @@ func (ps *ParticipantService) JoinRoom(req *models.JoinRoomRequest) (*models.Joi
 		MaxParticipants: room.MaxParticipants,
 		Timeout:         tokenResult.Timeout,
 		UIDs:            uids,
+		SessionID:       room.SessionID,
+		ChannelID:       room.ChannelID,
 	}, nil
 }
 
 // LeaveRoom 参与者离开房间
-func (ps *ParticipantService) LeaveRoom(req *models.LeaveRoomRequest) error {
-	logger := utils.GetLogger()
+func (ps *ParticipantService) LeaveRoom(ctx context.Context, req *models.LeaveRoomRequest) error {
+	tracing.SetRoom(ctx, req.RoomID, req.UID)
+	db := ps.db.WithContext(ctx)
+	logger := utils.LoggerFromContext(ctx)
 	// 检查房间是否存在
 	var room models.Room
-	if err := ps.db.Where("room_id = ?", req.RoomID).First(&room).Error; err != nil {
+	if err := db.Where("room_id = ?", req.RoomID).First(&room).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.Error("离开房间，未查询到房间信息",
 				zap.String("room_id", req.RoomID),
@@ -166,7 +260,7 @@ func (ps *ParticipantService) LeaveRoom(req *models.LeaveRoomRequest) error {
 
 	// 查询当前参与者的状态
 	var currentParticipant models.Participant
-	// if err := ps.db.Where("room_id = ? AND uid = ?", req.RoomID, req.UID).First(&currentParticipant).Error; err != nil {
+	// if err := db.Where("room_id = ? AND uid = ?", req.RoomID, req.UID).First(&currentParticipant).Error; err != nil {
 	// 	if err == gorm.ErrRecordNotFound {
 	// 		logger.Error("离开房间，未查询到参与者信息",
 	// 			zap.String("room_id", req.RoomID),
@@ -179,7 +273,7 @@ func (ps *ParticipantService) LeaveRoom(req *models.LeaveRoomRequest) error {
 
 	// 查询所有参与者
 	var allParticipants []models.Participant
-	if err := ps.db.Where("room_id = ?", req.RoomID).Find(&allParticipants).Error; err != nil {
+	if err := db.Where("room_id = ?", req.RoomID).Find(&allParticipants).Error; err != nil {
 		logger.Error("离开房间，查询参所有参与者错误",
 			zap.String("room_id", req.RoomID),
 			zap.Error(err),
@@ -200,7 +294,9 @@ func (ps *ParticipantService) LeaveRoom(req *models.LeaveRoomRequest) error {
 		} else if p.Status == models.ParticipantStatusMissed {
 			hasMissedOther = true
 		}
-		uids = append(uids, p.UID)
+		if p.Status != models.ParticipantStatusBlocked {
+			uids = append(uids, p.UID)
+		}
 		if p.Status == models.ParticipantStatusJoined || p.Status == models.ParticipantStatusHangup {
 			joinedCount++
 		}
@@ -208,6 +304,13 @@ func (ps *ParticipantService) LeaveRoom(req *models.LeaveRoomRequest) error {
 	if currentParticipant.ID == 0 {
 		return errors.NewBusinessErrorWithKey(i18n.ParticipantNotFound, req.UID)
 	}
+	// 等候室中的用户离开，只取消自己的等待，不影响房间
+	if currentParticipant.Status == models.ParticipantStatusLobby {
+		if err := db.Model(&currentParticipant).Update("status", models.ParticipantStatusCancelled).Error; err != nil {
+			return errors.NewBusinessErrorWithKey(i18n.ParticipantStatusUpdateFailed, err.Error())
+		}
+		return nil
+	}
 
 	if currentParticipant.Status == models.ParticipantStatusJoined || currentParticipant.LeaveTime > 0 {
 		hasJoined = true
@@ -218,17 +321,17 @@ func (ps *ParticipantService) LeaveRoom(req *models.LeaveRoomRequest) error {
 		if isCreator {
 			if hasMissedOther {
 				// 对方已超时未接听，创建者挂断 -> 走正常挂断流程，保留超时状态
-				return ps.handleNormalHangup(&room, req.UID, uids)
+				return ps.handleNormalHangup(ctx, &room, req.UID, uids)
 			}
 			if joinedCount <= 1 {
 				// 只有创建者自己加入，对方还在邀请中 -> 取消通话
-				return ps.handleCreatorCancelCall(&room, uids)
+				return ps.handleCreatorCancelCall(ctx, &room, uids)
 			}
 		} else {
 			// 情况2：非发起者离开（对方拒绝通话或挂断）
 			if !hasJoined {
 				// 对方还未加入就离开 -> 拒绝通话
-				return ps.handleParticipantReject(&room, req.UID, uids)
+				return ps.handleParticipantReject(ctx, &room, req.UID, uids)
 			}
 			// 情况3：双方都已加入 -> 结束通话挂断（走默认逻辑）
 		}
@@ -236,22 +339,30 @@ func (ps *ParticipantService) LeaveRoom(req *models.LeaveRoomRequest) error {
 		// 多人通话场景（MaxParticipants > 2）
 		if !hasJoined {
 			// 情况4：参与者未加入就离开 -> 拒绝通话
-			return ps.handleParticipantReject(&room, req.UID, uids)
+			return ps.handleParticipantReject(ctx, &room, req.UID, uids)
 		}
 		// 情况4：参与者已加入后离开 -> 正常挂断（走默认逻辑）
 	}
 
 	// 默认处理：正常挂断（情况3和情况4b）
-	return ps.handleNormalHangup(&room, req.UID, uids)
+	if err := ps.handleNormalHangup(ctx, &room, req.UID, uids); err != nil {
+		return err
+	}
+	// 多人通话中主持人离开，主持人身份顺延给下一位已加入的参与者
+	if !isOneToOne {
+		promoteNextHost(ctx, db, ps.businessWebhookService, ps.roomClient, &room, req.UID)
+	}
+	return nil
 }
 
 // handleCreatorCancelCall 处理发起者取消通话（情况1）
 // 发起者主动挂断，对方还未加入 -> 取消通话
-func (ps *ParticipantService) handleCreatorCancelCall(room *models.Room, uids []string) error {
-	logger := utils.GetLogger()
+func (ps *ParticipantService) handleCreatorCancelCall(ctx context.Context, room *models.Room, uids []string) error {
+	db := ps.db.WithContext(ctx)
+	logger := utils.LoggerFromContext(ctx)
 
 	// 1. 更新房间状态为已取消
-	if err := ps.db.Model(&models.Room{}).
+	if err := db.Model(&models.Room{}).
 		Where("room_id = ?", room.RoomID).
 		Update("status", models.RoomStatusCancelled).Error; err != nil {
 		logger.Error("更新房间状态失败",
@@ -263,7 +374,7 @@ func (ps *ParticipantService) handleCreatorCancelCall(room *models.Room, uids []
 	}
 
 	// 2. 更新所有参与者状态为已取消
-	if err := ps.db.Model(&models.Participant{}).
+	if err := db.Model(&models.Participant{}).
 		Where("room_id = ?", room.RoomID).
 		Update("status", models.ParticipantStatusCancelled).Error; err != nil {
 		logger.Error("更新参与者状态失败",
@@ -272,22 +383,24 @@ func (ps *ParticipantService) handleCreatorCancelCall(room *models.Room, uids []
 		)
 		return errors.NewBusinessErrorWithKey(i18n.ParticipantStatusUpdateFailed, err.Error())
 	}
+	ps.activeCallIndex.SyncRoom(ctx, room.RoomID)
 
 	// 3. 发送业务 webhook 事件（只发送一次）
 	if ps.businessWebhookService != nil {
-		ps.businessWebhookService.sendParticipantCancelled(room, uids)
-		ps.businessWebhookService.checkAndFinishRoom(room)
+		ps.businessWebhookService.sendParticipantCancelled(ctx, room, uids)
+		ps.businessWebhookService.checkAndFinishRoom(ctx, room)
 	}
 	return nil
 }
 
 // handleParticipantReject 处理参与者拒绝通话（情况2和情况4）
 // 参与者未加入就离开 -> 拒绝通话
-func (ps *ParticipantService) handleParticipantReject(room *models.Room, uid string, uids []string) error {
-	logger := utils.GetLogger()
+func (ps *ParticipantService) handleParticipantReject(ctx context.Context, room *models.Room, uid string, uids []string) error {
+	db := ps.db.WithContext(ctx)
+	logger := utils.LoggerFromContext(ctx)
 
 	// 1. 更新当前参与者状态为已拒绝
-	if err := ps.db.Model(&models.Participant{}).
+	if err := db.Model(&models.Participant{}).
 		Where("room_id = ? AND uid = ?", room.RoomID, uid).
 		Updates(map[string]interface{}{
 			"status": models.ParticipantStatusRejected,
@@ -304,7 +417,7 @@ func (ps *ParticipantService) handleParticipantReject(room *models.Room, uid str
 	// 2. 如果是一对一通话（MaxParticipants=2），更新房间状态和另一个参与者状态
 	if room.MaxParticipants == 2 {
 		// 2.1 更新房间状态为已拒绝
-		if err := ps.db.Model(&models.Room{}).
+		if err := db.Model(&models.Room{}).
 			Where("room_id = ?", room.RoomID).
 			Update("status", models.RoomStatusRejected).Error; err != nil {
 			logger.Error("更新房间状态为拒绝失败",
@@ -316,7 +429,7 @@ func (ps *ParticipantService) handleParticipantReject(room *models.Room, uid str
 		room.Status = models.RoomStatusRejected
 		room.UpdatedAt = time.Now()
 		// 2.2 更新另一个参与者状态为已拒绝
-		if err := ps.db.Model(&models.Participant{}).
+		if err := db.Model(&models.Participant{}).
 			Where("room_id = ? AND uid != ?", room.RoomID, uid).
 			Updates(map[string]interface{}{
 				"status": models.ParticipantStatusRejected,
@@ -330,22 +443,24 @@ func (ps *ParticipantService) handleParticipantReject(room *models.Room, uid str
 			// 这里不返回错误，因为主要操作已经完成
 		}
 	}
+	ps.activeCallIndex.SyncRoom(ctx, room.RoomID)
 
 	// 3. 发送业务 webhook 事件（不管多少人都发送）
 	if ps.businessWebhookService != nil {
-		ps.businessWebhookService.sendParticipantRejected(room, uid, uids)
-		ps.businessWebhookService.checkAndFinishRoom(room)
+		ps.businessWebhookService.sendParticipantRejected(ctx, room, uid, uids)
+		ps.businessWebhookService.checkAndFinishRoom(ctx, room)
 	}
 	return nil
 }
 
 // handleNormalHangup 处理正常挂断（情况3和情况4）
 // 参与者已加入后离开 -> 正常挂断
-func (ps *ParticipantService) handleNormalHangup(room *models.Room, uid string, uids []string) error {
-	logger := utils.GetLogger()
+func (ps *ParticipantService) handleNormalHangup(ctx context.Context, room *models.Room, uid string, uids []string) error {
+	db := ps.db.WithContext(ctx)
+	logger := utils.LoggerFromContext(ctx)
 
 	// 1. 更新当前参与者状态为已挂断
-	if err := ps.db.Model(&models.Participant{}).
+	if err := db.Model(&models.Participant{}).
 		Where("room_id = ? AND uid = ?", room.RoomID, uid).
 		Updates(map[string]interface{}{
 			"status":     models.ParticipantStatusHangup,
@@ -362,7 +477,7 @@ func (ps *ParticipantService) handleNormalHangup(room *models.Room, uid string,
 	// 2. 如果是一对一通话（MaxParticipants=2），更新房间状态和另一个参与者状态
 	if room.MaxParticipants == 2 {
 		// 2.1 更新房间状态为已结束
-		if err := ps.db.Model(&models.Room{}).
+		if err := db.Model(&models.Room{}).
 			Where("room_id = ?", room.RoomID).
 			Update("status", models.RoomStatusFinished).Error; err != nil {
 			logger.Error("更新房间状态为挂断错误",
@@ -375,7 +490,7 @@ func (ps *ParticipantService) handleNormalHangup(room *models.Room, uid string,
 		room.UpdatedAt = time.Now()
 
 		// 2.2 更新另一个参与者状态为已挂断
-		if err := ps.db.Model(&models.Participant{}).
+		if err := db.Model(&models.Participant{}).
 			Where("room_id = ? AND uid != ?", room.RoomID, uid).
 			Updates(map[string]interface{}{
 				"status":     models.ParticipantStatusHangup,
@@ -389,36 +504,55 @@ func (ps *ParticipantService) handleNormalHangup(room *models.Room, uid string,
 			// 这里不返回错误，因为主要操作已经完成
 		}
 	}
+	ps.activeCallIndex.SyncRoom(ctx, room.RoomID)
 
 	if ps.businessWebhookService != nil {
-		// ps.businessWebhookService.sendParticipantLeft(&room, uid)
-		ps.businessWebhookService.checkAndFinishRoom(room)
+		// ps.businessWebhookService.sendParticipantLeft(ctx, &room, uid)
+		ps.businessWebhookService.checkAndFinishRoom(ctx, room)
 	}
 	return nil
 }
 
 // InviteParticipants 邀请参与者
-func (ps *ParticipantService) InviteParticipants(req *models.InviteParticipantRequest) error {
-	logger := utils.GetLogger()
+// 携带 Idempotency-Key 的重试请求返回首次邀请的响应，不再重复邀请和推送
+func (ps *ParticipantService) InviteParticipants(ctx context.Context, req *models.InviteParticipantRequest) (*models.InviteParticipantResponse, error) {
+	var replayResp models.InviteParticipantResponse
+	replayed, finish, err := ps.idempotencyService.Begin(ctx, ratelimit.ActionInvite, req.RoomID, req.IdempotencyKey, req, &replayResp)
+	if err != nil {
+		return nil, err
+	}
+	if replayed {
+		return &replayResp, nil
+	}
+	resp, err := ps.inviteParticipants(ctx, req)
+	finish(resp, err)
+	return resp, err
+}
+
+// inviteParticipants 邀请参与者
+func (ps *ParticipantService) inviteParticipants(ctx context.Context, req *models.InviteParticipantRequest) (*models.InviteParticipantResponse, error) {
+	tracing.SetRoom(ctx, req.RoomID, "")
+	db := ps.db.WithContext(ctx)
+	logger := utils.LoggerFromContext(ctx)
 
 	// 检查房间是否存在
 	var room models.Room
-	if err := ps.db.Where("room_id = ?", req.RoomID).First(&room).Error; err != nil {
+	if err := db.Where("room_id = ?", req.RoomID).First(&room).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return errors.NewBusinessErrorWithKey(i18n.RoomNotFound, req.RoomID)
+			return nil, errors.NewBusinessErrorWithKey(i18n.RoomNotFound, req.RoomID)
 		}
-		return errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, err.Error())
+		return nil, errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, err.Error())
 	}
 
 	// 检查房间状态是否可以邀请（只有未开始或进行中的房间可以邀请）
 	if room.Status != models.RoomStatusNotStarted && room.Status != models.RoomStatusInProgress {
-		return errors.NewBusinessErrorWithKey(i18n.RoomNotActive)
+		return nil, errors.NewBusinessErrorWithKey(i18n.RoomNotActive)
 	}
 
 	// 查询房间参与者
 	var roomParticipants []models.Participant
-	if err := ps.db.Where("room_id = ?", req.RoomID).Find(&roomParticipants).Error; err != nil {
-		return errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
+	if err := db.Where("room_id = ?", req.RoomID).Find(&roomParticipants).Error; err != nil {
+		return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
 	}
 
 	// 检查当前房间参与者人数（包括邀请中和已加入的）
@@ -433,14 +567,31 @@ func (ps *ParticipantService) InviteParticipants(req *models.InviteParticipantRe
 
 	// 检查邀请后是否会超过最大人数
 	if int(currentParticipantCount)+len(req.UIDs) > room.MaxParticipants {
-		return errors.NewBusinessErrorWithKey(i18n.RoomFull)
+		return nil, errors.NewBusinessErrorWithKey(i18n.RoomFull)
+	}
+
+	// 频率限制：未传邀请人时按房间主持人计数
+	inviter := req.UID
+	if inviter == "" {
+		inviter = room.HostUID()
+	}
+	if err := checkRateLimit(ctx, ps.rateLimiter, ratelimit.ActionInvite, inviter); err != nil {
+		return nil, err
+	}
+	// 按呼叫策略过滤被邀请者：被拦截的被邀请者只记录参与者（状态为已拦截），不振铃、不推送
+	invitedUIDs, blockedUIDs := ps.callPolicyService.FilterCallees(ctx, &room, inviter, req.UIDs)
+	// 按被邀请者可用状态分组：免打扰的静默邀请，离线（或免打扰拒接）的不邀请
+	ringUIDs, silentUIDs, unavailableUIDs := ps.presenceService.ClassifyCallees(ctx, invitedUIDs)
+	invitedUIDs = append(ringUIDs, silentUIDs...)
+	if err := checkRingLimit(ctx, ps.rateLimiter, invitedUIDs); err != nil {
+		return nil, err
 	}
 
 	// 批量查询该房间中已存在的参与者（限定在 req.UIDs 范围内）
 	var existingParticipants []models.Participant
-	if err := ps.db.Where("room_id = ? AND uid IN ?", req.RoomID, req.UIDs).
+	if err := db.Where("room_id = ? AND uid IN ?", req.RoomID, req.UIDs).
 		Find(&existingParticipants).Error; err != nil {
-		return errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
+		return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
 	}
 
 	// 构建已存在 uid 的 map，便于快速查找
@@ -450,8 +601,18 @@ func (ps *ParticipantService) InviteParticipants(req *models.InviteParticipantRe
 	}
 
 	// 在事务中处理：已存在的更新状态，不存在的创建新记录
-	err := ps.db.Transaction(func(tx *gorm.DB) error {
-		for _, uid := range req.UIDs {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 锁定房间后重新检查人数，避免并发加入/邀请超过最大人数
+		if len(invitedUIDs) > 0 {
+			otherCount, err := lockRoomAndCountActive(tx, req.RoomID, invitedUIDs...)
+			if err != nil {
+				return errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
+			}
+			if int(otherCount)+len(invitedUIDs) > room.MaxParticipants {
+				return errors.NewBusinessErrorWithKey(i18n.RoomFull)
+			}
+		}
+		for _, uid := range invitedUIDs {
 			if existingParticipant, exists := existingUIDMap[uid]; exists {
 				// 参与者已存在，更新状态为邀请中，并重置 created_at 以便超时检查重新计时
 				if err := tx.Model(&models.Participant{}).
@@ -474,6 +635,40 @@ func (ps *ParticipantService) InviteParticipants(req *models.InviteParticipantRe
 				}
 			}
 		}
+		// 未邀请的被邀请者（已拦截/不可用）只记录状态
+		skipped := []struct {
+			status uint8
+			uids   []string
+		}{
+			{models.ParticipantStatusBlocked, blockedUIDs},
+			{models.ParticipantStatusUnavailable, unavailableUIDs},
+		}
+		for _, group := range skipped {
+			status := group.status
+			for _, uid := range group.uids {
+				existingParticipant, exists := existingUIDMap[uid]
+				if !exists {
+					participant := models.Participant{
+						RoomID: req.RoomID,
+						UID:    uid,
+						Status: status,
+					}
+					if err := tx.Create(&participant).Error; err != nil {
+						return errors.NewBusinessErrorWithKey(i18n.InvitedParticipantAddFailed, err.Error())
+					}
+					continue
+				}
+				// 已在邀请中或已加入的参与者保持原状态
+				if existingParticipant.Status == models.ParticipantStatusInviting || existingParticipant.Status == models.ParticipantStatusJoined {
+					continue
+				}
+				if err := tx.Model(&models.Participant{}).
+					Where("id = ?", existingParticipant.ID).
+					Update("status", status).Error; err != nil {
+					return errors.NewBusinessErrorWithKey(i18n.ParticipantStatusUpdateFailed, err.Error())
+				}
+			}
+		}
 		return nil
 	})
 
@@ -482,38 +677,50 @@ func (ps *ParticipantService) InviteParticipants(req *models.InviteParticipantRe
 			zap.String("room_id", req.RoomID),
 			zap.Error(err),
 		)
-		return err
+		return nil, err
 	}
+	ps.activeCallIndex.SyncRoom(ctx, req.RoomID)
 
 	// 为被邀请的参与者设置超时定时器
 	if ps.schedulerService != nil {
-		for _, uid := range req.UIDs {
+		for _, uid := range invitedUIDs {
 			ps.schedulerService.ScheduleParticipantTimeout(req.RoomID, uid)
 		}
 	}
 
-	// 发送邀请业务 webhook 事件
-	if ps.businessWebhookService != nil {
+	// 发送邀请业务 webhook 事件（被邀请者全部被拦截或不可用时不发送）
+	if ps.businessWebhookService != nil && len(invitedUIDs) > 0 {
 		joinedUids := make([]string, 0, len(roomParticipants))
 		for _, p := range roomParticipants {
 			if p.Status == models.ParticipantStatusJoined {
 				joinedUids = append(joinedUids, p.UID)
 			}
 		}
-		ps.businessWebhookService.sendParticipantInvited(&room, joinedUids, req.UIDs)
+		ps.businessWebhookService.sendParticipantInvited(ctx, &room, joinedUids, invitedUIDs, silentUIDs)
 	}
 
-	return nil
+	return &models.InviteParticipantResponse{
+		SilentUIDs:      silentUIDs,
+		UnavailableUIDs: unavailableUIDs,
+	}, nil
 }
 
 // GetUserAvailableRooms 获取用户可加入的房间列表
 // 查询该用户被邀请（status=0）或已加入（status=1）的所有房间
 // 返回 RoomResp 数组
-func (ps *ParticipantService) GetUserAvailableRooms(uid string, deviceType string) ([]models.RoomResp, error) {
+func (ps *ParticipantService) GetUserAvailableRooms(ctx context.Context, uid string, deviceType string) ([]models.RoomResp, error) {
+	db := ps.db.WithContext(ctx)
 	// 查询用户的参与者记录（邀请中或已加入）
+	// 通话索引可用时先从索引取得用户所在的房间，按 (room_id, uid) 唯一索引查询，避免按 uid 扫描
+	query := db.Where("uid = ? AND status IN ?", uid, activeParticipantStatuses)
+	if indexedRoomIDs, ok := ps.activeCallIndex.UserRoomIDs(ctx, uid); ok {
+		if len(indexedRoomIDs) == 0 {
+			return []models.RoomResp{}, nil
+		}
+		query = query.Where("room_id IN ?", indexedRoomIDs)
+	}
 	var participants []models.Participant
-	if err := ps.db.Where("uid = ? AND status IN ?", uid, []int{models.ParticipantStatusInviting, models.ParticipantStatusJoined}).
-		Find(&participants).Error; err != nil {
+	if err := query.Find(&participants).Error; err != nil {
 		return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
 	}
 
@@ -536,7 +743,7 @@ func (ps *ParticipantService) GetUserAvailableRooms(uid string, deviceType strin
 
 	// 查询所有房间信息（只查询未结束和未取消的房间）
 	var rooms []models.Room
-	if err := ps.db.Where("room_id IN ? AND status IN ?", roomIDs, []int{models.RoomStatusNotStarted, models.RoomStatusInProgress}).
+	if err := db.Where("room_id IN ? AND status IN ?", roomIDs, []int{models.RoomStatusNotStarted, models.RoomStatusInProgress}).
 		Find(&rooms).Error; err != nil {
 		return nil, errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, err.Error())
 	}
@@ -551,19 +758,29 @@ func (ps *ParticipantService) GetUserAvailableRooms(uid string, deviceType strin
 		queryRoomIDs = append(queryRoomIDs, r.RoomID)
 	}
 
-	// 一次性查询这些房间的所有活跃参与者（邀请中或已加入）
-	var allRoomParticipants []models.Participant
-	if err := ps.db.Where("room_id IN ? AND status IN ?", queryRoomIDs, []int{models.ParticipantStatusInviting, models.ParticipantStatusJoined}).
-		Find(&allRoomParticipants).Error; err != nil {
-		return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
-	}
+	// 一次性查询这些房间的所有活跃参与者（邀请中或已加入），通话索引可用时直接从索引读取
+	roomParticipantMap, ok := ps.activeCallIndex.RoomRosters(ctx, queryRoomIDs)
+	if !ok {
+		var allRoomParticipants []models.Participant
+		if err := db.Where("room_id IN ? AND status IN ?", queryRoomIDs, activeParticipantStatuses).
+			Find(&allRoomParticipants).Error; err != nil {
+			return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
+		}
 
-	// 按房间 ID 分组参与者
-	roomParticipantMap := make(map[string][]string)
-	for _, p := range allRoomParticipants {
-		roomParticipantMap[p.RoomID] = append(roomParticipantMap[p.RoomID], p.UID)
+		// 按房间 ID 分组参与者
+		roomParticipantMap = make(map[string][]string)
+		for _, p := range allRoomParticipants {
+			roomParticipantMap[p.RoomID] = append(roomParticipantMap[p.RoomID], p.UID)
+		}
+	}
+	participantUIDs := make([]string, 0)
+	for _, uids := range roomParticipantMap {
+		participantUIDs = append(participantUIDs, uids...)
 	}
 
+	// 一次性查询所有参与者的可用状态
+	presenceMap := ps.presenceService.PresenceMap(ctx, participantUIDs)
+
 	// 构建返回结果
 	result := make([]models.RoomResp, 0, len(rooms))
 	for _, room := range rooms {
@@ -578,7 +795,7 @@ func (ps *ParticipantService) GetUserAvailableRooms(uid string, deviceType strin
 			tempDeviceType = deviceType
 		}
 		// 为每个房间生成 Token
-		tokenResult, err := ps.tokenGenerator.GenerateTokenWithConfig(room.RoomID, uid, tempDeviceType)
+		tokenResult, err := ps.tokenGenerator.GenerateTokenWithMetadata(room.RoomID, uid, participantMetadata(&room, uid, tempDeviceType))
 		if err != nil {
 			return nil, errors.NewBusinessErrorWithKey(i18n.TokenGenerationFailed, err.Error())
 		}
@@ -588,10 +805,18 @@ func (ps *ParticipantService) GetUserAvailableRooms(uid string, deviceType strin
 		if uids == nil {
 			uids = []string{}
 		}
+		var presence map[string]string
+		if presenceMap != nil {
+			presence = make(map[string]string, len(uids))
+			for _, u := range uids {
+				presence[u] = presenceMap[u]
+			}
+		}
 
 		result = append(result, models.RoomResp{
 			RoomID:          room.RoomID,
 			Creator:         room.Creator,
+			Host:            room.HostUID(),
 			Token:           tokenResult.Token,
 			URL:             tokenResult.URL,
 			RTCType:         room.RTCType,
@@ -600,6 +825,9 @@ func (ps *ParticipantService) GetUserAvailableRooms(uid string, deviceType strin
 			MaxParticipants: room.MaxParticipants,
 			Timeout:         tokenResult.Timeout,
 			UIDs:            uids,
+			SessionID:       room.SessionID,
+			ChannelID:       room.ChannelID,
+			Presence:        presence,
 		})
 	}
 
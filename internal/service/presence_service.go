@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"tgo-rtc-server/internal/config"
+	"tgo-rtc-server/internal/errors"
+	"tgo-rtc-server/internal/i18n"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PresenceService 用户可用状态服务
+// 呼叫/邀请时据此决定被叫是正常振铃、静默邀请（免打扰）还是直接返回不可用（离线）
+type PresenceService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewPresenceService 创建用户可用状态服务
+func NewPresenceService(db *gorm.DB, cfg *config.Config) *PresenceService {
+	return &PresenceService{
+		db:     db,
+		config: cfg,
+	}
+}
+
+// SetPresence 设置（或更新）用户可用状态
+func (ps *PresenceService) SetPresence(ctx context.Context, req *models.SetPresenceRequest) (*models.PresenceResp, error) {
+	db := ps.db.WithContext(ctx)
+	var expiresAt *time.Time
+	if req.Duration > 0 {
+		t := time.Now().Add(time.Duration(req.Duration) * time.Second)
+		expiresAt = &t
+	}
+
+	// 按 uid 插入或更新，同一用户首次设置的并发请求不会因唯一索引冲突失败
+	presence := models.UserPresence{
+		UID:       req.UID,
+		Status:    req.Status,
+		ExpiresAt: expiresAt,
+	}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "uid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "expires_at", "updated_at"}),
+	}).Create(&presence).Error; err != nil {
+		return nil, errors.NewBusinessErrorWithKey(i18n.PresenceUpdateFailed, err.Error())
+	}
+	return newPresenceResp(req.UID, req.Status, expiresAt), nil
+}
+
+// GetPresence 批量获取用户当前生效的可用状态（按传入顺序返回）
+func (ps *PresenceService) GetPresence(ctx context.Context, uids []string) ([]models.PresenceResp, error) {
+	presences, err := ps.resolve(ctx, uids)
+	if err != nil {
+		return nil, errors.NewBusinessErrorWithKey(i18n.PresenceQueryFailed, err.Error())
+	}
+	result := make([]models.PresenceResp, 0, len(uids))
+	for _, uid := range uids {
+		result = append(result, presences[uid])
+	}
+	return result, nil
+}
+
+// PresenceMap 获取用户当前生效的可用状态（uid -> 状态），查询失败时返回 nil
+func (ps *PresenceService) PresenceMap(ctx context.Context, uids []string) map[string]string {
+	if ps == nil || len(uids) == 0 {
+		return nil
+	}
+	presences, err := ps.resolve(ctx, uids)
+	if err != nil {
+		utils.LoggerFromContext(ctx).Warn("查询用户可用状态失败",
+			zap.Strings("uids", uids),
+			zap.Error(err),
+		)
+		return nil
+	}
+	result := make(map[string]string, len(presences))
+	for uid, presence := range presences {
+		result[uid] = presence.Status
+	}
+	return result
+}
+
+// ClassifyCallees 按可用状态对被叫分组
+// ring: 正常振铃；silent: 免打扰，静默邀请（不推送，事件中标记）；unavailable: 离线或免打扰拒接，不邀请
+// 查询失败时全部正常振铃
+func (ps *PresenceService) ClassifyCallees(ctx context.Context, callees []string) (ring, silent, unavailable []string) {
+	if ps == nil || len(callees) == 0 {
+		return callees, nil, nil
+	}
+	presences, err := ps.resolve(ctx, callees)
+	if err != nil {
+		utils.LoggerFromContext(ctx).Warn("查询被叫可用状态失败，按可用处理",
+			zap.Strings("callees", callees),
+			zap.Error(err),
+		)
+		return callees, nil, nil
+	}
+
+	ring = make([]string, 0, len(callees))
+	for _, uid := range callees {
+		switch presences[uid].Status {
+		case models.PresenceOffline:
+			unavailable = append(unavailable, uid)
+		case models.PresenceDND:
+			if ps.config.PresenceDNDPolicy == models.PresenceDNDPolicyReject {
+				unavailable = append(unavailable, uid)
+			} else {
+				silent = append(silent, uid)
+			}
+		default:
+			ring = append(ring, uid)
+		}
+	}
+	return ring, silent, unavailable
+}
+
+// resolve 计算用户当前生效的可用状态
+// 优先使用未过期的显式设置；未设置时若开启了 PRESENCE_OFFLINE_AFTER，推送 Token 长期未更新的用户视为离线；其余为可用
+func (ps *PresenceService) resolve(ctx context.Context, uids []string) (map[string]models.PresenceResp, error) {
+	db := ps.db.WithContext(ctx)
+	now := time.Now()
+	result := make(map[string]models.PresenceResp, len(uids))
+	if len(uids) == 0 {
+		return result, nil
+	}
+
+	var presences []models.UserPresence
+	if err := db.Where("uid IN ? AND (expires_at IS NULL OR expires_at > ?)", uids, now).
+		Find(&presences).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range presences {
+		result[p.UID] = *newPresenceResp(p.UID, p.Status, p.ExpiresAt)
+	}
+
+	pending := make([]string, 0, len(uids))
+	for _, uid := range uids {
+		if _, ok := result[uid]; !ok {
+			pending = append(pending, uid)
+		}
+	}
+
+	if ps.config.PresenceOfflineAfter > 0 && len(pending) > 0 {
+		// 只推断有推送 Token 的用户，未注册推送的用户（如 Web 端）按可用处理
+		var staleUIDs []string
+		if err := db.Model(&models.DeviceToken{}).
+			Where("uid IN ?", pending).
+			Group("uid").
+			Having("MAX(updated_at) < ?", now.Add(-time.Duration(ps.config.PresenceOfflineAfter)*time.Second)).
+			Pluck("uid", &staleUIDs).Error; err != nil {
+			return nil, err
+		}
+		for _, uid := range staleUIDs {
+			result[uid] = *newPresenceResp(uid, models.PresenceOffline, nil)
+		}
+	}
+
+	for _, uid := range pending {
+		if _, ok := result[uid]; !ok {
+			result[uid] = *newPresenceResp(uid, models.PresenceAvailable, nil)
+		}
+	}
+	return result, nil
+}
+
+// newPresenceResp 构建可用状态响应
+func newPresenceResp(uid, status string, expiresAt *time.Time) *models.PresenceResp {
+	resp := &models.PresenceResp{
+		UID:    uid,
+		Status: status,
+	}
+	if expiresAt != nil {
+		resp.ExpiresAt = expiresAt.Unix()
+	}
+	return resp
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"time"
+
+	"tgo-rtc-server/internal/config"
+	"tgo-rtc-server/internal/errors"
+	"tgo-rtc-server/internal/i18n"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/push"
+	"tgo-rtc-server/internal/tracing"
+	"tgo-rtc-server/internal/utils"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 来电结束推送原因
+const (
+	pushReasonCancelled = "cancelled" // 发起者取消
+	pushReasonMissed    = "missed"    // 超时未接听
+)
+
+// PushService 移动端来电推送服务
+// 来电时向被邀请者的所有设备推送，取消/超时时推送结束通知让设备停止响铃
+type PushService struct {
+	db        *gorm.DB
+	config    *config.Config
+	providers map[string]push.Provider
+	inflight  sync.WaitGroup // 正在进行中的推送（优雅关闭时等待完成）
+}
+
+// NewPushService 创建推送服务
+func NewPushService(db *gorm.DB, cfg *config.Config, providers map[string]push.Provider) *PushService {
+	return &PushService{
+		db:        db,
+		config:    cfg,
+		providers: providers,
+	}
+}
+
+// Enabled 是否启用了任一推送通道
+func (ps *PushService) Enabled() bool {
+	return len(ps.providers) > 0
+}
+
+// RegisterDeviceToken 注册（或更新）设备推送 Token
+func (ps *PushService) RegisterDeviceToken(ctx context.Context, req *models.RegisterDeviceTokenRequest) error {
+	switch req.Provider {
+	case push.ProviderAPNs, push.ProviderFCM, push.ProviderHMS:
+	default:
+		return errors.NewBusinessErrorWithKey(i18n.PushProviderNotSupported, req.Provider)
+	}
+
+	db := ps.db.WithContext(ctx)
+	var existing models.DeviceToken
+	if err := db.Where("uid = ? AND device_type = ?", req.UID, req.DeviceType).First(&existing).Error; err == nil {
+		if err := db.Model(&existing).Updates(map[string]interface{}{
+			"provider": req.Provider,
+			"token":    req.Token,
+		}).Error; err != nil {
+			return errors.NewBusinessErrorWithKey(i18n.DeviceTokenSaveFailed, err.Error())
+		}
+		return nil
+	} else if err != gorm.ErrRecordNotFound {
+		return errors.NewBusinessErrorWithKey(i18n.DeviceTokenSaveFailed, err.Error())
+	}
+
+	deviceToken := models.DeviceToken{
+		UID:        req.UID,
+		DeviceType: req.DeviceType,
+		Provider:   req.Provider,
+		Token:      req.Token,
+	}
+	if err := db.Create(&deviceToken).Error; err != nil {
+		return errors.NewBusinessErrorWithKey(i18n.DeviceTokenSaveFailed, err.Error())
+	}
+	return nil
+}
+
+// UnregisterDeviceToken 注销设备推送 Token（用户退出登录时调用）
+func (ps *PushService) UnregisterDeviceToken(ctx context.Context, req *models.UnregisterDeviceTokenRequest) error {
+	if err := ps.db.WithContext(ctx).Where("uid = ? AND device_type = ?", req.UID, req.DeviceType).
+		Delete(&models.DeviceToken{}).Error; err != nil {
+		return errors.NewBusinessErrorWithKey(i18n.DeviceTokenSaveFailed, err.Error())
+	}
+	return nil
+}
+
+// NotifyIncomingCall 向被邀请者推送来电
+func (ps *PushService) NotifyIncomingCall(ctx context.Context, room *models.Room, callees []string) {
+	ps.notify(ctx, callees, &push.Notification{
+		Type:    push.TypeIncomingCall,
+		RoomID:  room.RoomID,
+		Caller:  room.Creator,
+		RTCType: room.RTCType,
+		TTL:     ps.config.LiveKitTimeout, // 超过响铃时间送达已无意义
+	})
+}
+
+// NotifyCallEnded 推送来电结束（取消/超时），设备停止响铃
+func (ps *PushService) NotifyCallEnded(ctx context.Context, room *models.Room, uids []string, reason string) {
+	ps.notify(ctx, uids, &push.Notification{
+		Type:    push.TypeCallEnded,
+		RoomID:  room.RoomID,
+		Caller:  room.Creator,
+		RTCType: room.RTCType,
+		Reason:  reason,
+	})
+}
+
+// notify 异步向用户的所有已注册设备推送
+func (ps *PushService) notify(ctx context.Context, uids []string, n *push.Notification) {
+	if !ps.Enabled() || len(uids) == 0 {
+		return
+	}
+	// 推送在后台进行，不随请求结束而取消，链路仍挂在触发推送的请求下
+	ctx = context.WithoutCancel(ctx)
+	ps.inflight.Add(1)
+	go func() {
+		defer ps.inflight.Done()
+		logger := utils.LoggerFromContext(ctx)
+		ctx, span := tracing.Start(ctx, "push.notify",
+			tracing.AttrRoomID.String(n.RoomID),
+			attribute.String("push.type", n.Type),
+		)
+		defer span.End()
+		db := ps.db.WithContext(ctx)
+
+		var tokens []models.DeviceToken
+		if err := db.Where("uid IN ?", uids).Find(&tokens).Error; err != nil {
+			logger.Error("查询设备推送 Token 失败",
+				zap.String("room_id", n.RoomID),
+				zap.Error(err),
+			)
+			return
+		}
+
+		for _, t := range tokens {
+			provider, ok := ps.providers[t.Provider]
+			if !ok {
+				continue
+			}
+			sendCtx, cancel := context.WithTimeout(ctx, time.Duration(ps.config.PushTimeout)*time.Second)
+			err := provider.Send(sendCtx, t.Token, n)
+			cancel()
+			if err == nil {
+				logger.Info("推送成功",
+					zap.String("room_id", n.RoomID),
+					zap.String("uid", t.UID),
+					zap.String("device_type", t.DeviceType),
+					zap.String("provider", t.Provider),
+					zap.String("type", n.Type),
+				)
+				continue
+			}
+			if stderrors.Is(err, push.ErrInvalidToken) {
+				// Token 已失效，删除避免后续重复推送
+				logger.Info("设备推送 Token 已失效，删除",
+					zap.String("uid", t.UID),
+					zap.String("device_type", t.DeviceType),
+					zap.String("provider", t.Provider),
+				)
+				db.Where("id = ? AND token = ?", t.ID, t.Token).Delete(&models.DeviceToken{})
+				continue
+			}
+			logger.Error("推送失败",
+				zap.String("room_id", n.RoomID),
+				zap.String("uid", t.UID),
+				zap.String("device_type", t.DeviceType),
+				zap.String("provider", t.Provider),
+				zap.String("type", n.Type),
+				zap.Error(err),
+			)
+		}
+	}()
+}
+
+// Drain 等待所有正在进行的推送完成（优雅关闭时调用）
+// ctx 超时后返回错误，未完成的推送随进程退出丢失
+func (ps *PushService) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		ps.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
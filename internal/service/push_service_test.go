@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tgo-rtc-server/internal/config"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/push"
+	"tgo-rtc-server/internal/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// stubProvider 通过本地 HTTP 替身发送推送的通道，设备 Token 以 "gone-" 开头时替身返回 410
+type stubProvider struct {
+	client *http.Client
+	url    string
+}
+
+func (p *stubProvider) Name() string { return push.ProviderFCM }
+
+func (p *stubProvider) Send(ctx context.Context, token string, n *push.Notification) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url+"/"+token, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusGone {
+		return push.ErrInvalidToken
+	}
+	return nil
+}
+
+// newTestPushService 创建使用 SQLite 内存库和本地 HTTP 替身的推送服务
+func newTestPushService(t *testing.T, handler http.HandlerFunc) *PushService {
+	t.Helper()
+	utils.Logger = zap.NewNop()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("打开 SQLite 失败: %v", err)
+	}
+	// 内存库每个连接独立，限制为单连接保证后台推送读到同一个库
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.DeviceToken{}); err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	providers := map[string]push.Provider{
+		push.ProviderFCM: &stubProvider{client: srv.Client(), url: srv.URL},
+	}
+	return NewPushService(db, &config.Config{PushTimeout: 5, LiveKitTimeout: 30}, providers)
+}
+
+func TestPushServiceDeletesInvalidToken(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+	ps := newTestPushService(t, func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.URL.Path, "/")
+		mu.Lock()
+		sent = append(sent, token)
+		mu.Unlock()
+		if strings.HasPrefix(token, "gone-") {
+			w.WriteHeader(http.StatusGone)
+		}
+	})
+	ctx := context.Background()
+
+	for _, req := range []*models.RegisterDeviceTokenRequest{
+		{UID: "bob", DeviceType: "android", Provider: push.ProviderFCM, Token: "gone-bob"},
+		{UID: "carol", DeviceType: "android", Provider: push.ProviderFCM, Token: "ok-carol"},
+	} {
+		if err := ps.RegisterDeviceToken(ctx, req); err != nil {
+			t.Fatalf("RegisterDeviceToken() error: %v", err)
+		}
+	}
+
+	ps.NotifyIncomingCall(ctx, &models.Room{RoomID: "room_1", Creator: "alice"}, []string{"bob", "carol"})
+	drainCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := ps.Drain(drainCtx); err != nil {
+		t.Fatalf("Drain() error: %v", err)
+	}
+
+	mu.Lock()
+	if len(sent) != 2 {
+		t.Errorf("sent = %v, want both devices", sent)
+	}
+	mu.Unlock()
+
+	var tokens []string
+	if err := ps.db.Model(&models.DeviceToken{}).Order("uid").Pluck("token", &tokens).Error; err != nil {
+		t.Fatalf("查询设备 Token 失败: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0] != "ok-carol" {
+		t.Fatalf("tokens = %v, want only ok-carol after 410", tokens)
+	}
+}
+
+func TestPushServiceDrainTimeout(t *testing.T) {
+	release := make(chan struct{})
+	ps := newTestPushService(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+	defer close(release)
+	ctx := context.Background()
+
+	if err := ps.RegisterDeviceToken(ctx, &models.RegisterDeviceTokenRequest{
+		UID: "bob", DeviceType: "android", Provider: push.ProviderFCM, Token: "slow-bob",
+	}); err != nil {
+		t.Fatalf("RegisterDeviceToken() error: %v", err)
+	}
+
+	ps.NotifyCallEnded(ctx, &models.Room{RoomID: "room_1", Creator: "alice"}, []string{"bob"}, pushReasonCancelled)
+	drainCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if err := ps.Drain(drainCtx); err != context.DeadlineExceeded {
+		t.Fatalf("Drain() error = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+
+	"tgo-rtc-server/internal/errors"
+	"tgo-rtc-server/internal/i18n"
+	"tgo-rtc-server/internal/ratelimit"
+	"tgo-rtc-server/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// checkRateLimit 检查单个用户维度的频率限制，超限时返回 429 业务错误
+// Redis 异常时放行，避免限流组件故障导致无法呼叫
+func checkRateLimit(ctx context.Context, limiter *ratelimit.Limiter, action, uid string) error {
+	result, err := limiter.Allow(ctx, action, ratelimit.ScopeUID, uid)
+	if err != nil {
+		utils.LoggerFromContext(ctx).Warn("频率限制检查失败，放行请求",
+			zap.String("action", action),
+			zap.String("uid", uid),
+			zap.Error(err),
+		)
+		return nil
+	}
+	if !result.Allowed {
+		utils.LoggerFromContext(ctx).Warn("用户触发频率限制",
+			zap.String("action", action),
+			zap.String("uid", uid),
+			zap.Int64("count", result.Count),
+		)
+		return errors.NewRateLimitError(result.RetryAfterSeconds(), i18n.RateLimitExceeded)
+	}
+	return nil
+}
+
+// checkRingLimit 检查被叫的来电频率，防止同一用户短时间内被反复呼叫骚扰
+// 任一被叫超限时拒绝整个呼叫/邀请
+func checkRingLimit(ctx context.Context, limiter *ratelimit.Limiter, callees []string) error {
+	for _, callee := range callees {
+		result, err := limiter.Allow(ctx, ratelimit.ActionRing, ratelimit.ScopeCallee, callee)
+		if err != nil {
+			utils.LoggerFromContext(ctx).Warn("被叫来电频率检查失败，放行请求",
+				zap.String("callee", callee),
+				zap.Error(err),
+			)
+			continue
+		}
+		if !result.Allowed {
+			utils.LoggerFromContext(ctx).Warn("被叫来电过于频繁",
+				zap.String("callee", callee),
+				zap.Int64("count", result.Count),
+			)
+			return errors.NewRateLimitError(result.RetryAfterSeconds(), i18n.CalleeRingLimitExceeded, callee)
+		}
+	}
+	return nil
+}
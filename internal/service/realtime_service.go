@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"tgo-rtc-server/internal/config"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// realtimeEventChannel 跨实例广播客户端事件的 Redis 频道
+	realtimeEventChannel = "rtc:events:stream"
+	// realtimeSubscriberBuffer 每个订阅连接的事件缓冲区大小，客户端消费过慢时丢弃新事件
+	realtimeSubscriberBuffer = 32
+	// eventStreamTokenIssuer 事件流 Token 签发者
+	eventStreamTokenIssuer = "tgo-rtc-server"
+)
+
+// StreamEvent 推送给客户端的事件
+type StreamEvent struct {
+	ID   string // 事件 ID
+	Type string // 事件类型
+	Data []byte // 事件 JSON（与业务 webhook 事件结构一致）
+}
+
+// EventSubscriber 单个客户端连接的事件订阅
+type EventSubscriber struct {
+	UID    string
+	Events chan *StreamEvent
+}
+
+// realtimeMessage Redis 频道中传递的消息
+type realtimeMessage struct {
+	UIDs  []string        `json:"uids"`  // 接收事件的用户
+	ID    string          `json:"id"`    // 事件 ID
+	Type  string          `json:"type"`  // 事件类型
+	Event json.RawMessage `json:"event"` // 事件 JSON
+}
+
+// RealtimeService 客户端实时事件推送服务
+// 事件来源与业务 webhook 相同，通过 Redis pub/sub 广播到所有实例，由持有该用户连接的实例推送
+type RealtimeService struct {
+	redisClient *redis.Client
+	config      *config.Config
+	secret      []byte
+
+	mu          sync.RWMutex
+	subscribers map[string]map[*EventSubscriber]struct{} // uid -> 该用户在本实例上的所有连接
+
+	pubsub *redis.PubSub
+
+	stopOnce sync.Once
+	done     chan struct{} // 服务停止时关闭，通知所有事件流连接断开
+}
+
+// NewRealtimeService 创建客户端实时事件推送服务
+func NewRealtimeService(redisClient *redis.Client, cfg *config.Config) *RealtimeService {
+	return &RealtimeService{
+		redisClient: redisClient,
+		config:      cfg,
+		secret:      []byte(cfg.EventStreamSecret),
+		subscribers: make(map[string]map[*EventSubscriber]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Enabled 是否启用客户端实时事件推送
+func (rts *RealtimeService) Enabled() bool {
+	return rts.config.EventStreamEnabled
+}
+
+// Start 订阅 Redis 频道，接收所有实例发布的事件
+func (rts *RealtimeService) Start() {
+	logger := utils.GetLogger()
+	if !rts.Enabled() {
+		logger.Info("客户端实时事件推送已禁用")
+		return
+	}
+	if rts.config.EventStreamIssuerKey == "" {
+		logger.Warn("未配置 EVENT_STREAM_ISSUER_KEY，签发事件流 Token 接口将拒绝所有请求")
+	}
+
+	rts.pubsub = rts.redisClient.Subscribe(context.Background(), realtimeEventChannel)
+	ch := rts.pubsub.Channel()
+	go func() {
+		for msg := range ch {
+			var rm realtimeMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &rm); err != nil {
+				logger.Warn("解析实时事件消息失败", zap.Error(err))
+				continue
+			}
+			rts.dispatch(&rm)
+		}
+	}()
+
+	logger.Info("客户端实时事件推送已启动",
+		zap.String("channel", realtimeEventChannel),
+	)
+}
+
+// Stop 取消 Redis 频道订阅，并通知所有事件流连接断开（客户端重连到其他实例）
+func (rts *RealtimeService) Stop() {
+	rts.stopOnce.Do(func() {
+		close(rts.done)
+		if rts.pubsub != nil {
+			_ = rts.pubsub.Close()
+			utils.GetLogger().Info("客户端实时事件推送已停止")
+		}
+	})
+}
+
+// Done 服务停止时关闭的通道
+func (rts *RealtimeService) Done() <-chan struct{} {
+	return rts.done
+}
+
+// Publish 发布事件，推送给事件涉及的用户
+func (rts *RealtimeService) Publish(ctx context.Context, event *models.BusinessWebhookEvent) {
+	if !rts.Enabled() {
+		return
+	}
+	logger := utils.LoggerFromContext(ctx)
+
+	uids := eventRecipients(event.Data)
+	if len(uids) == 0 {
+		return
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("序列化实时事件失败",
+			zap.String("event_type", event.EventType),
+			zap.Error(err),
+		)
+		return
+	}
+	rm := &realtimeMessage{
+		UIDs:  uids,
+		ID:    event.EventID,
+		Type:  event.EventType,
+		Event: eventJSON,
+	}
+	payload, err := json.Marshal(rm)
+	if err != nil {
+		return
+	}
+
+	publishCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	if err := rts.redisClient.Publish(publishCtx, realtimeEventChannel, payload).Err(); err != nil {
+		// Redis 不可用时至少推送给本实例上的连接
+		logger.Warn("发布实时事件到 Redis 失败，仅推送本实例连接",
+			zap.String("event_type", event.EventType),
+			zap.Error(err),
+		)
+		rts.dispatch(rm)
+	}
+}
+
+// Subscribe 为用户注册一个事件订阅连接
+func (rts *RealtimeService) Subscribe(uid string) *EventSubscriber {
+	sub := &EventSubscriber{
+		UID:    uid,
+		Events: make(chan *StreamEvent, realtimeSubscriberBuffer),
+	}
+	rts.mu.Lock()
+	defer rts.mu.Unlock()
+	if rts.subscribers[uid] == nil {
+		rts.subscribers[uid] = make(map[*EventSubscriber]struct{})
+	}
+	rts.subscribers[uid][sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe 注销事件订阅连接
+func (rts *RealtimeService) Unsubscribe(sub *EventSubscriber) {
+	rts.mu.Lock()
+	defer rts.mu.Unlock()
+	if subs, ok := rts.subscribers[sub.UID]; ok {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(rts.subscribers, sub.UID)
+		}
+	}
+}
+
+// dispatch 将事件推送给本实例上对应用户的所有连接
+func (rts *RealtimeService) dispatch(rm *realtimeMessage) {
+	event := &StreamEvent{ID: rm.ID, Type: rm.Type, Data: rm.Event}
+
+	rts.mu.RLock()
+	defer rts.mu.RUnlock()
+	for _, uid := range rm.UIDs {
+		for sub := range rts.subscribers[uid] {
+			select {
+			case sub.Events <- event:
+			default:
+				utils.GetLogger().Warn("客户端事件缓冲区已满，丢弃事件",
+					zap.String("uid", uid),
+					zap.String("event_type", rm.Type),
+					zap.String("event_id", rm.ID),
+				)
+			}
+		}
+	}
+}
+
+// IssueToken 为用户签发事件流 Token（HS256 JWT，sub 为 uid）
+// ttl 小于等于 0 时使用默认有效期
+func (rts *RealtimeService) IssueToken(uid string, ttl int) (string, int64, error) {
+	if len(rts.secret) == 0 {
+		return "", 0, fmt.Errorf("事件流签名密钥未配置")
+	}
+	if ttl <= 0 {
+		ttl = rts.config.EventStreamTokenTTL
+	}
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(ttl) * time.Second)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    eventStreamTokenIssuer,
+		Subject:   uid,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	})
+	signed, err := token.SignedString(rts.secret)
+	if err != nil {
+		return "", 0, err
+	}
+	return signed, expiresAt.Unix(), nil
+}
+
+// VerifyToken 校验事件流 Token，返回 uid
+func (rts *RealtimeService) VerifyToken(tokenString string) (string, error) {
+	if len(rts.secret) == 0 {
+		return "", fmt.Errorf("事件流签名密钥未配置")
+	}
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return rts.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(eventStreamTokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return "", err
+	}
+	if claims.Subject == "" {
+		return "", fmt.Errorf("token 缺少 uid")
+	}
+	return claims.Subject, nil
+}
+
+// eventRecipients 计算事件的接收用户：房间参与者以及事件中涉及的用户
+func eventRecipients(data interface{}) []string {
+	var lists [][]string
+	switch d := data.(type) {
+	case *models.ParticipantEventData:
+		lists = append(lists, d.Uids, []string{d.UID}, d.InvitedUIDs, d.MissedUIDs, d.LobbyUIDs)
+	case *models.RoomHostChangedEventData:
+		lists = append(lists, d.Uids, []string{d.PreviousHost, d.Host})
+	case *models.RoomEventData:
+		lists = append(lists, d.Uids)
+	}
+
+	seen := make(map[string]struct{})
+	uids := make([]string, 0)
+	for _, list := range lists {
+		for _, uid := range list {
+			if uid == "" {
+				continue
+			}
+			if _, ok := seen[uid]; ok {
+				continue
+			}
+			seen[uid] = struct{}{}
+			uids = append(uids, uid)
+		}
+	}
+	return uids
+}
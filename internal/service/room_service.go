@@ -1,31 +1,46 @@
 package service
 
 import (
+	"context"
 	"strings"
 	"tgo-rtc-server/internal/errors"
 	"tgo-rtc-server/internal/i18n"
 	"tgo-rtc-server/internal/livekit"
+	"tgo-rtc-server/internal/metrics"
 	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/ratelimit"
+	"tgo-rtc-server/internal/tracing"
 	"tgo-rtc-server/internal/utils"
 	"time"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // RoomService 房间服务
 type RoomService struct {
 	db                      *gorm.DB
+	redisClient             *redis.Client
 	tokenGenerator          *livekit.TokenGenerator
 	timeFormatter           *utils.TimeFormatter
 	participantDeduplicator *utils.ParticipantDeduplicator
 	schedulerService        *SchedulerService
+	participantService      *ParticipantService
+	pushService             *PushService
+	rateLimiter             *ratelimit.Limiter
+	callPolicyService       *CallPolicyService
+	presenceService         *PresenceService
+	activeCallIndex         *ActiveCallIndex
+	idempotencyService      *IdempotencyService
 }
 
 // NewRoomService 创建房间服务
-func NewRoomService(db *gorm.DB, tokenGenerator *livekit.TokenGenerator) *RoomService {
+func NewRoomService(db *gorm.DB, redisClient *redis.Client, tokenGenerator *livekit.TokenGenerator) *RoomService {
 	return &RoomService{
 		db:                      db,
+		redisClient:             redisClient,
 		tokenGenerator:          tokenGenerator,
 		timeFormatter:           utils.NewTimeFormatter(),
 		participantDeduplicator: utils.NewParticipantDeduplicator(),
@@ -37,53 +52,170 @@ func (rs *RoomService) SetSchedulerService(ss *SchedulerService) {
 	rs.schedulerService = ss
 }
 
+// SetActiveCallIndex 设置通话索引
+func (rs *RoomService) SetActiveCallIndex(aci *ActiveCallIndex) {
+	rs.activeCallIndex = aci
+}
+
+// SetIdempotencyService 设置幂等请求服务
+func (rs *RoomService) SetIdempotencyService(is *IdempotencyService) {
+	rs.idempotencyService = is
+}
+
+// SetParticipantService 设置参与者服务（频道已有进行中的房间时用于加入该房间）
+func (rs *RoomService) SetParticipantService(ps *ParticipantService) {
+	rs.participantService = ps
+}
+
+// SetPushService 设置移动端来电推送服务
+func (rs *RoomService) SetPushService(ps *PushService) {
+	rs.pushService = ps
+}
+
+// SetRateLimiter 设置频率限制器
+func (rs *RoomService) SetRateLimiter(l *ratelimit.Limiter) {
+	rs.rateLimiter = l
+}
+
+// SetCallPolicyService 设置呼叫策略服务（屏蔽列表/业务呼叫策略）
+func (rs *RoomService) SetCallPolicyService(cps *CallPolicyService) {
+	rs.callPolicyService = cps
+}
+
+// SetPresenceService 设置用户可用状态服务（免打扰/离线）
+func (rs *RoomService) SetPresenceService(ps *PresenceService) {
+	rs.presenceService = ps
+}
+
 // CreateRoom 创建房间
-func (rs *RoomService) CreateRoom(req *models.CreateRoomRequest) (*models.CreateRoomResponse, error) {
+// 携带 Idempotency-Key 的重试请求返回首次创建的房间（房间仍在进行中时重新生成 Token）
+func (rs *RoomService) CreateRoom(ctx context.Context, req *models.CreateRoomRequest) (*models.CreateRoomResponse, error) {
+	var replayResp models.CreateRoomResponse
+	replayed, finish, err := rs.idempotencyService.Begin(ctx, ratelimit.ActionCreate, req.Creator, req.IdempotencyKey, req, &replayResp)
+	if err != nil {
+		return nil, err
+	}
+	if replayed {
+		return refreshReplayedToken(ctx, rs.db, rs.tokenGenerator, &replayResp, req.Creator, req.DeviceType), nil
+	}
+	resp, err := rs.createRoom(ctx, req)
+	finish(resp, err)
+	return resp, err
+}
+
+// createRoom 创建房间
+func (rs *RoomService) createRoom(ctx context.Context, req *models.CreateRoomRequest) (*models.CreateRoomResponse, error) {
+	db := rs.db.WithContext(ctx)
+	tracing.SetRoom(ctx, req.RoomID, req.Creator)
 	// 0. 兼容 UIDs 未传递的情况，初始化为空切片
 	if req.UIDs == nil {
 		req.UIDs = []string{}
 	}
+	if err := checkRateLimit(ctx, rs.rateLimiter, ratelimit.ActionCreate, req.Creator); err != nil {
+		return nil, err
+	}
+
+	// 绑定频道的房间：同一频道同时只允许一个进行中的通话
+	// 加锁后再检查，避免两个成员同时发起呼叫时创建出两个并行的房间
+	if req.ChannelID != "" {
+		unlock, err := rs.lockChannel(ctx, req.ChannelID)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+
+		activeRoom, err := findActiveChannelRoom(db, req.ChannelID)
+		if err != nil {
+			return nil, errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, err.Error())
+		}
+		if activeRoom != nil {
+			return rs.joinChannelRoom(ctx, activeRoom, req)
+		}
+	}
 
 	// 1. 如果 room_id 没有传递，则生成 UUID（去掉 '-'）
 	roomID := req.RoomID
+	persistent := req.Persistent == models.RoomPersistent
+	// restartRoom 不为空表示重新开始一个已结束的持久房间
+	var restartRoom *models.Room
 	if roomID == "" {
 		roomID = strings.ReplaceAll(uuid.New().String(), "-", "")
+		tracing.SetRoom(ctx, roomID, "")
 	} else {
 		// 2. 如果 room_id 已传递，检查是否已存在
 		var existingRoom models.Room
-		if err := rs.db.Where("room_id = ?", roomID).First(&existingRoom).Error; err == nil {
-			return nil, errors.NewBusinessErrorWithKey(i18n.RoomAlreadyExists, roomID)
+		if err := db.Where("room_id = ?", roomID).First(&existingRoom).Error; err == nil {
+			// 一次性房间不允许重复使用 room_id
+			if existingRoom.Persistent != models.RoomPersistent {
+				return nil, errors.NewBusinessErrorWithKey(i18n.RoomAlreadyExists, roomID)
+			}
+			// 持久房间仍在通话中，不能重新开始
+			if existingRoom.Status == models.RoomStatusNotStarted || existingRoom.Status == models.RoomStatusInProgress {
+				return nil, errors.NewConflictError(i18n.RoomSessionActive, roomID)
+			}
+			restartRoom = &existingRoom
+			persistent = true
 		} else if err != gorm.ErrRecordNotFound {
 			return nil, errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, err.Error())
 		}
 	}
 
+	// 锁定主叫和被叫，忙线检查到房间写入完成期间其他涉及这些用户的建房请求需等待
+	unlockUIDs, err := rs.lockCallUIDs(ctx, append([]string{req.Creator}, req.UIDs...))
+	if err != nil {
+		return nil, err
+	}
+	defer unlockUIDs()
+
+	// 对呼：被叫刚刚呼叫了发起者，发起者直接加入对方的房间，避免两边都因对方忙线而失败
+	if req.ChannelID == "" && restartRoom == nil && rs.participantService != nil {
+		callees := rs.participantDeduplicator.RemoveDuplicateUIDs(rs.participantDeduplicator.DeduplicateUIDs(req.UIDs), req.Creator)
+		glareRoom, err := rs.findGlareRoom(ctx, req.Creator, callees)
+		if err != nil {
+			return nil, errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, err.Error())
+		}
+		if glareRoom != nil {
+			return rs.joinGlareRoom(ctx, glareRoom, req)
+		}
+	}
+
 	// 3. 检查 creator 是否在 rtc_participant 表存在 status=0/1 的情况
-	var participant models.Participant
-	if err := rs.db.Where("uid = ? AND (status = ? OR status = ?)",
-		req.Creator, models.ParticipantStatusInviting, models.ParticipantStatusJoined).
-		First(&participant).Error; err == nil {
-		return nil, errors.NewBusinessErrorWithKey(i18n.CreatorInAnotherCall)
-	} else if err != gorm.ErrRecordNotFound {
+	if busyUID, err := rs.activeCallIndex.FirstBusyUID(ctx, db, []string{req.Creator}); err != nil {
 		return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
+	} else if busyUID != "" {
+		return nil, errors.NewBusinessErrorWithKey(i18n.CreatorInAnotherCall)
 	}
 
 	// 5. 对 UIDs 进行去重，并移除创建者（避免重复添加）
 	deduplicatedUIDs := rs.participantDeduplicator.DeduplicateUIDs(req.UIDs)
 	deduplicatedUIDs = rs.participantDeduplicator.RemoveDuplicateUIDs(deduplicatedUIDs, req.Creator)
+	// 按呼叫策略过滤被叫：被拦截的被叫只记录参与者（状态为已拦截），不振铃、不推送
+	deduplicatedUIDs, blockedUIDs := rs.callPolicyService.FilterCallees(ctx, &models.Room{
+		RoomID:  roomID,
+		RTCType: req.RTCType,
+	}, req.Creator, deduplicatedUIDs)
+	// 按被叫可用状态分组：免打扰的静默邀请，离线（或免打扰拒接）的不邀请
+	hasCallees := len(deduplicatedUIDs) > 0
+	ringUIDs, silentUIDs, unavailableUIDs := rs.presenceService.ClassifyCallees(ctx, deduplicatedUIDs)
+	deduplicatedUIDs = append(ringUIDs, silentUIDs...)
+	if hasCallees && len(deduplicatedUIDs) == 0 {
+		return nil, errors.NewConflictError(i18n.CalleeUnavailable, unavailableUIDs[0])
+	}
+	if err := checkRingLimit(ctx, rs.rateLimiter, deduplicatedUIDs); err != nil {
+		return nil, err
+	}
 	isBusy := false
 	var busyParticipantUID string
 	// 6. 检查 UIDs 中的用户是否在通话中
 	if len(deduplicatedUIDs) > 0 {
-		var busyParticipant models.Participant
-		if err := rs.db.Where("uid IN ? AND (status = ? OR status = ?)",
-			deduplicatedUIDs, models.ParticipantStatusInviting, models.ParticipantStatusJoined).
-			First(&busyParticipant).Error; err == nil {
+		busyUID, err := rs.activeCallIndex.FirstBusyUID(ctx, db, deduplicatedUIDs)
+		if err != nil {
+			return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
+		}
+		if busyUID != "" {
 			isBusy = true
-			busyParticipantUID = busyParticipant.UID
+			busyParticipantUID = busyUID
 			//return nil, errors.NewConflictError(i18n.ParticipantInCall, busyParticipant.UID)
-		} else if err != gorm.ErrRecordNotFound {
-			return nil, errors.NewBusinessErrorWithKey(i18n.ParticipantQueryFailed, err.Error())
 		}
 	}
 
@@ -98,22 +230,91 @@ func (rs *RoomService) CreateRoom(req *models.CreateRoomRequest) (*models.Create
 		roomStatus = models.RoomStatusBusy
 		participantStatus = models.ParticipantStatusBusy
 	}
+	// 持久房间每次开始都生成新的会话 ID
+	sessionID := ""
+	if persistent {
+		sessionID = generateSessionID()
+	}
 	// 使用事务确保数据一致性
-	err := rs.db.Transaction(func(tx *gorm.DB) error {
-		// 创建房间
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// 绑定频道的房间：锁定频道行后再次检查，Redis 建房锁失效时也不会创建出两个并行的房间
+		if req.ChannelID != "" {
+			if err := lockChannelRow(tx, req.ChannelID); err != nil {
+				return errors.NewBusinessErrorWithKey(i18n.RoomCreationFailed, err.Error())
+			}
+			activeRoom, err := findActiveChannelRoom(tx, req.ChannelID)
+			if err != nil {
+				return errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, err.Error())
+			}
+			if activeRoom != nil {
+				return errors.NewConflictError(i18n.ChannelHasActiveRoom)
+			}
+		}
+
 		room := models.Room{
-			Creator: req.Creator,
-			RoomID:            roomID,
-			RTCType:           req.RTCType,
-			InviteOn:          req.InviteOn,
-			Status:            uint8(roomStatus),
-			MaxParticipants:   maxParticipants,
+			Creator:         req.Creator,
+			Host:            req.Creator,
+			RoomID:          roomID,
+			RTCType:         req.RTCType,
+			InviteOn:        req.InviteOn,
+			Status:          uint8(roomStatus),
+			MaxParticipants: maxParticipants,
+			SessionID:       sessionID,
+			ChannelID:       req.ChannelID,
+		}
+		if persistent {
+			room.Persistent = models.RoomPersistent
 		}
 
-		if err := tx.Create(&room).Error; err != nil {
+		if restartRoom != nil {
+			// 锁定房间行后重新检查状态，同一房间并发重新开始时只有一个请求能归档上一个会话
+			var current models.Room
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ?", restartRoom.ID).
+				First(&current).Error; err != nil {
+				return errors.NewBusinessErrorWithKey(i18n.RoomQueryFailed, err.Error())
+			}
+			if current.Status == models.RoomStatusNotStarted || current.Status == models.RoomStatusInProgress ||
+				current.SessionID != restartRoom.SessionID {
+				return errors.NewConflictError(i18n.RoomSessionActive, roomID)
+			}
+			// 重新开始持久房间：归档上一个会话的参与者，并复用房间记录
+			if err := archiveRoomSessionParticipants(tx, restartRoom); err != nil {
+				return errors.NewBusinessErrorWithKey(i18n.RoomCreationFailed, err.Error())
+			}
+			if err := tx.Model(&models.Room{}).
+				Where("id = ? AND session_id = ?", restartRoom.ID, restartRoom.SessionID).
+				Updates(map[string]interface{}{
+					"creator":          room.Creator,
+					"host":             room.Host,
+					"rtc_type":         room.RTCType,
+					"invite_on":        room.InviteOn,
+					"status":           room.Status,
+					"max_participants": room.MaxParticipants,
+					"session_id":       room.SessionID,
+					"channel_id":       room.ChannelID,
+				}).Error; err != nil {
+				return errors.NewBusinessErrorWithKey(i18n.RoomCreationFailed, err.Error())
+			}
+		} else if err := tx.Create(&room).Error; err != nil {
+			// 创建房间
 			return errors.NewBusinessErrorWithKey(i18n.RoomCreationFailed, err.Error())
 		}
 
+		// 记录持久房间的新会话
+		if persistent {
+			session := models.RoomSession{
+				SessionID: sessionID,
+				RoomID:    roomID,
+				Creator:   req.Creator,
+				RTCType:   req.RTCType,
+				Status:    uint8(roomStatus),
+			}
+			if err := tx.Create(&session).Error; err != nil {
+				return errors.NewBusinessErrorWithKey(i18n.RoomCreationFailed, err.Error())
+			}
+		}
+
 		// 合并创建者和去重后的邀请用户到一个数组中
 		participants := make([]models.Participant, 0)
 
@@ -135,6 +336,20 @@ func (rs *RoomService) CreateRoom(req *models.CreateRoomRequest) (*models.Create
 				})
 			}
 		}
+		for _, uid := range unavailableUIDs {
+			participants = append(participants, models.Participant{
+				RoomID: roomID,
+				UID:    uid,
+				Status: models.ParticipantStatusUnavailable,
+			})
+		}
+		for _, uid := range blockedUIDs {
+			participants = append(participants, models.Participant{
+				RoomID: roomID,
+				UID:    uid,
+				Status: models.ParticipantStatusBlocked,
+			})
+		}
 
 		// 批量创建参与者记录
 		if err := tx.Create(&participants).Error; err != nil {
@@ -147,11 +362,24 @@ func (rs *RoomService) CreateRoom(req *models.CreateRoomRequest) (*models.Create
 	if err != nil {
 		return nil, err
 	}
+	rs.activeCallIndex.SyncRoom(ctx, roomID)
+	metrics.RoomsCreated.WithLabelValues(metrics.RTCTypeLabel(req.RTCType)).Inc()
 	// 如果正在通话中直接返回错误，不能返回房间信息
 	if isBusy {
+		// 忙线的房间创建即结束，不会发送房间完成事件，在此统计最终状态
+		metrics.RoomsFinished.WithLabelValues(metrics.RoomStatusLabel(models.RoomStatusBusy)).Inc()
 		return nil, errors.NewConflictError(i18n.ParticipantInCall, busyParticipantUID)
 	}
 
+	// 向被邀请者推送来电（免打扰的被叫静默邀请，不推送）
+	if rs.pushService != nil && len(ringUIDs) > 0 {
+		rs.pushService.NotifyIncomingCall(ctx, &models.Room{
+			RoomID:  roomID,
+			Creator: req.Creator,
+			RTCType: req.RTCType,
+		}, ringUIDs)
+	}
+
 	// 为所有参与者设置超时定时器
 	if rs.schedulerService != nil {
 		// 为创建者设置定时器
@@ -163,7 +391,10 @@ func (rs *RoomService) CreateRoom(req *models.CreateRoomRequest) (*models.Create
 	}
 
 	// 生成 Token 和获取配置信息
-	tokenResult, err := rs.tokenGenerator.GenerateTokenWithConfig(roomID, req.Creator, req.DeviceType)
+	tokenResult, err := rs.tokenGenerator.GenerateTokenWithMetadata(roomID, req.Creator, livekit.ParticipantMetadata{
+		DeviceType: req.DeviceType,
+		Role:       livekit.RoleHost,
+	})
 	if err != nil {
 		return nil, errors.NewBusinessErrorWithKey(i18n.TokenGenerationFailed, err.Error())
 	}
@@ -171,15 +402,20 @@ func (rs *RoomService) CreateRoom(req *models.CreateRoomRequest) (*models.Create
 	uids := append(req.UIDs, req.Creator)
 	uids = rs.participantDeduplicator.DeduplicateUIDs(uids)
 	return &models.CreateRoomResponse{
-		RoomID: roomID,
-		Creator:           req.Creator,
-		Token:             tokenResult.Token,
-		URL:               tokenResult.URL,
-		Status:            models.RoomStatusNotStarted,
-		CreatedAt:         rs.timeFormatter.FormatDateTime(time.Now()),
-		MaxParticipants:   maxParticipants,
-		Timeout:           tokenResult.Timeout,
-		RTCType:           req.RTCType,
-		UIDs:              uids,
+		RoomID:          roomID,
+		Creator:         req.Creator,
+		Host:            req.Creator,
+		Token:           tokenResult.Token,
+		URL:             tokenResult.URL,
+		Status:          models.RoomStatusNotStarted,
+		CreatedAt:       rs.timeFormatter.FormatDateTime(time.Now()),
+		MaxParticipants: maxParticipants,
+		Timeout:         tokenResult.Timeout,
+		RTCType:         req.RTCType,
+		UIDs:            uids,
+		SessionID:       sessionID,
+		ChannelID:       req.ChannelID,
+		SilentUIDs:      silentUIDs,
+		UnavailableUIDs: unavailableUIDs,
 	}, nil
 }
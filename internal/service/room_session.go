@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/utils"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// generateSessionID 生成会话 ID（UUID 去掉 '-'）
+func generateSessionID() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// archiveRoomSessionParticipants 将持久房间上一个会话的参与者移入会话历史表
+// 移除后 rtc_participant 中只保留当前会话的参与者，按 room_id 查询的逻辑无需区分会话
+func archiveRoomSessionParticipants(tx *gorm.DB, room *models.Room) error {
+	var participants []models.Participant
+	if err := tx.Where("room_id = ?", room.RoomID).Find(&participants).Error; err != nil {
+		return err
+	}
+	if len(participants) == 0 {
+		return nil
+	}
+
+	if room.SessionID != "" {
+		history := make([]models.RoomSessionParticipant, 0, len(participants))
+		for _, p := range participants {
+			history = append(history, models.RoomSessionParticipant{
+				SessionID:  room.SessionID,
+				RoomID:     p.RoomID,
+				UID:        p.UID,
+				DeviceType: p.DeviceType,
+				Status:     p.Status,
+				JoinTime:   p.JoinTime,
+				LeaveTime:  p.LeaveTime,
+			})
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Where("room_id = ?", room.RoomID).Delete(&models.Participant{}).Error
+}
+
+// finishRoomSession 记录持久房间当前会话的最终状态和通话时长
+func (bws *BusinessWebhookService) finishRoomSession(ctx context.Context, room *models.Room, duration int64) {
+	db := bws.db.WithContext(ctx)
+	if room.SessionID == "" {
+		return
+	}
+	logger := utils.LoggerFromContext(ctx)
+	now := time.Now()
+	if err := db.Model(&models.RoomSession{}).
+		Where("session_id = ?", room.SessionID).
+		Updates(map[string]interface{}{
+			"status":      room.Status,
+			"duration":    duration,
+			"finished_at": now,
+		}).Error; err != nil {
+		logger.Error("更新房间会话状态失败",
+			zap.String("room_id", room.RoomID),
+			zap.String("session_id", room.SessionID),
+			zap.Error(err),
+		)
+	}
+}
+
+// isStaleSessionEvent 判断 LiveKit 事件是否属于持久房间的上一个会话
+// 持久房间重新开始后，上一个会话的 room_finished 等事件可能延迟到达，不能作用于当前会话
+func (ws *WebhookService) isStaleSessionEvent(ctx context.Context, room *models.Room, event *models.WebhookEvent) bool {
+	db := ws.db.WithContext(ctx)
+	if room.SessionID == "" || event.CreatedAt.Int64() <= 0 {
+		return false
+	}
+	var session models.RoomSession
+	if err := db.Where("session_id = ?", room.SessionID).First(&session).Error; err != nil {
+		return false
+	}
+	return event.CreatedAt.Int64() < session.CreatedAt.Unix()
+}
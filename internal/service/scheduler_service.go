@@ -1,11 +1,14 @@
 package service
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"tgo-rtc-server/internal/config"
+	"tgo-rtc-server/internal/metrics"
 	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/tracing"
 	"tgo-rtc-server/internal/utils"
 
 	"go.uber.org/zap"
@@ -21,10 +24,13 @@ type SchedulerService struct {
 	businessWebhookService  *BusinessWebhookService
 	participantService      *ParticipantService
 	participantDeduplicator *utils.ParticipantDeduplicator
+	activeCallIndex         *ActiveCallIndex
 
 	// 精确定时器相关
 	timersMu sync.RWMutex
 	timers   map[string]*time.Timer // key: "roomID:uid"
+	stopped  bool                   // 已停止，不再创建新的精确定时器
+	running  sync.WaitGroup         // 正在执行的精确定时器回调
 }
 
 // NewSchedulerService 创建定时器服务
@@ -43,6 +49,11 @@ func (ss *SchedulerService) SetBusinessWebhookService(bws *BusinessWebhookServic
 	ss.businessWebhookService = bws
 }
 
+// SetActiveCallIndex 设置通话索引（兜底轮询只检查索引中存在超时邀请的房间）
+func (ss *SchedulerService) SetActiveCallIndex(aci *ActiveCallIndex) {
+	ss.activeCallIndex = aci
+}
+
 // SetParticipantService 设置参与者服务
 func (ss *SchedulerService) SetParticipantService(ps *ParticipantService) {
 	ss.participantService = ps
@@ -75,22 +86,32 @@ func (ss *SchedulerService) Start() {
 }
 
 // Stop 停止定时器
+// 未触发的精确定时器直接取消：参与者仍处于邀请中状态，由其他实例（或重启后）的兜底轮询按创建时间判定超时
+// 已在执行的定时器回调会等待其完成，避免状态更新到一半被中断
 func (ss *SchedulerService) Stop() {
 	if ss.ticker != nil {
 		ss.ticker.Stop()
 	}
+	// 等待正在执行的兜底检查完成后退出
 	ss.done <- true
 
 	// 清理所有精确定时器
 	ss.timersMu.Lock()
+	ss.stopped = true
+	handedOff := len(ss.timers)
 	for key, timer := range ss.timers {
 		timer.Stop()
 		delete(ss.timers, key)
 	}
+	metrics.SchedulerActiveTimers.Set(0)
 	ss.timersMu.Unlock()
 
+	ss.running.Wait()
+
 	logger := utils.GetLogger()
-	logger.Info("参与者超时检查定时器已停止")
+	logger.Info("参与者超时检查定时器已停止",
+		zap.Int("handed_off_timers", handedOff),
+	)
 }
 
 // ScheduleParticipantTimeout 为参与者设置精确超时定时器
@@ -101,6 +122,11 @@ func (ss *SchedulerService) ScheduleParticipantTimeout(roomID, uid string) {
 	ss.timersMu.Lock()
 	defer ss.timersMu.Unlock()
 
+	// 已停止（正在关闭），交由兜底轮询处理
+	if ss.stopped {
+		return
+	}
+
 	// 如果已存在定时器，先取消
 	if existingTimer, exists := ss.timers[key]; exists {
 		existingTimer.Stop()
@@ -112,6 +138,7 @@ func (ss *SchedulerService) ScheduleParticipantTimeout(roomID, uid string) {
 		ss.checkSingleParticipantTimeout(roomID, uid)
 	})
 	ss.timers[key] = timer
+	metrics.SchedulerActiveTimers.Set(float64(len(ss.timers)))
 }
 
 // CancelParticipantTimeout 取消参与者的超时定时器
@@ -124,6 +151,7 @@ func (ss *SchedulerService) CancelParticipantTimeout(roomID, uid string) {
 	if timer, exists := ss.timers[key]; exists {
 		timer.Stop()
 		delete(ss.timers, key)
+		metrics.SchedulerActiveTimers.Set(float64(len(ss.timers)))
 	}
 }
 
@@ -134,19 +162,34 @@ func (ss *SchedulerService) checkSingleParticipantTimeout(roomID, uid string) {
 
 	// 从定时器 map 中移除
 	ss.timersMu.Lock()
+	if ss.stopped {
+		// 已停止（正在关闭），交由兜底轮询处理
+		ss.timersMu.Unlock()
+		return
+	}
 	delete(ss.timers, key)
+	metrics.SchedulerActiveTimers.Set(float64(len(ss.timers)))
+	ss.running.Add(1)
 	ss.timersMu.Unlock()
+	defer ss.running.Done()
+
+	ctx, span := tracing.Start(context.Background(), "scheduler.participant_timeout",
+		tracing.AttrRoomID.String(roomID),
+		tracing.AttrUID.String(uid),
+	)
+	defer span.End()
+	db := ss.db.WithContext(ctx)
 
 	// 查询参与者当前状态
 	var participant models.Participant
-	if err := ss.db.Where("room_id = ? AND uid = ? AND status = ?",
+	if err := db.Where("room_id = ? AND uid = ? AND status = ?",
 		roomID, uid, models.ParticipantStatusInviting).First(&participant).Error; err != nil {
 		// 参与者不存在或状态已改变，无需处理
 		return
 	}
 
 	// 更新参与者状态为超时（仅更新仍处于邀请中状态的参与者，避免覆盖已加入的参与者）
-	if err := ss.db.Model(&models.Participant{}).
+	if err := db.Model(&models.Participant{}).
 		Where("room_id = ? AND uid = ? AND status = ?", roomID, uid, models.ParticipantStatusInviting).
 		Update("status", models.ParticipantStatusMissed).Error; err != nil {
 		logger.Error("更新参与者状态为超时失败",
@@ -156,10 +199,11 @@ func (ss *SchedulerService) checkSingleParticipantTimeout(roomID, uid string) {
 		)
 		return
 	}
+	ss.activeCallIndex.SyncRoom(ctx, roomID)
 
 	// 查询房间信息
 	var room models.Room
-	if err := ss.db.Where("room_id = ?", roomID).First(&room).Error; err != nil {
+	if err := db.Where("room_id = ?", roomID).First(&room).Error; err != nil {
 		logger.Error("查询房间失败",
 			zap.String("room_id", roomID),
 			zap.Error(err),
@@ -171,7 +215,7 @@ func (ss *SchedulerService) checkSingleParticipantTimeout(roomID, uid string) {
 	// 将房间标记为超时，所有仍在邀请中的参与者也标记为超时
 	if room.MaxParticipants == 2 {
 		// 更新房间状态为超时未接听
-		if err := ss.db.Model(&models.Room{}).
+		if err := db.Model(&models.Room{}).
 			Where("room_id = ?", roomID).
 			Update("status", models.RoomStatusMissed).Error; err != nil {
 			logger.Error("更新房间状态为超时未接听失败",
@@ -182,7 +226,7 @@ func (ss *SchedulerService) checkSingleParticipantTimeout(roomID, uid string) {
 		}
 
 		// 将所有仍在邀请中的参与者标记为超时
-		if err := ss.db.Model(&models.Participant{}).
+		if err := db.Model(&models.Participant{}).
 			Where("room_id = ? AND status = ?", roomID, models.ParticipantStatusInviting).
 			Update("status", models.ParticipantStatusMissed).Error; err != nil {
 			logger.Error("批量更新参与者状态为超时失败",
@@ -192,7 +236,7 @@ func (ss *SchedulerService) checkSingleParticipantTimeout(roomID, uid string) {
 		}
 
 		// 将已加入的参与者（创建者）标记为挂断，通话已结束
-		if err := ss.db.Model(&models.Participant{}).
+		if err := db.Model(&models.Participant{}).
 			Where("room_id = ? AND status = ?", roomID, models.ParticipantStatusJoined).
 			Update("status", models.ParticipantStatusHangup).Error; err != nil {
 			logger.Error("更新已加入参与者状态为挂断失败",
@@ -200,27 +244,28 @@ func (ss *SchedulerService) checkSingleParticipantTimeout(roomID, uid string) {
 				zap.Error(err),
 			)
 		}
+		ss.activeCallIndex.SyncRoom(ctx, roomID)
 
 		// 收集所有参与者 UID 用于 webhook
 		var allUIDs []string
-		ss.db.Model(&models.Participant{}).
-			Where("room_id = ?", roomID).
+		db.Model(&models.Participant{}).
+			Where("room_id = ? AND status <> ?", roomID, models.ParticipantStatusBlocked).
 			Pluck("uid", &allUIDs)
 
 		// 重新查询房间（状态已更新为 Missed）
-		ss.db.Where("room_id = ?", roomID).First(&room)
+		db.Where("room_id = ?", roomID).First(&room)
 
 		// 发送 webhook 事件
 		if ss.businessWebhookService != nil {
-			ss.businessWebhookService.sendParticipantMissed(&room, allUIDs)
-			ss.businessWebhookService.checkAndFinishRoom(&room)
+			ss.businessWebhookService.sendParticipantMissed(ctx, &room, allUIDs)
+			ss.businessWebhookService.checkAndFinishRoom(ctx, &room)
 		}
 		return
 	}
 
 	// 多人通话场景：检查房间中是否还有已加入的参与者
 	var joinedCount int64
-	if err := ss.db.Model(&models.Participant{}).
+	if err := db.Model(&models.Participant{}).
 		Where("room_id = ? AND status = ?", roomID, models.ParticipantStatusJoined).
 		Count(&joinedCount).Error; err != nil {
 		logger.Error("查询已加入的参与者数量失败",
@@ -232,7 +277,7 @@ func (ss *SchedulerService) checkSingleParticipantTimeout(roomID, uid string) {
 
 	// 只有当房间中没有已加入的参与者时，才更新房间状态为超时未接听
 	if joinedCount == 0 {
-		if err := ss.db.Model(&models.Room{}).
+		if err := db.Model(&models.Room{}).
 			Where("room_id = ?", roomID).
 			Update("status", models.RoomStatusMissed).Error; err != nil {
 			logger.Error("更新房间状态为超时未接听失败",
@@ -245,10 +290,10 @@ func (ss *SchedulerService) checkSingleParticipantTimeout(roomID, uid string) {
 
 	// 发送参与者超时事件
 	if ss.businessWebhookService != nil {
-		ss.businessWebhookService.sendParticipantMissed(&room, []string{uid})
+		ss.businessWebhookService.sendParticipantMissed(ctx, &room, []string{uid})
 		// 只有房间状态变成 missed 时才检查是否需要发送房间完成事件
 		if joinedCount == 0 {
-			ss.businessWebhookService.checkAndFinishRoom(&room)
+			ss.businessWebhookService.checkAndFinishRoom(ctx, &room)
 		}
 	}
 }
@@ -256,9 +301,19 @@ func (ss *SchedulerService) checkSingleParticipantTimeout(roomID, uid string) {
 // checkParticipantTimeout 检查超时的参与者
 func (ss *SchedulerService) checkParticipantTimeout() {
 	// 获取所有状态为 0（邀请中）的参与者
+	// 通话索引可用时只查询最早邀请时间已超时的房间，避免每次扫描全部邀请中的参与者
 	logger := utils.GetLogger()
+	ss.activeCallIndex.EnsureReady(context.Background())
+	query := ss.db.Where("status = ?", models.ParticipantStatusInviting)
+	timeoutBefore := time.Now().Add(-time.Duration(ss.config.LiveKitTimeout) * time.Second)
+	if roomIDs, ok := ss.activeCallIndex.InvitingRoomIDs(context.Background(), timeoutBefore); ok {
+		if len(roomIDs) == 0 {
+			return
+		}
+		query = query.Where("room_id IN ?", roomIDs)
+	}
 	var participants []models.Participant
-	if err := ss.db.Where("status = ?", models.ParticipantStatusInviting).Find(&participants).Error; err != nil {
+	if err := query.Find(&participants).Error; err != nil {
 		logger.Error("查询邀请中的参与者失败",
 			zap.Error(err),
 		)
@@ -307,91 +362,102 @@ func (ss *SchedulerService) checkParticipantTimeout() {
 		for _, p := range participants {
 			uids = append(uids, p.UID)
 		}
-		// 更新参与者状态为超时（仅更新仍处于邀请中状态的参与者，避免覆盖已加入的参与者）
-		result := ss.db.Model(&models.Participant{}).
-			Where("room_id = ? AND uid IN ? AND status = ?", roomId, uids, models.ParticipantStatusInviting).
-			Update("status", models.ParticipantStatusMissed)
-		if result.Error != nil {
-			logger.Error("检查超时的参与者--->更新参与者状态为超时失败",
-				zap.String("room_id", roomId),
-				zap.Error(result.Error),
-			)
-			continue
-		}
-		if result.RowsAffected == 0 {
-			logger.Info("检查超时的参与者--->没有需要更新的参与者（可能已加入或状态已变更）",
-				zap.String("room_id", roomId),
-				zap.Strings("uids", uids),
-			)
-			continue
-		}
-		logger.Info("检查超时的参与者--->已更新参与者状态为超时",
+		ctx, span := tracing.Start(context.Background(), "scheduler.fallback_timeout", tracing.AttrRoomID.String(roomId))
+		ss.expireRoomParticipants(ctx, roomId, uids, rooms)
+		span.End()
+	}
+}
+
+// expireRoomParticipants 兜底轮询：将房间中超时的邀请中参与者标记为超时，并在房间无人通话时结束房间
+func (ss *SchedulerService) expireRoomParticipants(ctx context.Context, roomId string, uids []string, rooms []models.Room) {
+	logger := utils.LoggerFromContext(ctx)
+	db := ss.db.WithContext(ctx)
+	// 更新参与者状态为超时（仅更新仍处于邀请中状态的参与者，避免覆盖已加入的参与者）
+	result := db.Model(&models.Participant{}).
+		Where("room_id = ? AND uid IN ? AND status = ?", roomId, uids, models.ParticipantStatusInviting).
+		Update("status", models.ParticipantStatusMissed)
+	if result.Error != nil {
+		logger.Error("检查超时的参与者--->更新参与者状态为超时失败",
 			zap.String("room_id", roomId),
-			zap.Int64("affected_rows", result.RowsAffected),
-			zap.Int("expected_count", len(uids)),
+			zap.Error(result.Error),
 		)
+		return
+	}
+	if result.RowsAffected == 0 {
+		logger.Info("检查超时的参与者--->没有需要更新的参与者（可能已加入或状态已变更）",
+			zap.String("room_id", roomId),
+			zap.Strings("uids", uids),
+		)
+		return
+	}
+	metrics.SchedulerFallbackCatches.Add(float64(result.RowsAffected))
+	ss.activeCallIndex.SyncRoom(ctx, roomId)
+	logger.Info("检查超时的参与者--->已更新参与者状态为超时",
+		zap.String("room_id", roomId),
+		zap.Int64("affected_rows", result.RowsAffected),
+		zap.Int("expected_count", len(uids)),
+	)
 
-		// 重新查询房间中是否还有已加入（正在通话中）的参与者
-		var activeCount int64
-		if err := ss.db.Model(&models.Participant{}).
-			Where("room_id = ? AND status = ?", roomId, models.ParticipantStatusJoined).
-			Count(&activeCount).Error; err != nil {
-			logger.Error("检查超时的参与者--->查询活跃参与者数量失败",
-				zap.String("room_id", roomId),
-				zap.Error(err),
-			)
-			continue
-		}
+	// 重新查询房间中是否还有已加入（正在通话中）的参与者
+	var activeCount int64
+	if err := db.Model(&models.Participant{}).
+		Where("room_id = ? AND status = ?", roomId, models.ParticipantStatusJoined).
+		Count(&activeCount).Error; err != nil {
+		logger.Error("检查超时的参与者--->查询活跃参与者数量失败",
+			zap.String("room_id", roomId),
+			zap.Error(err),
+		)
+		return
+	}
 
-		var room models.Room
-		for _, r := range rooms {
-			if r.RoomID == roomId {
-				room = r
-				break
-			}
+	var room models.Room
+	for _, r := range rooms {
+		if r.RoomID == roomId {
+			room = r
+			break
 		}
+	}
 
-		// 获取实际被更新为超时的参与者 UIDs
-		var actualMissedParticipants []models.Participant
-		if err := ss.db.Where("room_id = ? AND uid IN ? AND status = ?", roomId, uids, models.ParticipantStatusMissed).
-			Find(&actualMissedParticipants).Error; err != nil {
-			logger.Error("检查超时的参与者--->查询实际超时参与者失败",
-				zap.String("room_id", roomId),
-				zap.Error(err),
-			)
-			continue
-		}
-		actualMissedUids := make([]string, 0, len(actualMissedParticipants))
-		for _, p := range actualMissedParticipants {
-			actualMissedUids = append(actualMissedUids, p.UID)
-		}
+	// 获取实际被更新为超时的参与者 UIDs
+	var actualMissedParticipants []models.Participant
+	if err := db.Where("room_id = ? AND uid IN ? AND status = ?", roomId, uids, models.ParticipantStatusMissed).
+		Find(&actualMissedParticipants).Error; err != nil {
+		logger.Error("检查超时的参与者--->查询实际超时参与者失败",
+			zap.String("room_id", roomId),
+			zap.Error(err),
+		)
+		return
+	}
+	actualMissedUids := make([]string, 0, len(actualMissedParticipants))
+	for _, p := range actualMissedParticipants {
+		actualMissedUids = append(actualMissedUids, p.UID)
+	}
 
-		if len(actualMissedUids) > 0 {
-			// 发送参与者超时事件
-			ss.businessWebhookService.sendParticipantMissed(&room, actualMissedUids)
-		}
+	if len(actualMissedUids) > 0 {
+		// 发送参与者超时事件
+		ss.businessWebhookService.sendParticipantMissed(ctx, &room, actualMissedUids)
+	}
 
-		if activeCount > 0 {
-			// 房间中还有人在通话，不更新房间状态，不发送房间完成事件
-			logger.Info("检查超时的参与者--->房间中仍有活跃参与者，跳过房间状态更新",
-				zap.String("room_id", roomId),
-				zap.Int64("active_count", activeCount),
-			)
-			continue
-		}
+	if activeCount > 0 {
+		// 房间中还有人在通话，不更新房间状态，不发送房间完成事件
+		logger.Info("检查超时的参与者--->房间中仍有活跃参与者，跳过房间状态更新",
+			zap.String("room_id", roomId),
+			zap.Int64("active_count", activeCount),
+		)
+		return
+	}
 
-		// 房间中没有活跃参与者了，更新房间状态为超时未接听
-		if err := ss.db.Model(&models.Room{}).
-			Where("room_id = ? AND status IN ?", roomId, []int{models.RoomStatusNotStarted, models.RoomStatusInProgress}).
-			Update("status", models.RoomStatusMissed).Error; err != nil {
-			logger.Error("检查超时的参与者--->更新房间状态为超时未接听失败",
-				zap.String("room_id", roomId),
-				zap.Error(err),
-			)
-			continue
-		}
-		room.Status = models.RoomStatusMissed
-		// 发送房间完成事件
-		ss.businessWebhookService.checkAndFinishRoom(&room)
+	// 房间中没有活跃参与者了，更新房间状态为超时未接听
+	if err := db.Model(&models.Room{}).
+		Where("room_id = ? AND status IN ?", roomId, []int{models.RoomStatusNotStarted, models.RoomStatusInProgress}).
+		Update("status", models.RoomStatusMissed).Error; err != nil {
+		logger.Error("检查超时的参与者--->更新房间状态为超时未接听失败",
+			zap.String("room_id", roomId),
+			zap.Error(err),
+		)
+		return
 	}
+	room.Status = models.RoomStatusMissed
+	// 发送房间完成事件
+	ss.businessWebhookService.checkAndFinishRoom(ctx, &room)
 }
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// webhookProcessingTTL 事件处理中标记的有效期，处理实例异常退出后 LiveKit 的重试可重新处理
+	webhookProcessingTTL = 30 * time.Second
+	// webhookDoneTTL 事件处理完成标记的有效期
+	webhookDoneTTL = time.Hour
+	// webhookDoneMarker 事件处理完成标记的值
+	webhookDoneMarker = "done"
+)
+
+// ErrWebhookEventInProgress 同一事件正由其他实例处理中，返回非 2xx 让 LiveKit 稍后重试
+var ErrWebhookEventInProgress = errors.New("webhook 事件正在处理中")
+
+// markWebhookDoneScript 仅当处理中标记仍由自己持有时改为完成标记
+// ARGV[1]: 处理中标记的值, ARGV[2]: 完成标记, ARGV[3]: 完成标记有效期（毫秒）
+var markWebhookDoneScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+end
+return 0
+`)
+
+// webhookClaim 事件处理权，处理成功后调用 Done 标记完成，失败后调用 Release 释放以便重试
+type webhookClaim struct {
+	redisClient *redis.Client
+	key         string
+	token       string
+}
+
+// webhookDedupKey 事件去重 key，格式: webhook:{event_type}:{event_id}
+func webhookDedupKey(eventType, eventID string) string {
+	return fmt.Sprintf("webhook:%s:%s", eventType, eventID)
+}
+
+// claimWebhookEvent 通过 SETNX 原子地获取事件处理权
+// 事件已处理完成时返回 (nil, false, nil)；其他实例处理中时返回 ErrWebhookEventInProgress
+func claimWebhookEvent(ctx context.Context, redisClient *redis.Client, key string) (*webhookClaim, bool, error) {
+	token := generateSessionID()
+	acquired, err := redisClient.SetNX(ctx, key, token, webhookProcessingTTL).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if acquired {
+		return &webhookClaim{redisClient: redisClient, key: key, token: token}, true, nil
+	}
+
+	value, err := redisClient.Get(ctx, key).Result()
+	if err == redis.Nil {
+		// 处理中标记恰好过期或被释放，再尝试一次
+		acquired, err = redisClient.SetNX(ctx, key, token, webhookProcessingTTL).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		if acquired {
+			return &webhookClaim{redisClient: redisClient, key: key, token: token}, true, nil
+		}
+		return nil, false, ErrWebhookEventInProgress
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	// 兼容旧版本写入的完成标记 "1"
+	if value == webhookDoneMarker || value == "1" {
+		return nil, false, nil
+	}
+	return nil, false, ErrWebhookEventInProgress
+}
+
+// Done 标记事件处理完成，之后 LiveKit 的重试直接忽略
+func (c *webhookClaim) Done(ctx context.Context) error {
+	return markWebhookDoneScript.Run(ctx, c.redisClient, []string{c.key}, c.token, webhookDoneMarker, webhookDoneTTL.Milliseconds()).Err()
+}
+
+// Release 释放处理权（处理失败时调用），LiveKit 的重试可重新处理
+func (c *webhookClaim) Release(ctx context.Context) error {
+	return releaseLockScript.Run(ctx, c.redisClient, []string{c.key}, c.token).Err()
+}
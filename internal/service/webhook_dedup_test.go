@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"tgo-rtc-server/internal/config"
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	utils.Logger = zap.NewNop()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+// newTestWebhookService 创建使用空 SQLite 库的 webhook 服务（未建表，查询房间会失败）
+func newTestWebhookService(t *testing.T, client *redis.Client) *WebhookService {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("打开 SQLite 失败: %v", err)
+	}
+	return NewWebhookService(db, client, &config.Config{})
+}
+
+func TestClaimWebhookEventConcurrent(t *testing.T) {
+	_, client := newTestRedis(t)
+	key := webhookDedupKey(models.WebhookEventParticipantJoined, "EV_1")
+
+	const workers = 20
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		claimed    int
+		inProgress int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := claimWebhookEvent(context.Background(), client, key)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == ErrWebhookEventInProgress:
+				inProgress++
+			case err != nil:
+				t.Errorf("获取处理权失败: %v", err)
+			case ok:
+				claimed++
+			}
+		}()
+	}
+	wg.Wait()
+
+	if claimed != 1 || inProgress != workers-1 {
+		t.Fatalf("claimed = %d, in progress = %d, want 1 and %d", claimed, inProgress, workers-1)
+	}
+}
+
+func TestWebhookClaimDone(t *testing.T) {
+	mr, client := newTestRedis(t)
+	ctx := context.Background()
+	key := webhookDedupKey(models.WebhookEventRoomStarted, "EV_2")
+
+	claim, ok, err := claimWebhookEvent(ctx, client, key)
+	if err != nil || !ok {
+		t.Fatalf("claimWebhookEvent() = %v, %v", ok, err)
+	}
+	if err := claim.Done(ctx); err != nil {
+		t.Fatalf("Done() error: %v", err)
+	}
+	if got, _ := mr.Get(key); got != webhookDoneMarker {
+		t.Fatalf("marker = %q, want %q", got, webhookDoneMarker)
+	}
+	if ttl := mr.TTL(key); ttl != webhookDoneTTL {
+		t.Fatalf("ttl = %v, want %v", ttl, webhookDoneTTL)
+	}
+
+	// 已处理完成的事件不再获取处理权，也不视为处理中
+	if _, ok, err := claimWebhookEvent(ctx, client, key); ok || err != nil {
+		t.Fatalf("claim after done = %v, %v, want false, nil", ok, err)
+	}
+}
+
+func TestWebhookClaimRelease(t *testing.T) {
+	_, client := newTestRedis(t)
+	ctx := context.Background()
+	key := webhookDedupKey(models.WebhookEventRoomFinished, "EV_3")
+
+	claim, ok, err := claimWebhookEvent(ctx, client, key)
+	if err != nil || !ok {
+		t.Fatalf("claimWebhookEvent() = %v, %v", ok, err)
+	}
+	if err := claim.Release(ctx); err != nil {
+		t.Fatalf("Release() error: %v", err)
+	}
+	if _, ok, err := claimWebhookEvent(ctx, client, key); !ok || err != nil {
+		t.Fatalf("claim after release = %v, %v, want true, nil", ok, err)
+	}
+}
+
+func TestWebhookClaimProcessingExpires(t *testing.T) {
+	mr, client := newTestRedis(t)
+	ctx := context.Background()
+	key := webhookDedupKey(models.WebhookEventParticipantLeft, "EV_4")
+
+	stale, ok, err := claimWebhookEvent(ctx, client, key)
+	if err != nil || !ok {
+		t.Fatalf("claimWebhookEvent() = %v, %v", ok, err)
+	}
+	if _, _, err := claimWebhookEvent(ctx, client, key); err != ErrWebhookEventInProgress {
+		t.Fatalf("second claim error = %v, want ErrWebhookEventInProgress", err)
+	}
+
+	// 处理实例异常退出：处理中标记过期后可重新获取
+	mr.FastForward(webhookProcessingTTL)
+	fresh, ok, err := claimWebhookEvent(ctx, client, key)
+	if err != nil || !ok {
+		t.Fatalf("claim after expiry = %v, %v", ok, err)
+	}
+
+	// 过期的持有者不能释放或完成新持有者的处理权
+	if err := stale.Release(ctx); err != nil {
+		t.Fatalf("stale Release() error: %v", err)
+	}
+	if err := stale.Done(ctx); err != nil {
+		t.Fatalf("stale Done() error: %v", err)
+	}
+	if got, _ := mr.Get(key); got != fresh.token {
+		t.Fatalf("marker = %q, want holder token %q", got, fresh.token)
+	}
+}
+
+func TestWebhookClaimLegacyMarker(t *testing.T) {
+	mr, client := newTestRedis(t)
+	key := webhookDedupKey(models.WebhookEventRoomStarted, "EV_5")
+	mr.Set(key, "1")
+
+	if _, ok, err := claimWebhookEvent(context.Background(), client, key); ok || err != nil {
+		t.Fatalf("claim with legacy marker = %v, %v, want false, nil", ok, err)
+	}
+}
+
+func TestHandleWebhookEventReleasesOnError(t *testing.T) {
+	mr, client := newTestRedis(t)
+	ws := newTestWebhookService(t, client)
+	event := &models.WebhookEvent{
+		Event: models.WebhookEventRoomStarted,
+		ID:    "EV_6",
+		Room:  &models.RoomInfo{Name: "room_1"},
+	}
+	key := webhookDedupKey(event.Event, event.ID)
+
+	// 未建表，处理失败后释放处理权，LiveKit 的重试可重新处理
+	if err := ws.HandleWebhookEvent(context.Background(), event); err == nil {
+		t.Fatal("HandleWebhookEvent() error = nil, want query error")
+	}
+	if mr.Exists(key) {
+		t.Fatalf("dedup key %q still exists after failure", key)
+	}
+	if err := ws.HandleWebhookEvent(context.Background(), event); err == nil {
+		t.Fatal("retry was skipped, want it to be processed again")
+	}
+}
+
+func TestHandleWebhookEventMarksDone(t *testing.T) {
+	mr, client := newTestRedis(t)
+	ws := newTestWebhookService(t, client)
+	// 房间信息为空时处理直接成功
+	event := &models.WebhookEvent{Event: models.WebhookEventRoomStarted, ID: "EV_7"}
+	key := webhookDedupKey(event.Event, event.ID)
+
+	if err := ws.HandleWebhookEvent(context.Background(), event); err != nil {
+		t.Fatalf("HandleWebhookEvent() error: %v", err)
+	}
+	if got, _ := mr.Get(key); got != webhookDoneMarker {
+		t.Fatalf("marker = %q, want %q", got, webhookDoneMarker)
+	}
+	// 重复事件直接忽略
+	if err := ws.HandleWebhookEvent(context.Background(), event); err != nil {
+		t.Fatalf("duplicate HandleWebhookEvent() error: %v", err)
+	}
+}
+
+func TestHandleWebhookEventInProgress(t *testing.T) {
+	mr, client := newTestRedis(t)
+	ws := newTestWebhookService(t, client)
+	event := &models.WebhookEvent{Event: models.WebhookEventRoomStarted, ID: "EV_8"}
+	key := webhookDedupKey(event.Event, event.ID)
+	mr.Set(key, "other-replica")
+
+	if err := ws.HandleWebhookEvent(context.Background(), event); err != ErrWebhookEventInProgress {
+		t.Fatalf("HandleWebhookEvent() error = %v, want ErrWebhookEventInProgress", err)
+	}
+	// 其他实例的处理中标记保持不变
+	if got, _ := mr.Get(key); got != "other-replica" {
+		t.Fatalf("marker = %q, want other-replica", got)
+	}
+}
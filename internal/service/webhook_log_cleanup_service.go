@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"time"
 
 	"tgo-rtc-server/internal/config"
@@ -16,6 +17,8 @@ type WebhookLogCleanupService struct {
 	config *config.Config
 	ticker *time.Ticker
 	done   chan bool
+
+	archiveService *ArchiveService // 配置 ARCHIVE_WEBHOOK_LOGS 时先归档再删除
 }
 
 // NewWebhookLogCleanupService 创建 webhook 日志清理服务
@@ -27,6 +30,11 @@ func NewWebhookLogCleanupService(db *gorm.DB, cfg *config.Config) *WebhookLogCle
 	}
 }
 
+// SetArchiveService 设置冷数据归档服务
+func (wlcs *WebhookLogCleanupService) SetArchiveService(as *ArchiveService) {
+	wlcs.archiveService = as
+}
+
 // Start 启动日志清理定时器
 func (wlcs *WebhookLogCleanupService) Start() {
 	logger := utils.GetLogger()
@@ -79,6 +87,25 @@ func (wlcs *WebhookLogCleanupService) cleanup() {
 	// 计算截断时间
 	cutoffTime := time.Now().AddDate(0, 0, -wlcs.config.BusinessWebhookLogRetentionDays)
 
+	// 启用归档时移入归档表或归档文件，不直接删除
+	if wlcs.config.ArchiveWebhookLogs && wlcs.archiveService != nil && wlcs.archiveService.Enabled() {
+		archived, err := wlcs.archiveService.ArchiveWebhookLogs(context.Background(), cutoffTime)
+		if err != nil {
+			logger.Error("归档 webhook 日志失败",
+				zap.Int64("archived_count", archived),
+				zap.Error(err),
+			)
+			return
+		}
+		if archived > 0 {
+			logger.Info("webhook 日志归档完成",
+				zap.Int64("archived_count", archived),
+				zap.Time("cutoff_time", cutoffTime),
+			)
+		}
+		return
+	}
+
 	// 删除旧日志（使用原始 SQL）
 	result := wlcs.db.Exec("DELETE FROM business_webhook_log WHERE created_at < ?", cutoffTime)
 	if result.Error != nil {
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// errStaleWebhookEvent 事件乱序到达，房间或参与者已应用了更晚的事件，不再处理
+var errStaleWebhookEvent = errors.New("webhook 事件已过期")
+
+// webhookEventTime 事件发生时间（LiveKit 的 createdAt，Unix 秒），缺失时取当前时间
+func webhookEventTime(event *models.WebhookEvent) int64 {
+	if t := event.CreatedAt.Int64(); t > 0 {
+		return t
+	}
+	return time.Now().Unix()
+}
+
+// participantJoinedAt 参与者本次连接加入 LiveKit 房间的时间，缺失时取事件时间
+func participantJoinedAt(event *models.WebhookEvent) int64 {
+	if event.Participant != nil {
+		if t := event.Participant.JoinedAt.Int64(); t > 0 {
+			return t
+		}
+	}
+	return webhookEventTime(event)
+}
+
+// isOutOfOrderEvent 房间已应用过更晚发生的事件（事件乱序到达）
+func isOutOfOrderEvent(room *models.Room, event *models.WebhookEvent) bool {
+	t := event.CreatedAt.Int64()
+	return t > 0 && t < room.LastEventAt
+}
+
+// recordRoomEventTime 记录房间最后应用的事件时间，只前进不后退
+func (ws *WebhookService) recordRoomEventTime(ctx context.Context, event *models.WebhookEvent) {
+	t := event.CreatedAt.Int64()
+	if event.Room == nil || t <= 0 {
+		return
+	}
+	if err := ws.db.WithContext(ctx).Model(&models.Room{}).
+		Where("room_id = ? AND last_event_at < ?", event.Room.Name, t).
+		Update("last_event_at", t).Error; err != nil {
+		utils.LoggerFromContext(ctx).Warn("记录房间最后事件时间失败",
+			zap.String("room_id", event.Room.Name),
+			zap.Int64("event_at", t),
+			zap.Error(err),
+		)
+	}
+}
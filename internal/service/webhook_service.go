@@ -3,11 +3,13 @@ package service
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"time"
 
 	"tgo-rtc-server/internal/config"
+	"tgo-rtc-server/internal/livekit"
+	"tgo-rtc-server/internal/metrics"
 	"tgo-rtc-server/internal/models"
+	"tgo-rtc-server/internal/tracing"
 	"tgo-rtc-server/internal/utils"
 
 	"github.com/go-redis/redis/v8"
@@ -21,6 +23,8 @@ type WebhookService struct {
 	redisClient            *redis.Client
 	config                 *config.Config
 	businessWebhookService *BusinessWebhookService
+	activeCallIndex        *ActiveCallIndex
+	roomClient             *livekit.RoomClient
 }
 
 // NewWebhookService 创建 webhook 服务
@@ -37,55 +41,88 @@ func (ws *WebhookService) SetBusinessWebhookService(bws *BusinessWebhookService)
 	ws.businessWebhookService = bws
 }
 
+// SetActiveCallIndex 设置通话索引
+func (ws *WebhookService) SetActiveCallIndex(aci *ActiveCallIndex) {
+	ws.activeCallIndex = aci
+}
+
+// SetRoomClient 设置 LiveKit RoomService 客户端（主持人自动顺延时同步已连接参与者的角色）
+func (ws *WebhookService) SetRoomClient(rc *livekit.RoomClient) {
+	ws.roomClient = rc
+}
+
 // HandleWebhookEvent 处理 webhook 事件
 // 支持分布式环境中的事件去重（使用 Redis）
-func (ws *WebhookService) HandleWebhookEvent(event *models.WebhookEvent) error {
-	logger := utils.GetLogger()
+func (ws *WebhookService) HandleWebhookEvent(ctx context.Context, event *models.WebhookEvent) (processErr error) {
+	logger := utils.LoggerFromContext(ctx)
+	if event.Room != nil {
+		uid := ""
+		if event.Participant != nil {
+			uid = event.Participant.Identity
+		}
+		tracing.SetRoom(ctx, event.Room.Name, uid)
+	}
 
 	// 使用 Redis 进行事件去重（防止分布式环境中的重复处理）
+	// 处理前通过 SETNX 获取处理权，多个实例同时收到同一事件时只有一个处理；
+	// 处理成功后标记完成，失败则释放，让 LiveKit 的重试可以重新处理
 	if ws.redisClient != nil {
-		// 生成事件 ID（用于去重）
-		// 格式: webhook:{event_type}:{event_id}
-		deduplicationKey := fmt.Sprintf("webhook:%s:%s", event.Event, event.ID)
-
-		// 使用 Redis 检查是否已处理过
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		exists, err := ws.redisClient.Exists(ctx, deduplicationKey).Result()
-		if err != nil {
-			logger.Warn("Redis 查询失败，继续处理事件",
+		deduplicationKey := webhookDedupKey(event.Event, event.ID)
+
+		claimCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		claim, claimed, err := claimWebhookEvent(claimCtx, ws.redisClient, deduplicationKey)
+		cancel()
+		switch {
+		case err == ErrWebhookEventInProgress:
+			logger.Info("webhook 事件正由其他实例处理",
+				zap.String("event_type", event.Event),
+				zap.String("event_id", event.ID),
+			)
+			metrics.LiveKitWebhookEvents.WithLabelValues(event.Event, "in_progress").Inc()
+			return err
+		case err != nil:
+			logger.Warn("Redis 去重失败，继续处理事件",
 				zap.String("event_id", event.ID),
 				zap.Error(err),
 			)
-		} else if exists > 0 {
+		case !claimed:
 			// 事件已处理过，直接返回
+			metrics.LiveKitWebhookEvents.WithLabelValues(event.Event, "duplicate").Inc()
 			return nil
+		default:
+			defer func() {
+				// 请求上下文可能已取消，仍需更新去重标记
+				markCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+				defer cancel()
+				if processErr != nil {
+					if err := claim.Release(markCtx); err != nil {
+						logger.Warn("Redis 释放事件处理权失败，需等待处理中标记过期后重试",
+							zap.String("event_id", event.ID),
+							zap.Error(err),
+						)
+					}
+					return
+				}
+				if err := claim.Done(markCtx); err != nil {
+					logger.Warn("Redis 设置失败，事件已处理但未标记",
+						zap.String("event_id", event.ID),
+						zap.Error(err),
+					)
+				}
+			}()
 		}
-
-		// 处理事件后，标记为已处理（设置 1 小时过期）
-		defer func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-
-			if err := ws.redisClient.Set(ctx, deduplicationKey, "1", time.Hour).Err(); err != nil {
-				logger.Warn("Redis 设置失败，事件已处理但未标记",
-					zap.String("event_id", event.ID),
-					zap.Error(err),
-				)
-			}
-		}()
 	}
 
+	var err error
 	switch event.Event {
 	case models.WebhookEventRoomStarted:
-		return ws.handleRoomStarted(event) // 房间开始
+		err = ws.handleRoomStarted(ctx, event) // 房间开始
 	case models.WebhookEventRoomFinished:
-		return ws.handleRoomFinished(event) // 房间结束
+		err = ws.handleRoomFinished(ctx, event) // 房间结束
 	case models.WebhookEventParticipantJoined:
-		return ws.handleParticipantJoined(event) // 参与者加入房间
+		err = ws.handleParticipantJoined(ctx, event) // 参与者加入房间
 	case models.WebhookEventParticipantLeft:
-		return ws.handleParticipantLeft(event) // 参与者离开房间
+		err = ws.handleParticipantLeft(ctx, event) // 参与者离开房间
 	// case models.WebhookEventParticipantConnectionAborted:
 	// 	return ws.handleParticipantConnectionAborted(event)
 	// case models.WebhookEventTrackPublished:
@@ -96,20 +133,41 @@ func (ws *WebhookService) HandleWebhookEvent(event *models.WebhookEvent) error {
 		logger.Warn("未知的 webhook 事件类型",
 			zap.String("event_type", event.Event),
 		)
+		metrics.LiveKitWebhookEvents.WithLabelValues(event.Event, "ignored").Inc()
 		return nil
 	}
+
+	result := "ok"
+	switch {
+	case err == errStaleWebhookEvent:
+		// 乱序到达的过期事件不应用，视为处理成功，避免 LiveKit 重试
+		logger.Info("忽略乱序到达的 webhook 事件",
+			zap.String("event_type", event.Event),
+			zap.String("event_id", event.ID),
+			zap.Int64("created_at", event.CreatedAt.Int64()),
+		)
+		result = "stale"
+		err = nil
+	case err != nil:
+		result = "error"
+	default:
+		ws.recordRoomEventTime(ctx, event)
+	}
+	metrics.LiveKitWebhookEvents.WithLabelValues(event.Event, result).Inc()
+	return err
 }
 
 // handleRoomStarted 处理房间开始事件
-func (ws *WebhookService) handleRoomStarted(event *models.WebhookEvent) error {
+func (ws *WebhookService) handleRoomStarted(ctx context.Context, event *models.WebhookEvent) error {
+	db := ws.db.WithContext(ctx)
 	if event.Room == nil {
 		return nil
 	}
-	logger := utils.GetLogger()
+	logger := utils.LoggerFromContext(ctx)
 
 	// 1、查询房间是否存在，如果存在则更新状态为进行中
 	var room models.Room
-	if err := ws.db.Where("room_id = ?", event.Room.Name).First(&room).Error; err != nil {
+	if err := db.Where("room_id = ?", event.Room.Name).First(&room).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.Warn("livekit事件: 房间开始--->房间不存在",
 				zap.String("room_id", event.Room.Name),
@@ -123,8 +181,14 @@ func (ws *WebhookService) handleRoomStarted(event *models.WebhookEvent) error {
 		return err
 	}
 
+	// 房间已是终态（如 room_finished 先到达），或已应用过更晚的事件，不再回退为进行中
+	if room.Status > models.RoomStatusInProgress ||
+		(room.Status == models.RoomStatusInProgress && isOutOfOrderEvent(&room, event)) {
+		return errStaleWebhookEvent
+	}
+
 	// 更新房间状态为进行中
-	if err := ws.db.Model(&room).Update("status", models.RoomStatusInProgress).Error; err != nil {
+	if err := db.Model(&room).Update("status", models.RoomStatusInProgress).Error; err != nil {
 		logger.Error("livekit事件: 房间开始--->更新房间状态失败",
 			zap.String("room_id", event.Room.Name),
 			zap.Uint8("room_status", models.RoomStatusInProgress),
@@ -133,24 +197,37 @@ func (ws *WebhookService) handleRoomStarted(event *models.WebhookEvent) error {
 		return err
 	}
 	room.Status = models.RoomStatusInProgress
+	// 持久房间同步更新当前会话状态
+	if room.SessionID != "" {
+		if err := db.Model(&models.RoomSession{}).
+			Where("session_id = ?", room.SessionID).
+			Update("status", models.RoomStatusInProgress).Error; err != nil {
+			logger.Error("livekit事件: 房间开始--->更新房间会话状态失败",
+				zap.String("room_id", event.Room.Name),
+				zap.String("session_id", room.SessionID),
+				zap.Error(err),
+			)
+		}
+	}
 	// 2、通知业务的webhook
 	if ws.businessWebhookService != nil {
-		ws.businessWebhookService.sendRoomStarted(&room)
+		ws.businessWebhookService.sendRoomStarted(ctx, &room)
 	}
 
 	return nil
 }
 
 // handleRoomFinished 处理房间结束事件
-func (ws *WebhookService) handleRoomFinished(event *models.WebhookEvent) error {
+func (ws *WebhookService) handleRoomFinished(ctx context.Context, event *models.WebhookEvent) error {
+	db := ws.db.WithContext(ctx)
 	if event.Room == nil {
 		return nil
 	}
-	logger := utils.GetLogger()
+	logger := utils.LoggerFromContext(ctx)
 
 	// 先查询房间当前状态
 	var room models.Room
-	if err := ws.db.Where("room_id = ?", event.Room.Name).First(&room).Error; err != nil {
+	if err := db.Where("room_id = ?", event.Room.Name).First(&room).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.Warn("livekit事件: 房间结束--->房间不存在",
 				zap.String("room_id", event.Room.Name),
@@ -164,6 +241,16 @@ func (ws *WebhookService) handleRoomFinished(event *models.WebhookEvent) error {
 		return err
 	}
 
+	// 持久房间上一个会话的结束事件延迟到达，不能结束当前会话
+	if ws.isStaleSessionEvent(ctx, &room, event) {
+		return errStaleWebhookEvent
+	}
+
+	// 结束事件之后发生的事件已被应用（如参与者重新加入，LiveKit 重新创建了房间），结束事件已过期
+	if isOutOfOrderEvent(&room, event) {
+		return errStaleWebhookEvent
+	}
+
 	// 房间已经是终态（超时/取消/拒绝等），不覆盖状态
 	if room.Status > models.RoomStatusInProgress {
 		logger.Info("livekit事件: 房间结束--->房间已是终态，跳过状态更新",
@@ -174,7 +261,7 @@ func (ws *WebhookService) handleRoomFinished(event *models.WebhookEvent) error {
 	}
 
 	// 房间仍在进行中，更新为已结束
-	if err := ws.db.Model(&models.Room{}).
+	if err := db.Model(&models.Room{}).
 		Where("room_id = ? AND status <= ?", event.Room.Name, models.RoomStatusInProgress).
 		Update("status", models.RoomStatusFinished).Error; err != nil {
 		logger.Error("livekit事件: 房间结束--->更新房间状态失败",
@@ -185,7 +272,7 @@ func (ws *WebhookService) handleRoomFinished(event *models.WebhookEvent) error {
 	}
 
 	// 将仍在 邀请中/已加入 的参与者标记为挂断
-	if err := ws.db.Model(&models.Participant{}).
+	if err := db.Model(&models.Participant{}).
 		Where("room_id = ? AND (status = ? OR status = ?)", event.Room.Name, models.ParticipantStatusInviting, models.ParticipantStatusJoined).
 		Update("status", models.ParticipantStatusHangup).Error; err != nil {
 		logger.Error("livekit事件: 房间结束--->更新房间参与者状态为挂断失败",
@@ -194,16 +281,28 @@ func (ws *WebhookService) handleRoomFinished(event *models.WebhookEvent) error {
 		)
 	}
 
+	// 房间已结束，仍在等候室中的用户标记为已取消
+	if err := db.Model(&models.Participant{}).
+		Where("room_id = ? AND status = ?", event.Room.Name, models.ParticipantStatusLobby).
+		Update("status", models.ParticipantStatusCancelled).Error; err != nil {
+		logger.Error("livekit事件: 房间结束--->更新等候室参与者状态为已取消失败",
+			zap.String("room_id", event.Room.Name),
+			zap.Error(err),
+		)
+	}
+	ws.activeCallIndex.SyncRoom(ctx, event.Room.Name)
+
 	// 通知业务的 webhook
 	if ws.businessWebhookService != nil {
-		ws.businessWebhookService.checkAndFinishRoom(&room)
+		ws.businessWebhookService.checkAndFinishRoom(ctx, &room)
 	}
 	return nil
 }
 
 // handleParticipantJoined 处理参与者加入事件
-func (ws *WebhookService) handleParticipantJoined(event *models.WebhookEvent) error {
-	logger := utils.GetLogger()
+func (ws *WebhookService) handleParticipantJoined(ctx context.Context, event *models.WebhookEvent) error {
+	db := ws.db.WithContext(ctx)
+	logger := utils.LoggerFromContext(ctx)
 
 	if event.Room == nil || event.Participant == nil {
 		return nil
@@ -225,9 +324,34 @@ func (ws *WebhookService) handleParticipantJoined(event *models.WebhookEvent) er
 		}
 	}
 
-	// 1、判断参与者是否在 rtc_participant 表存在
+	// 1、查询房间，已结束的房间不因迟到的加入事件恢复为通话中
+	var room models.Room
+	roomFound := true
+	if err := db.Where("room_id = ?", event.Room.Name).First(&room).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			logger.Error("查询房间信息失败",
+				zap.String("room_id", event.Room.Name),
+				zap.Error(err),
+			)
+			return err
+		}
+		roomFound = false
+	}
+	if roomFound {
+		// 房间已是终态（如 room_finished 先于 participant_joined 到达）
+		if room.Status > models.RoomStatusInProgress {
+			return errStaleWebhookEvent
+		}
+		// 持久房间上一个会话的加入事件延迟到达
+		if ws.isStaleSessionEvent(ctx, &room, event) {
+			return errStaleWebhookEvent
+		}
+	}
+	joinedAt := participantJoinedAt(event)
+
+	// 2、判断参与者是否在 rtc_participant 表存在
 	var participant models.Participant
-	if err := ws.db.Where("room_id = ? AND uid = ?", event.Room.Name, event.Participant.Identity).First(&participant).Error; err != nil {
+	if err := db.Where("room_id = ? AND uid = ?", event.Room.Name, event.Participant.Identity).First(&participant).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			// 参与者不存在，插入一条新记录
 			participant = models.Participant{
@@ -235,9 +359,9 @@ func (ws *WebhookService) handleParticipantJoined(event *models.WebhookEvent) er
 				UID:        event.Participant.Identity,
 				DeviceType: deviceType,
 				Status:     models.ParticipantStatusJoined,
-				JoinTime:   time.Now().Unix(),
+				JoinTime:   joinedAt,
 			}
-			if err := ws.db.Create(&participant).Error; err != nil {
+			if err := db.Create(&participant).Error; err != nil {
 				logger.Error("创建参与者记录失败",
 					zap.String("room_id", event.Room.Name),
 					zap.String("uid", event.Participant.Identity),
@@ -254,10 +378,16 @@ func (ws *WebhookService) handleParticipantJoined(event *models.WebhookEvent) er
 			return err
 		}
 	} else {
+		// 本次连接的离开事件已先到达并应用（离开时间不早于本次加入时间），不再恢复为已加入
+		if participant.Status != models.ParticipantStatusInviting &&
+			participant.Status != models.ParticipantStatusJoined &&
+			participant.LeaveTime >= joinedAt {
+			return errStaleWebhookEvent
+		}
 		// 参与者已存在，更新状态为已加入
-		if err := ws.db.Model(&participant).Updates(map[string]interface{}{
+		if err := db.Model(&participant).Updates(map[string]interface{}{
 			"status":      models.ParticipantStatusJoined,
-			"join_time":   time.Now().Unix(),
+			"join_time":   joinedAt,
 			"device_type": deviceType,
 		}).Error; err != nil {
 			logger.Error("更新参与者状态失败",
@@ -268,35 +398,27 @@ func (ws *WebhookService) handleParticipantJoined(event *models.WebhookEvent) er
 			return err
 		}
 	}
+	ws.activeCallIndex.SyncRoom(ctx, event.Room.Name)
 
-	// 2、通知业务的 webhook
-	if ws.businessWebhookService != nil {
-		// 查询房间信息
-		var room models.Room
-		if err := ws.db.Where("room_id = ?", participant.RoomID).First(&room).Error; err != nil {
-			logger.Error("查询房间信息失败",
-				zap.String("room_id", participant.RoomID),
-				zap.Error(err),
-			)
-		} else {
-			ws.businessWebhookService.sendParticipantJoined(&room, participant.UID, deviceType)
-			// 构建事件数据
-		}
+	// 3、通知业务的 webhook
+	if ws.businessWebhookService != nil && roomFound {
+		ws.businessWebhookService.sendParticipantJoined(ctx, &room, participant.UID, deviceType)
 	}
 
 	return nil
 }
 
 // handleParticipantLeft 处理参与者离开事件
-func (ws *WebhookService) handleParticipantLeft(event *models.WebhookEvent) error {
+func (ws *WebhookService) handleParticipantLeft(ctx context.Context, event *models.WebhookEvent) error {
+	db := ws.db.WithContext(ctx)
 	if event.Room == nil || event.Participant == nil {
 		return nil
 	}
-	logger := utils.GetLogger()
+	logger := utils.LoggerFromContext(ctx)
 
 	// 1、查询房间信息
 	var room models.Room
-	if err := ws.db.Where("room_id = ?", event.Room.Name).First(&room).Error; err != nil {
+	if err := db.Where("room_id = ?", event.Room.Name).First(&room).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			logger.Warn("livekit事件: 参与者离开--->房间不存在",
 				zap.String("room_id", event.Room.Name),
@@ -309,19 +431,34 @@ func (ws *WebhookService) handleParticipantLeft(event *models.WebhookEvent) erro
 		)
 		return err
 	}
-	// 如果房间已经结束或取消，则跳过
+	// 如果房间已经结束或取消（如 room_finished 先于最后的 participant_left 到达），则跳过
 	if room.Status > models.RoomStatusInProgress {
-		return nil
+		return errStaleWebhookEvent
+	}
+	// 持久房间上一个会话的离开事件延迟到达，跳过
+	if ws.isStaleSessionEvent(ctx, &room, event) {
+		return errStaleWebhookEvent
+	}
+
+	// 参与者断线重连后，上一次连接的离开事件迟于本次加入事件到达，跳过
+	leftAt := webhookEventTime(event)
+	var currentParticipant models.Participant
+	if err := db.Where("uid = ? AND room_id = ?", event.Participant.Identity, event.Room.Name).First(&currentParticipant).Error; err == nil {
+		if currentParticipant.Status == models.ParticipantStatusJoined &&
+			event.Participant.JoinedAt.Int64() > 0 &&
+			currentParticipant.JoinTime > event.Participant.JoinedAt.Int64() {
+			return errStaleWebhookEvent
+		}
 	}
 
 	// 更新参与者状态为已挂断，并设置离开时间（仅更新仍在 邀请中/已加入 状态的参与者）
 	var leftParticipant models.Participant
-	if err := ws.db.Model(&models.Participant{}).
+	if err := db.Model(&models.Participant{}).
 		Where("uid = ? AND room_id = ? AND status IN ?", event.Participant.Identity, event.Room.Name,
-			[]uint8{models.ParticipantStatusInviting, models.ParticipantStatusJoined}).
+			activeParticipantStatuses).
 		Updates(map[string]interface{}{
 			"status":     models.ParticipantStatusHangup,
-			"leave_time": time.Now().Unix(),
+			"leave_time": leftAt,
 		}).Error; err != nil {
 		logger.Error("livekit事件: 参与者离开--->更新参与者状态为已挂断失败",
 			zap.String("participant_uid", event.Participant.Identity),
@@ -332,7 +469,7 @@ func (ws *WebhookService) handleParticipantLeft(event *models.WebhookEvent) erro
 	}
 
 	// 查询离开的参与者信息
-	if err := ws.db.Where("uid = ? AND room_id = ?", event.Participant.Identity, event.Room.Name).First(&leftParticipant).Error; err != nil {
+	if err := db.Where("uid = ? AND room_id = ?", event.Participant.Identity, event.Room.Name).First(&leftParticipant).Error; err != nil {
 		logger.Error("livekit事件: 参与者离开--->查询离开的参与者信息失败",
 			zap.String("participant_uid", event.Participant.Identity),
 			zap.String("room_id", event.Room.Name),
@@ -341,7 +478,7 @@ func (ws *WebhookService) handleParticipantLeft(event *models.WebhookEvent) erro
 	}
 
 	var allParticipants []models.Participant
-	if err := ws.db.Where("room_id = ?", event.Room.Name).Find(&allParticipants).Error; err != nil {
+	if err := db.Where("room_id = ?", event.Room.Name).Find(&allParticipants).Error; err != nil {
 		logger.Error("livekit事件: 参与者离开--->查所有参与者失败",
 			zap.String("room_id", event.Room.Name),
 			zap.Error(err),
@@ -349,10 +486,7 @@ func (ws *WebhookService) handleParticipantLeft(event *models.WebhookEvent) erro
 		return err
 	}
 
-	uids := make([]string, 0, len(allParticipants))
-	for _, p := range allParticipants {
-		uids = append(uids, p.UID)
-	}
+	uids := visibleUIDs(allParticipants)
 	isSendCancelEvent := false
 	// 情况1：房间的 max_participants=2，则标记房间已经结束
 	if room.MaxParticipants == 2 {
@@ -370,7 +504,7 @@ func (ws *WebhookService) handleParticipantLeft(event *models.WebhookEvent) erro
 		room.Status = models.RoomStatusFinished
 		if joinedCount < 2 {
 			// 未通话
-			if time.Now().Unix()-leftParticipant.JoinTime > int64(ws.config.LiveKitTimeout) {
+			if leftAt-leftParticipant.JoinTime > int64(ws.config.LiveKitTimeout) {
 				room.Status = models.RoomStatusMissed // 超时未接听
 				otherParticipantStatus = models.ParticipantStatusMissed
 			} else {
@@ -379,7 +513,7 @@ func (ws *WebhookService) handleParticipantLeft(event *models.WebhookEvent) erro
 			}
 		}
 		// 更新房间状态为已结束
-		if err := ws.db.Model(&models.Room{}).
+		if err := db.Model(&models.Room{}).
 			Where("room_id = ?", event.Room.Name).
 			Update("status", room.Status).Error; err != nil {
 			logger.Error("livekit事件: 参与者离开--->更新房间状态为完成错误",
@@ -391,7 +525,7 @@ func (ws *WebhookService) handleParticipantLeft(event *models.WebhookEvent) erro
 		}
 
 		// 修改另外一个参与者的状态
-		if err := ws.db.Model(&models.Participant{}).
+		if err := db.Model(&models.Participant{}).
 			Where("room_id = ? AND uid != ?", event.Room.Name, event.Participant.Identity).
 			Update("status", otherParticipantStatus).Error; err != nil {
 			logger.Error("livekit事件: 参与者离开--->更新其他参与者状态失败",
@@ -401,7 +535,7 @@ func (ws *WebhookService) handleParticipantLeft(event *models.WebhookEvent) erro
 		}
 	} else {
 		// 多人通话场景
-		if leftParticipant.UID == room.Creator {
+		if leftParticipant.UID == room.HostUID() {
 			// 判断是否有其他人加入
 			hasJoined := false
 			for _, p := range allParticipants {
@@ -414,7 +548,7 @@ func (ws *WebhookService) handleParticipantLeft(event *models.WebhookEvent) erro
 				// 如果没有其他人加入，则标记房间已取消
 				room.Status = models.RoomStatusCancelled
 				// 更新房间状态为已结束
-				if err := ws.db.Model(&models.Room{}).
+				if err := db.Model(&models.Room{}).
 					Where("room_id = ?", event.Room.Name).
 					Update("status", room.Status).Error; err != nil {
 					logger.Error("livekit事件: 参与者离开--->多人通话更新房间状态为完成错误",
@@ -425,7 +559,7 @@ func (ws *WebhookService) handleParticipantLeft(event *models.WebhookEvent) erro
 				}
 
 				// 更新其他参与者的状态为已取消
-				if err := ws.db.Model(&models.Participant{}).
+				if err := db.Model(&models.Participant{}).
 					Where("room_id = ?", event.Room.Name).
 					Update("status", models.ParticipantStatusCancelled).Error; err != nil {
 					logger.Error("livekit事件: 参与者离开--->多人通话更所有参与者状态为已取消错误",
@@ -437,16 +571,21 @@ func (ws *WebhookService) handleParticipantLeft(event *models.WebhookEvent) erro
 				// fixme 这里有个小概率事件 当其他参与者加入的同时，发起人离开，会导致这个逻辑执行
 				// fixme 先忽略这个情况
 				isSendCancelEvent = true
+			} else {
+				// 已有其他人加入，主持人身份顺延给下一位已加入的参与者
+				promoteNextHost(ctx, db, ws.businessWebhookService, ws.roomClient, &room, leftParticipant.UID)
 			}
 		}
 	}
+	ws.activeCallIndex.SyncRoom(ctx, event.Room.Name)
+
 	// 3、通知业务的 webhook
 	if ws.businessWebhookService != nil {
 		if isSendCancelEvent {
-			ws.businessWebhookService.sendParticipantCancelled(&room, uids)
+			ws.businessWebhookService.sendParticipantCancelled(ctx, &room, uids)
 		}
-		ws.businessWebhookService.sendParticipantLeft(&room, leftParticipant.UID, uids)
-		ws.businessWebhookService.checkAndFinishRoom(&room)
+		ws.businessWebhookService.sendParticipantLeft(ctx, &room, leftParticipant.UID, uids)
+		ws.businessWebhookService.checkAndFinishRoom(ctx, &room)
 	}
 
 	return nil
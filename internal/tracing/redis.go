@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisHook 为每条 Redis 命令（及每个 Pipeline）创建 Span
+type RedisHook struct{}
+
+var _ redis.Hook = RedisHook{}
+
+// NewRedisHook 创建 Redis 链路追踪 Hook
+func NewRedisHook() RedisHook {
+	return RedisHook{}
+}
+
+// BeforeProcess 命令执行前开始 Span
+func (RedisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	if !hasParent(ctx) {
+		return ctx, nil
+	}
+	ctx, _ = Tracer().Start(ctx, "redis."+cmd.FullName(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", cmd.Name()),
+		),
+	)
+	return ctx, nil
+}
+
+// AfterProcess 命令执行后结束 Span
+func (RedisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if !hasParent(ctx) {
+		return nil
+	}
+	span := trace.SpanFromContext(ctx)
+	if err := cmd.Err(); err != nil && err != redis.Nil {
+		RecordError(span, err)
+	}
+	span.End()
+	return nil
+}
+
+// BeforeProcessPipeline Pipeline 执行前开始 Span
+func (RedisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	if !hasParent(ctx) {
+		return ctx, nil
+	}
+	names := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		names = append(names, cmd.Name())
+	}
+	ctx, _ = Tracer().Start(ctx, "redis.pipeline",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", strings.Join(names, " ")),
+		),
+	)
+	return ctx, nil
+}
+
+// AfterProcessPipeline Pipeline 执行后结束 Span
+func (RedisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	if !hasParent(ctx) {
+		return nil
+	}
+	span := trace.SpanFromContext(ctx)
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && err != redis.Nil {
+			RecordError(span, err)
+			break
+		}
+	}
+	span.End()
+	return nil
+}
+
+// hasParent 是否处于已有链路中
+// 订阅、定时轮询等后台命令不单独生成根 Span，避免噪音
+func hasParent(ctx context.Context) bool {
+	return trace.SpanContextFromContext(ctx).IsValid()
+}
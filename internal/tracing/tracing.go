@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"tgo-rtc-server/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 本服务创建 Span 使用的 Tracer 名称
+const instrumentationName = "tgo-rtc-server"
+
+// 导出器类型
+const (
+	ExporterOTLP   = "otlp"   // OTLP/HTTP 导出到 Collector
+	ExporterStdout = "stdout" // 输出到标准输出（本地调试）
+)
+
+// 业务属性
+const (
+	AttrRoomID    = attribute.Key("rtc.room_id")
+	AttrUID       = attribute.Key("rtc.uid")
+	AttrEventType = attribute.Key("rtc.event_type")
+	AttrEventID   = attribute.Key("rtc.event_id")
+)
+
+// Init 初始化全局 TracerProvider 和 W3C Trace Context 传播器
+// 未启用时保持 OpenTelemetry 默认的空实现，埋点代码无需判断是否启用
+// 返回的函数用于关闭时刷新未导出的 Span
+func Init(cfg *config.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	if !cfg.TracingEnabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.TracingExporter {
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		// 未配置地址时使用 OTEL_EXPORTER_OTLP_ENDPOINT 等标准环境变量（默认 http://localhost:4318）
+		var opts []otlptracehttp.Option
+		if cfg.TracingEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.TracingEndpoint))
+		}
+		exporter, err = otlptracehttp.New(context.Background(), opts...)
+	default:
+		return nil, fmt.Errorf("不支持的链路追踪导出器: %s", cfg.TracingExporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("创建链路追踪导出器失败: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.TracingServiceName),
+		semconv.DeploymentEnvironment(cfg.Env),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("创建链路追踪资源失败: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer 获取本服务的 Tracer
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start 创建子 Span
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// SetRoom 为当前 Span 添加房间和用户属性，便于按 room_id/uid 检索同一通话的所有链路
+func SetRoom(ctx context.Context, roomID, uid string) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	if roomID != "" {
+		span.SetAttributes(AttrRoomID.String(roomID))
+	}
+	if uid != "" {
+		span.SetAttributes(AttrUID.String(uid))
+	}
+}
+
+// RecordError 记录错误并将 Span 标记为失败
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Inject 将当前链路上下文写入 carrier（如出站 HTTP 请求头的 traceparent）
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+// Extract 从 carrier 中读取上游链路上下文
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
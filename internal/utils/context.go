@@ -0,0 +1,13 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestContext 获取传给业务层的请求上下文
+// 保留链路追踪等上下文值，但不随客户端断开而取消，避免房间/成员状态只更新了一半
+func RequestContext(c *gin.Context) context.Context {
+	return context.WithoutCancel(c.Request.Context())
+}
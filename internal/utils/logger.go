@@ -1,6 +1,11 @@
 package utils
 
 import (
+	"context"
+
+	"tgo-rtc-server/internal/middleware"
+
+	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -41,6 +46,20 @@ func GetLogger() *zap.Logger {
 	return Logger
 }
 
+// LoggerFromContext 获取请求级日志记录器（带 request_id 字段）
+// 上下文中没有时（如定时任务、后台协程）返回全局日志记录器
+func LoggerFromContext(ctx context.Context) *zap.Logger {
+	if logger := middleware.LoggerFromContext(ctx); logger != nil {
+		return logger
+	}
+	return GetLogger()
+}
+
+// ContextLogger 获取当前 HTTP 请求的日志记录器
+func ContextLogger(c *gin.Context) *zap.Logger {
+	return LoggerFromContext(c.Request.Context())
+}
+
 // CloseLogger 关闭日志记录器
 func CloseLogger() error {
 	if Logger != nil {
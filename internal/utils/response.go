@@ -2,6 +2,7 @@ package utils
 
 import (
 	"net/http"
+	"strconv"
 	"tgo-rtc-server/internal/errors"
 	"tgo-rtc-server/internal/i18n"
 	"tgo-rtc-server/internal/middleware"
@@ -12,7 +13,7 @@ import (
 
 // RespondWithError 返回错误响应
 func RespondWithError(c *gin.Context, statusCode int, code int, msg string) {
-	c.JSON(statusCode, models.NewErrorResponse(code, msg))
+	c.JSON(statusCode, newErrorResponse(c, code, msg))
 }
 
 // RespondWithBusinessError 返回业务错误响应
@@ -20,18 +21,24 @@ func RespondWithBusinessError(c *gin.Context, err error) {
 	lang := middleware.GetLanguageFromContext(c)
 
 	if businessErr, ok := err.(*errors.BusinessError); ok {
-		c.JSON(http.StatusBadRequest, models.NewErrorResponse(http.StatusBadRequest, businessErr.GetLocalizedMessage(lang)))
+		// 频率限制错误返回 429 和 Retry-After，便于客户端退避重试
+		if businessErr.GetErrorCode() == http.StatusTooManyRequests {
+			c.Header("Retry-After", strconv.Itoa(businessErr.RetryAfter))
+			c.JSON(http.StatusTooManyRequests, newErrorResponse(c, http.StatusTooManyRequests, businessErr.GetLocalizedMessage(lang)))
+			return
+		}
+		c.JSON(http.StatusBadRequest, newErrorResponse(c, http.StatusBadRequest, businessErr.GetLocalizedMessage(lang)))
 		return
 	}
 
 	// 如果不是 BusinessError，返回通用错误
-	c.JSON(http.StatusInternalServerError, models.NewErrorResponse(http.StatusInternalServerError, err.Error()))
+	c.JSON(http.StatusInternalServerError, newErrorResponse(c, http.StatusInternalServerError, err.Error()))
 }
 
 // RespondWithBindError 返回参数绑定错误响应
 func RespondWithBindError(c *gin.Context) {
 	lang := middleware.GetLanguageFromContext(c)
-	c.JSON(http.StatusBadRequest, models.NewErrorResponse(http.StatusBadRequest, i18n.Translate(lang, i18n.InvalidParameters)))
+	c.JSON(http.StatusBadRequest, newErrorResponse(c, http.StatusBadRequest, i18n.Translate(lang, i18n.InvalidParameters)))
 }
 
 // RespondWithSuccess 返回成功响应（只有消息）
@@ -61,15 +68,22 @@ func RespondNoContent(c *gin.Context) {
 
 // RespondUnauthorized 返回未授权错误（HTTP 401）
 func RespondUnauthorized(c *gin.Context, msg string) {
-	c.JSON(http.StatusUnauthorized, models.NewErrorResponse(http.StatusUnauthorized, msg))
+	c.JSON(http.StatusUnauthorized, newErrorResponse(c, http.StatusUnauthorized, msg))
 }
 
 // RespondInternalError 返回内部服务器错误（HTTP 500）
 func RespondInternalError(c *gin.Context, msg string) {
-	c.JSON(http.StatusInternalServerError, models.NewErrorResponse(http.StatusInternalServerError, msg))
+	c.JSON(http.StatusInternalServerError, newErrorResponse(c, http.StatusInternalServerError, msg))
 }
 
 // RespondBadRequest 返回错误请求（HTTP 400）
 func RespondBadRequest(c *gin.Context, msg string) {
-	c.JSON(http.StatusBadRequest, models.NewErrorResponse(http.StatusBadRequest, msg))
+	c.JSON(http.StatusBadRequest, newErrorResponse(c, http.StatusBadRequest, msg))
+}
+
+// newErrorResponse 创建带请求 ID 的错误响应
+func newErrorResponse(c *gin.Context, code int, msg string) *models.ErrorResponse {
+	resp := models.NewErrorResponse(code, msg)
+	resp.RequestID = middleware.GetRequestID(c)
+	return resp
 }
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"tgo-rtc-server/internal/config"
 	"tgo-rtc-server/internal/database"
+	"tgo-rtc-server/internal/push"
 	"tgo-rtc-server/internal/router"
 	"tgo-rtc-server/internal/service"
+	"tgo-rtc-server/internal/tracing"
 	"tgo-rtc-server/internal/utils"
 
 	"github.com/joho/godotenv"
@@ -30,6 +38,20 @@ func main() {
 	// 初始化配置
 	cfg := config.LoadConfig()
 
+	// migrate 子命令：只执行数据库迁移后退出，不启动服务
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := database.RunMigrateCommand(cfg, os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("migrate 命令执行失败: %v", err)
+		}
+		return
+	}
+
+	// 初始化链路追踪（需在数据库、Redis 之前，使其 Hook 使用已配置的 TracerProvider）
+	shutdownTracing, err := tracing.Init(cfg)
+	if err != nil {
+		log.Fatalf("链路追踪初始化失败: %v", err)
+	}
+
 	// 初始化数据库
 	db, err := database.InitDB(cfg)
 	if err != nil {
@@ -45,25 +67,52 @@ func main() {
 	// 初始化业务 webhook 服务
 	businessWebhookService := service.NewBusinessWebhookService(db, redisClient, cfg)
 
+	// 初始化客户端实时事件推送服务（与业务 webhook 共用事件来源）
+	realtimeService := service.NewRealtimeService(redisClient, cfg)
+	businessWebhookService.SetRealtimeService(realtimeService)
+	realtimeService.Start()
+
+	// 初始化移动端来电推送服务（APNs VoIP/FCM/HMS，未配置的通道不启用）
+	pushProviders, err := push.NewProviders(cfg)
+	if err != nil {
+		log.Fatalf("推送通道初始化失败: %v", err)
+	}
+	pushService := service.NewPushService(db, cfg, pushProviders)
+	businessWebhookService.SetPushService(pushService)
+
+	// 初始化通话索引（用户 -> 进行中的房间、房间 -> 活跃参与者），启动时从数据库全量重建
+	activeCallIndex := service.NewActiveCallIndex(db, redisClient, cfg.ActiveCallIndexEnabled)
+	if err := activeCallIndex.Rebuild(context.Background()); err != nil {
+		// 重建失败不影响启动，查询回退到数据库，由超时兜底轮询重试重建
+		logger.Warn("通话索引重建失败", zap.Error(err))
+	}
+
 	// 创建路由（同时获取 participantService 和 roomService）
-	r, participantService, roomService := router.SetupRouter(db, redisClient, cfg, businessWebhookService)
+	r, participantService, roomService := router.SetupRouter(db, redisClient, cfg, businessWebhookService, realtimeService, pushService, activeCallIndex)
 
 	// 启动参与者超时检查定时器
 	scheduler := service.NewSchedulerService(db, cfg)
 	scheduler.SetBusinessWebhookService(businessWebhookService)
 	scheduler.SetParticipantService(participantService)
+	scheduler.SetActiveCallIndex(activeCallIndex)
 
 	// 设置 scheduler 到各个服务（用于精确定时器）
 	participantService.SetSchedulerService(scheduler)
 	roomService.SetSchedulerService(scheduler)
 
 	scheduler.Start()
-	defer scheduler.Stop()
+
+	// 启动冷数据归档定时器
+	archiveService, err := service.NewArchiveService(db, redisClient, cfg)
+	if err != nil {
+		log.Fatalf("归档服务初始化失败: %v", err)
+	}
+	archiveService.Start()
 
 	// 启动 webhook 日志清理定时器
 	logCleanup := service.NewWebhookLogCleanupService(db, cfg)
+	logCleanup.SetArchiveService(archiveService)
 	logCleanup.Start()
-	defer logCleanup.Stop()
 
 	// 启动服务器
 	port := cfg.Port
@@ -71,10 +120,62 @@ func main() {
 		port = "8080"
 	}
 
-	logger.Info("🚀 音视频服务启动",
-		zap.String("port", port),
-	)
-	if err := r.Run(":" + port); err != nil {
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: r,
+	}
+	// 开始关闭时立即断开 SSE 长连接，否则 Shutdown 会一直等待到超时
+	srv.RegisterOnShutdown(realtimeService.Stop)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("🚀 音视频服务启动",
+			zap.String("port", port),
+		)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+		}
+	}()
+
+	// 等待退出信号
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-serverErr:
 		log.Fatalf("启动服务器失败: %v", err)
+	case sig := <-quit:
+		logger.Info("收到退出信号，开始优雅关闭",
+			zap.String("signal", sig.String()),
+			zap.Int("timeout_seconds", cfg.ShutdownTimeout),
+		)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout)*time.Second)
+	defer cancel()
+
+	// 1. 停止接收新请求，等待处理中的请求完成
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("HTTP 服务关闭超时", zap.Error(err))
+	}
+
+	// 2. 停止定时任务，未触发的超时定时器交由其他实例的兜底轮询处理
+	scheduler.Stop()
+	logCleanup.Stop()
+	archiveService.Stop()
+
+	// 3. 等待正在投递的业务 webhook 和来电推送完成
+	if err := businessWebhookService.Drain(ctx); err != nil {
+		logger.Error("等待业务 webhook 投递完成超时，未完成的投递将丢失", zap.Error(err))
+	}
+	if err := pushService.Drain(ctx); err != nil {
+		logger.Error("等待来电推送完成超时，未完成的推送将丢失", zap.Error(err))
+	}
+
+	realtimeService.Stop()
+
+	// 4. 导出剩余的链路数据
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("链路追踪数据导出失败", zap.Error(err))
 	}
+	logger.Info("音视频服务已退出")
 }
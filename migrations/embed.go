@@ -0,0 +1,9 @@
+// Package migrations 内嵌各数据库方言的迁移脚本，使服务二进制不依赖工作目录中的 migrations/ 目录
+package migrations
+
+import "embed"
+
+// FS 内嵌的迁移脚本，目录结构为 <驱动>/<版本>.up.sql、<驱动>/<版本>.down.sql
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var FS embed.FS
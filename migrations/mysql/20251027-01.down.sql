@@ -0,0 +1,4 @@
+-- Migration 20251027-01: Rollback
+-- Description: 删除 rtc_room 表
+
+DROP TABLE IF EXISTS rtc_room;
@@ -0,0 +1,4 @@
+-- Migration 20251030-04: Rollback
+-- Description: 删除 business_webhook_log 表
+
+DROP TABLE IF EXISTS business_webhook_log;
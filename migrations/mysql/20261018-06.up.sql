@@ -0,0 +1,7 @@
+-- Migration 20261018-06: Add persistent room columns to rtc_room table
+-- Description: 添加持久房间标记和当前会话ID字段
+-- Created: 2026-10-18
+
+ALTER TABLE rtc_room
+ADD COLUMN persistent SMALLINT NOT NULL DEFAULT 0 COMMENT '0: 一次性房间, 1: 持久房间' AFTER max_participants,
+ADD COLUMN session_id VARCHAR(40) NOT NULL DEFAULT '' COMMENT '当前会话ID（仅持久房间）' AFTER persistent;
@@ -0,0 +1,18 @@
+-- Migration 20261018-07: Create rtc_room_session table
+-- Description: 创建房间会话表，记录持久房间每一次开始到结束的会话
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_room_session (
+    id INT AUTO_INCREMENT PRIMARY KEY COMMENT '会话记录ID',
+    session_id VARCHAR(40) NOT NULL DEFAULT '' COMMENT '会话ID',
+    room_id VARCHAR(40) NOT NULL DEFAULT '' COMMENT '房间ID',
+    creator VARCHAR(40) NOT NULL DEFAULT '' COMMENT '会话发起者',
+    rtc_type SMALLINT NOT NULL DEFAULT 0 COMMENT '0: 语音, 1: 视频',
+    status SMALLINT NOT NULL DEFAULT 0 COMMENT '会话状态，与房间状态一致',
+    duration BIGINT NOT NULL DEFAULT 0 COMMENT '通话时长(秒)',
+    finished_at TIMESTAMP NULL COMMENT '结束时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    UNIQUE KEY uk_session_id (session_id),
+    INDEX idx_room_id (room_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='音视频房间会话表';
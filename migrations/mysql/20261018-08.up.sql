@@ -0,0 +1,16 @@
+-- Migration 20261018-08: Create rtc_room_session_participant table
+-- Description: 创建会话参与者历史表，持久房间重新开始时保存上一个会话的参与者
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_room_session_participant (
+    id INT AUTO_INCREMENT PRIMARY KEY COMMENT '记录ID',
+    session_id VARCHAR(40) NOT NULL DEFAULT '' COMMENT '会话ID',
+    room_id VARCHAR(40) NOT NULL DEFAULT '' COMMENT '房间ID',
+    uid VARCHAR(40) NOT NULL DEFAULT '' COMMENT '用户ID',
+    device_type VARCHAR(20) NOT NULL DEFAULT '' COMMENT '设备类型',
+    status SMALLINT NOT NULL DEFAULT 0 COMMENT '参与者最终状态',
+    join_time BIGINT NOT NULL DEFAULT 0 COMMENT '加入时间戳',
+    leave_time BIGINT NOT NULL DEFAULT 0 COMMENT '离开时间戳',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    INDEX idx_session_id (session_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='音视频房间会话参与者历史表';
@@ -0,0 +1,6 @@
+-- Migration 20261018-09: Rollback
+-- Description: 删除 rtc_room.channel_id 字段及索引
+
+ALTER TABLE rtc_room
+DROP INDEX idx_channel_id,
+DROP COLUMN channel_id;
@@ -0,0 +1,7 @@
+-- Migration 20261018-09: Add channel_id to rtc_room table
+-- Description: 添加房间绑定的外部频道/群组ID字段
+-- Created: 2026-10-18
+
+ALTER TABLE rtc_room
+ADD COLUMN channel_id VARCHAR(64) NOT NULL DEFAULT '' COMMENT '绑定的外部频道/群组ID' AFTER session_id,
+ADD INDEX idx_channel_id (channel_id);
@@ -0,0 +1,19 @@
+-- Migration 20261018-10: Create rtc_invite_link table
+-- Description: 创建房间邀请链接表，记录签名邀请码的有效期、使用次数和角色
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_invite_link (
+    id INT AUTO_INCREMENT PRIMARY KEY COMMENT '记录ID',
+    link_id VARCHAR(40) NOT NULL DEFAULT '' COMMENT '邀请链接ID',
+    room_id VARCHAR(40) NOT NULL DEFAULT '' COMMENT '房间ID',
+    creator VARCHAR(40) NOT NULL DEFAULT '' COMMENT '创建者',
+    role VARCHAR(20) NOT NULL DEFAULT '' COMMENT '通过链接加入的角色',
+    max_uses INT NOT NULL DEFAULT 0 COMMENT '最多使用次数，0 表示不限',
+    used_count INT NOT NULL DEFAULT 0 COMMENT '已使用次数',
+    status SMALLINT NOT NULL DEFAULT 0 COMMENT '0: 有效, 1: 已撤销',
+    expires_at TIMESTAMP NULL COMMENT '过期时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    UNIQUE KEY uk_link_id (link_id),
+    INDEX idx_room_id (room_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='音视频房间邀请链接表';
@@ -0,0 +1,4 @@
+-- Migration 20261018-11: Rollback
+-- Description: 删除 rtc_room.host 字段
+
+ALTER TABLE rtc_room DROP COLUMN host;
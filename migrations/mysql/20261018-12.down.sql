@@ -0,0 +1,3 @@
+-- Migration 20261018-12: Rollback
+-- Description: 数据回填无需回滚（host 字段由 20261018-11 的回滚删除）
+
@@ -0,0 +1,4 @@
+-- Migration 20261018-13: Rollback
+-- Description: 删除 rtc_device_token 表
+
+DROP TABLE IF EXISTS rtc_device_token;
@@ -0,0 +1,14 @@
+-- Migration 20261018-13: Create rtc_device_token table
+-- Description: 创建设备推送 Token 表，用于来电推送（APNs VoIP/FCM/HMS）
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_device_token (
+    id INT AUTO_INCREMENT PRIMARY KEY COMMENT '记录ID',
+    uid VARCHAR(40) NOT NULL DEFAULT '' COMMENT '用户ID',
+    device_type VARCHAR(20) NOT NULL DEFAULT '' COMMENT '设备类型',
+    provider VARCHAR(20) NOT NULL DEFAULT '' COMMENT '推送通道: apns, fcm, hms',
+    token VARCHAR(255) NOT NULL DEFAULT '' COMMENT '设备推送Token',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    UNIQUE KEY uk_uid_device_type (uid, device_type)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='设备推送Token表';
@@ -0,0 +1,12 @@
+-- Migration 20261018-14: Create rtc_user_block table
+-- Description: 创建用户屏蔽列表，被屏蔽者的呼叫/邀请会被静默拦截
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_user_block (
+    id INT AUTO_INCREMENT PRIMARY KEY COMMENT '记录ID',
+    uid VARCHAR(40) NOT NULL DEFAULT '' COMMENT '用户ID',
+    blocked_uid VARCHAR(40) NOT NULL DEFAULT '' COMMENT '被屏蔽的用户ID',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    UNIQUE KEY uk_uid_blocked_uid (uid, blocked_uid),
+    INDEX idx_blocked_uid (blocked_uid)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户屏蔽列表';
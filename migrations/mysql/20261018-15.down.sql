@@ -0,0 +1,4 @@
+-- Migration 20261018-15: Rollback
+-- Description: 删除 rtc_call_policy_log 表
+
+DROP TABLE IF EXISTS rtc_call_policy_log;
@@ -0,0 +1,16 @@
+-- Migration 20261018-15: Create rtc_call_policy_log table
+-- Description: 创建呼叫策略拦截记录表，记录被屏蔽列表或业务后端拦截的呼叫
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_call_policy_log (
+    id INT AUTO_INCREMENT PRIMARY KEY COMMENT '记录ID',
+    room_id VARCHAR(40) NOT NULL DEFAULT '' COMMENT '房间ID',
+    caller VARCHAR(40) NOT NULL DEFAULT '' COMMENT '主叫',
+    callee VARCHAR(40) NOT NULL DEFAULT '' COMMENT '被叫',
+    source VARCHAR(20) NOT NULL DEFAULT '' COMMENT '拦截来源: block_list, callout',
+    reason VARCHAR(255) NOT NULL DEFAULT '' COMMENT '拦截原因',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    INDEX idx_room_id (room_id),
+    INDEX idx_caller (caller),
+    INDEX idx_callee (callee)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='呼叫策略拦截记录表';
@@ -0,0 +1,13 @@
+-- Migration 20261018-16: Create rtc_user_presence table
+-- Description: 创建用户可用状态表（可用/免打扰/离开/离线），呼叫和邀请时据此决定是否振铃
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_user_presence (
+    id INT AUTO_INCREMENT PRIMARY KEY COMMENT '记录ID',
+    uid VARCHAR(40) NOT NULL DEFAULT '' COMMENT '用户ID',
+    status VARCHAR(16) NOT NULL DEFAULT '' COMMENT '可用状态: available, dnd, away, offline',
+    expires_at TIMESTAMP NULL DEFAULT NULL COMMENT '过期时间，为空表示长期有效',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    UNIQUE KEY uk_uid (uid)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户可用状态表';
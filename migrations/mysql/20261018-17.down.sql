@@ -0,0 +1,8 @@
+-- Migration 20261018-17: Rollback
+-- Description: 删除 rtc_participant.idx_uid（重复的历史索引不再恢复）
+
+SET @idx_count := (SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'rtc_participant' AND index_name = 'idx_uid');
+SET @ddl := IF(@idx_count > 0, 'DROP INDEX idx_uid ON rtc_participant', 'DO 0');
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,25 @@
+-- Migration 20261018-17: Reconcile rtc_participant / rtc_room indexes
+-- Description: 统一索引定义，迁移脚本作为唯一的表结构来源：
+--   1. 补齐 rtc_participant.idx_uid（此前仅由 AutoMigrate 创建）
+--   2. 删除历史 AutoMigrate 创建的重复唯一索引 rtc_participant.idx_room_uid（与 uk_room_uid 相同）
+--   3. 删除历史 AutoMigrate 创建的重复唯一索引 rtc_room.idx_rtc_room_room_id（与 uk_room_id 相同）
+-- MySQL 不支持 CREATE/DROP INDEX IF [NOT] EXISTS，通过 information_schema 判断后动态执行
+-- Created: 2026-10-18
+
+SET @idx_count := (SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'rtc_participant' AND index_name = 'idx_uid');
+SET @ddl := IF(@idx_count = 0, 'CREATE INDEX idx_uid ON rtc_participant (uid)', 'DO 0');
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @idx_count := (SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'rtc_participant' AND index_name = 'idx_room_uid');
+SET @ddl := IF(@idx_count > 0, 'DROP INDEX idx_room_uid ON rtc_participant', 'DO 0');
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @idx_count := (SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'rtc_room' AND index_name = 'idx_rtc_room_room_id');
+SET @ddl := IF(@idx_count > 0, 'DROP INDEX idx_rtc_room_room_id ON rtc_room', 'DO 0');
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,56 @@
+-- Migration 20261018-18: Create archive tables
+-- Description: 创建冷数据归档表，已结束超过保留期的房间、参与者及 webhook 日志由归档任务移入这些表
+--   字段与源表一致（id 保留源表主键，不自增），另加 archived_at 记录归档时间
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_room_archive (
+    id INT NOT NULL PRIMARY KEY COMMENT '房间ID（源表主键）',
+    creator VARCHAR(40) NOT NULL DEFAULT '' COMMENT '房间创建者',
+    room_id VARCHAR(40) NOT NULL DEFAULT '' COMMENT '房间ID',
+    rtc_type SMALLINT NOT NULL DEFAULT 0 COMMENT '0: 语音, 1: 视频',
+    invite_on SMALLINT NOT NULL DEFAULT 0 COMMENT '0: 否, 1: 是, 2: 等候室',
+    status SMALLINT NOT NULL DEFAULT 0 COMMENT '房间最终状态',
+    max_participants INT NOT NULL DEFAULT 2 COMMENT '最多参与者数',
+    persistent SMALLINT NOT NULL DEFAULT 0 COMMENT '0: 一次性房间, 1: 持久房间',
+    session_id VARCHAR(40) NOT NULL DEFAULT '' COMMENT '会话ID',
+    channel_id VARCHAR(64) NOT NULL DEFAULT '' COMMENT '绑定的外部频道/群组ID',
+    host VARCHAR(40) NOT NULL DEFAULT '' COMMENT '主持人',
+    created_at TIMESTAMP NULL DEFAULT NULL COMMENT '创建时间',
+    updated_at TIMESTAMP NULL DEFAULT NULL COMMENT '更新时间',
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '归档时间',
+    INDEX idx_room_id (room_id),
+    INDEX idx_creator (creator),
+    INDEX idx_archived_at (archived_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='音视频房间归档表';
+
+CREATE TABLE IF NOT EXISTS rtc_participant_archive (
+    id INT NOT NULL PRIMARY KEY COMMENT '参与者ID（源表主键）',
+    room_id VARCHAR(40) NOT NULL DEFAULT '' COMMENT '房间ID',
+    uid VARCHAR(40) NOT NULL DEFAULT '' COMMENT '用户ID',
+    device_type VARCHAR(20) NOT NULL DEFAULT '' COMMENT '设备类型',
+    status SMALLINT NOT NULL DEFAULT 0 COMMENT '参与者最终状态',
+    join_time BIGINT COMMENT '加入时间戳(毫秒)',
+    leave_time BIGINT COMMENT '离开时间戳(毫秒)',
+    created_at TIMESTAMP NULL DEFAULT NULL COMMENT '创建时间',
+    updated_at TIMESTAMP NULL DEFAULT NULL COMMENT '更新时间',
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '归档时间',
+    INDEX idx_room_id (room_id),
+    INDEX idx_uid (uid)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='音视频房间参与者归档表';
+
+CREATE TABLE IF NOT EXISTS business_webhook_log_archive (
+    id BIGINT NOT NULL PRIMARY KEY COMMENT '日志ID（源表主键）',
+    event_type VARCHAR(100) NOT NULL DEFAULT '' COMMENT '事件类型',
+    event_id VARCHAR(100) NOT NULL DEFAULT '' COMMENT '事件ID',
+    url VARCHAR(500) NOT NULL DEFAULT '' COMMENT 'Webhook URL',
+    status INT NOT NULL DEFAULT 0 COMMENT 'HTTP 状态码',
+    request LONGTEXT COMMENT '请求体',
+    response LONGTEXT COMMENT '响应体',
+    error VARCHAR(500) COMMENT '错误信息',
+    retry INT NOT NULL DEFAULT 0 COMMENT '重试次数',
+    created_at TIMESTAMP NULL DEFAULT NULL COMMENT '创建时间',
+    updated_at TIMESTAMP NULL DEFAULT NULL COMMENT '更新时间',
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '归档时间',
+    INDEX idx_event_id (event_id),
+    INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='业务 webhook 日志归档表';
@@ -0,0 +1,10 @@
+-- Migration 20261018-19: Add last_event_at to rtc_room table
+-- Description: 记录房间最后一次应用的 LiveKit 事件时间（事件的 createdAt，Unix 秒），用于识别乱序到达的过期事件
+--   归档表字段与源表保持一致，同步添加
+-- Created: 2026-10-18
+
+ALTER TABLE rtc_room
+ADD COLUMN last_event_at BIGINT NOT NULL DEFAULT 0 COMMENT '最后应用的 LiveKit 事件时间（Unix 秒）' AFTER host;
+
+ALTER TABLE rtc_room_archive
+ADD COLUMN last_event_at BIGINT NOT NULL DEFAULT 0 COMMENT '最后应用的 LiveKit 事件时间（Unix 秒）' AFTER host;
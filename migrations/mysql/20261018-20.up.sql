@@ -0,0 +1,11 @@
+-- Migration 20261018-20: Create rtc_channel_lock table
+-- Description: 创建频道建房锁表（每个频道一行），创建绑定频道的房间时在事务内锁定该行
+--   Redis 建房锁失效或不可用时，由此保证同一频道同时只有一个进行中的房间
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_channel_lock (
+    id INT AUTO_INCREMENT PRIMARY KEY COMMENT '记录ID',
+    channel_id VARCHAR(64) NOT NULL DEFAULT '' COMMENT '外部频道/群组ID',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    UNIQUE KEY uk_channel_id (channel_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='频道建房锁表';
@@ -0,0 +1,36 @@
+-- Migration 20261018-21: Create session archive tables
+-- Description: 创建持久房间会话归档表，结束超过保留期的会话及其参与者历史由归档任务移入这些表
+--   字段与源表一致（id 保留源表主键，不自增），另加 archived_at 记录归档时间
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_room_session_archive (
+    id INT NOT NULL PRIMARY KEY COMMENT '会话记录ID（源表主键）',
+    session_id VARCHAR(40) NOT NULL DEFAULT '' COMMENT '会话ID',
+    room_id VARCHAR(40) NOT NULL DEFAULT '' COMMENT '房间ID',
+    creator VARCHAR(40) NOT NULL DEFAULT '' COMMENT '会话发起者',
+    rtc_type SMALLINT NOT NULL DEFAULT 0 COMMENT '0: 语音, 1: 视频',
+    status SMALLINT NOT NULL DEFAULT 0 COMMENT '会话最终状态',
+    duration BIGINT NOT NULL DEFAULT 0 COMMENT '通话时长(秒)',
+    finished_at TIMESTAMP NULL DEFAULT NULL COMMENT '结束时间',
+    created_at TIMESTAMP NULL DEFAULT NULL COMMENT '创建时间',
+    updated_at TIMESTAMP NULL DEFAULT NULL COMMENT '更新时间',
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '归档时间',
+    INDEX idx_session_id (session_id),
+    INDEX idx_room_id (room_id),
+    INDEX idx_archived_at (archived_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='音视频房间会话归档表';
+
+CREATE TABLE IF NOT EXISTS rtc_room_session_participant_archive (
+    id INT NOT NULL PRIMARY KEY COMMENT '记录ID（源表主键）',
+    session_id VARCHAR(40) NOT NULL DEFAULT '' COMMENT '会话ID',
+    room_id VARCHAR(40) NOT NULL DEFAULT '' COMMENT '房间ID',
+    uid VARCHAR(40) NOT NULL DEFAULT '' COMMENT '用户ID',
+    device_type VARCHAR(20) NOT NULL DEFAULT '' COMMENT '设备类型',
+    status SMALLINT NOT NULL DEFAULT 0 COMMENT '参与者最终状态',
+    join_time BIGINT NOT NULL DEFAULT 0 COMMENT '加入时间戳',
+    leave_time BIGINT NOT NULL DEFAULT 0 COMMENT '离开时间戳',
+    created_at TIMESTAMP NULL DEFAULT NULL COMMENT '创建时间',
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '归档时间',
+    INDEX idx_session_id (session_id),
+    INDEX idx_uid (uid)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='音视频房间会话参与者归档表';
@@ -0,0 +1,4 @@
+-- Migration 20260104-05: Rollback (PostgreSQL)
+-- Description: 删除 rtc_participant.device_type 字段
+
+ALTER TABLE rtc_participant DROP COLUMN IF EXISTS device_type;
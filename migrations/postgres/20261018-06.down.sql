@@ -0,0 +1,6 @@
+-- Migration 20261018-06: Rollback (PostgreSQL)
+-- Description: 删除 rtc_room.persistent、session_id 字段
+
+ALTER TABLE rtc_room
+DROP COLUMN IF EXISTS persistent,
+DROP COLUMN IF EXISTS session_id;
@@ -0,0 +1,7 @@
+-- Migration 20261018-06: Add persistent room columns to rtc_room table (PostgreSQL)
+-- Description: 添加持久房间标记和当前会话ID字段
+-- Created: 2026-10-18
+
+ALTER TABLE rtc_room
+ADD COLUMN IF NOT EXISTS persistent SMALLINT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS session_id VARCHAR(40) NOT NULL DEFAULT '';
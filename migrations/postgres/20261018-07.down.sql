@@ -0,0 +1,4 @@
+-- Migration 20261018-07: Rollback (PostgreSQL)
+-- Description: 删除 rtc_room_session 表
+
+DROP TABLE IF EXISTS rtc_room_session;
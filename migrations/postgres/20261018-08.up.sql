@@ -0,0 +1,16 @@
+-- Migration 20261018-08: Create rtc_room_session_participant table (PostgreSQL)
+-- Description: 创建会话参与者历史表，持久房间重新开始时保存上一个会话的参与者
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_room_session_participant (
+    id SERIAL PRIMARY KEY,
+    session_id VARCHAR(40) NOT NULL DEFAULT '',
+    room_id VARCHAR(40) NOT NULL DEFAULT '',
+    uid VARCHAR(40) NOT NULL DEFAULT '',
+    device_type VARCHAR(20) NOT NULL DEFAULT '',
+    status SMALLINT NOT NULL DEFAULT 0,
+    join_time BIGINT NOT NULL DEFAULT 0,
+    leave_time BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS rtc_room_session_participant_idx_session_id ON rtc_room_session_participant (session_id);
@@ -0,0 +1,5 @@
+-- Migration 20261018-09: Rollback (PostgreSQL)
+-- Description: 删除 rtc_room.channel_id 字段及索引
+
+DROP INDEX IF EXISTS rtc_room_idx_channel_id;
+ALTER TABLE rtc_room DROP COLUMN IF EXISTS channel_id;
@@ -0,0 +1,14 @@
+-- Migration 20261018-13: Create rtc_device_token table (PostgreSQL)
+-- Description: 创建设备推送 Token 表，用于来电推送（APNs VoIP/FCM/HMS）
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_device_token (
+    id SERIAL PRIMARY KEY,
+    uid VARCHAR(40) NOT NULL DEFAULT '',
+    device_type VARCHAR(20) NOT NULL DEFAULT '',
+    provider VARCHAR(20) NOT NULL DEFAULT '',
+    token VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS rtc_device_token_uk_uid_device_type ON rtc_device_token (uid, device_type);
@@ -0,0 +1,13 @@
+-- Migration 20261018-16: Create rtc_user_presence table (PostgreSQL)
+-- Description: 创建用户可用状态表（可用/免打扰/离开/离线），呼叫和邀请时据此决定是否振铃
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_user_presence (
+    id SERIAL PRIMARY KEY,
+    uid VARCHAR(40) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NULL DEFAULT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS rtc_user_presence_uk_uid ON rtc_user_presence (uid);
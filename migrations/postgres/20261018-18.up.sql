@@ -0,0 +1,56 @@
+-- Migration 20261018-18: Create archive tables (PostgreSQL)
+-- Description: 创建冷数据归档表，已结束超过保留期的房间、参与者及 webhook 日志由归档任务移入这些表
+--   字段与源表一致（id 保留源表主键，不自增），另加 archived_at 记录归档时间
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_room_archive (
+    id INTEGER NOT NULL PRIMARY KEY,
+    creator VARCHAR(40) NOT NULL DEFAULT '',
+    room_id VARCHAR(40) NOT NULL DEFAULT '',
+    rtc_type SMALLINT NOT NULL DEFAULT 0,
+    invite_on SMALLINT NOT NULL DEFAULT 0,
+    status SMALLINT NOT NULL DEFAULT 0,
+    max_participants INT NOT NULL DEFAULT 2,
+    persistent SMALLINT NOT NULL DEFAULT 0,
+    session_id VARCHAR(40) NOT NULL DEFAULT '',
+    channel_id VARCHAR(64) NOT NULL DEFAULT '',
+    host VARCHAR(40) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NULL DEFAULT NULL,
+    updated_at TIMESTAMPTZ NULL DEFAULT NULL,
+    archived_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS rtc_room_archive_idx_room_id ON rtc_room_archive (room_id);
+CREATE INDEX IF NOT EXISTS rtc_room_archive_idx_creator ON rtc_room_archive (creator);
+CREATE INDEX IF NOT EXISTS rtc_room_archive_idx_archived_at ON rtc_room_archive (archived_at);
+
+CREATE TABLE IF NOT EXISTS rtc_participant_archive (
+    id INTEGER NOT NULL PRIMARY KEY,
+    room_id VARCHAR(40) NOT NULL DEFAULT '',
+    uid VARCHAR(40) NOT NULL DEFAULT '',
+    device_type VARCHAR(20) NOT NULL DEFAULT '',
+    status SMALLINT NOT NULL DEFAULT 0,
+    join_time BIGINT,
+    leave_time BIGINT,
+    created_at TIMESTAMPTZ NULL DEFAULT NULL,
+    updated_at TIMESTAMPTZ NULL DEFAULT NULL,
+    archived_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS rtc_participant_archive_idx_room_id ON rtc_participant_archive (room_id);
+CREATE INDEX IF NOT EXISTS rtc_participant_archive_idx_uid ON rtc_participant_archive (uid);
+
+CREATE TABLE IF NOT EXISTS business_webhook_log_archive (
+    id BIGINT NOT NULL PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL DEFAULT '',
+    event_id VARCHAR(100) NOT NULL DEFAULT '',
+    url VARCHAR(500) NOT NULL DEFAULT '',
+    status INT NOT NULL DEFAULT 0,
+    request TEXT,
+    response TEXT,
+    error VARCHAR(500),
+    retry INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NULL DEFAULT NULL,
+    updated_at TIMESTAMPTZ NULL DEFAULT NULL,
+    archived_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS business_webhook_log_archive_idx_event_id ON business_webhook_log_archive (event_id);
+CREATE INDEX IF NOT EXISTS business_webhook_log_archive_idx_created_at ON business_webhook_log_archive (created_at);
@@ -0,0 +1,4 @@
+-- Migration 20261018-20: Rollback (PostgreSQL)
+-- Description: 删除 rtc_channel_lock 表
+
+DROP TABLE IF EXISTS rtc_channel_lock;
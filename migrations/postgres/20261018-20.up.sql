@@ -0,0 +1,11 @@
+-- Migration 20261018-20: Create rtc_channel_lock table (PostgreSQL)
+-- Description: 创建频道建房锁表（每个频道一行），创建绑定频道的房间时在事务内锁定该行
+--   Redis 建房锁失效或不可用时，由此保证同一频道同时只有一个进行中的房间
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_channel_lock (
+    id SERIAL PRIMARY KEY,
+    channel_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS rtc_channel_lock_uk_channel_id ON rtc_channel_lock (channel_id);
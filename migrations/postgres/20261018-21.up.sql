@@ -0,0 +1,36 @@
+-- Migration 20261018-21: Create session archive tables (PostgreSQL)
+-- Description: 创建持久房间会话归档表，结束超过保留期的会话及其参与者历史由归档任务移入这些表
+--   字段与源表一致（id 保留源表主键，不自增），另加 archived_at 记录归档时间
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_room_session_archive (
+    id INTEGER NOT NULL PRIMARY KEY,
+    session_id VARCHAR(40) NOT NULL DEFAULT '',
+    room_id VARCHAR(40) NOT NULL DEFAULT '',
+    creator VARCHAR(40) NOT NULL DEFAULT '',
+    rtc_type SMALLINT NOT NULL DEFAULT 0,
+    status SMALLINT NOT NULL DEFAULT 0,
+    duration BIGINT NOT NULL DEFAULT 0,
+    finished_at TIMESTAMPTZ NULL DEFAULT NULL,
+    created_at TIMESTAMPTZ NULL DEFAULT NULL,
+    updated_at TIMESTAMPTZ NULL DEFAULT NULL,
+    archived_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS rtc_room_session_archive_idx_session_id ON rtc_room_session_archive (session_id);
+CREATE INDEX IF NOT EXISTS rtc_room_session_archive_idx_room_id ON rtc_room_session_archive (room_id);
+CREATE INDEX IF NOT EXISTS rtc_room_session_archive_idx_archived_at ON rtc_room_session_archive (archived_at);
+
+CREATE TABLE IF NOT EXISTS rtc_room_session_participant_archive (
+    id INTEGER NOT NULL PRIMARY KEY,
+    session_id VARCHAR(40) NOT NULL DEFAULT '',
+    room_id VARCHAR(40) NOT NULL DEFAULT '',
+    uid VARCHAR(40) NOT NULL DEFAULT '',
+    device_type VARCHAR(20) NOT NULL DEFAULT '',
+    status SMALLINT NOT NULL DEFAULT 0,
+    join_time BIGINT NOT NULL DEFAULT 0,
+    leave_time BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NULL DEFAULT NULL,
+    archived_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS rtc_room_session_participant_archive_idx_session_id ON rtc_room_session_participant_archive (session_id);
+CREATE INDEX IF NOT EXISTS rtc_room_session_participant_archive_idx_uid ON rtc_room_session_participant_archive (uid);
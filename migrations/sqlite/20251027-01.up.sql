@@ -0,0 +1,18 @@
+-- Migration 20251027-01: Create rtc_room table (SQLite)
+-- Description: 创建房间表，用于存储音视频房间信息
+-- Created: 2025-10-27
+
+CREATE TABLE IF NOT EXISTS rtc_room (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    creator VARCHAR(40) NOT NULL DEFAULT '',
+    room_id VARCHAR(40) NOT NULL DEFAULT '',
+    rtc_type SMALLINT NOT NULL DEFAULT 0,
+    invite_on SMALLINT NOT NULL DEFAULT 0,
+    status SMALLINT NOT NULL DEFAULT 0,
+    max_participants INT NOT NULL DEFAULT 2,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS rtc_room_uk_room_id ON rtc_room (room_id);
+CREATE INDEX IF NOT EXISTS rtc_room_idx_creator ON rtc_room (creator);
+CREATE INDEX IF NOT EXISTS rtc_room_idx_status ON rtc_room (status);
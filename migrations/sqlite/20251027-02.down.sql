@@ -0,0 +1,4 @@
+-- Migration 20251027-02: Rollback (SQLite)
+-- Description: 删除 rtc_participant 表
+
+DROP TABLE IF EXISTS rtc_participant;
@@ -0,0 +1,18 @@
+-- Migration 20251027-02: Create rtc_participant table (SQLite)
+-- Description: 创建参与者表，用于存储房间参与者信息
+-- Created: 2025-10-27
+
+CREATE TABLE IF NOT EXISTS rtc_participant (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_id VARCHAR(40) NOT NULL DEFAULT '',
+    uid VARCHAR(40) NOT NULL DEFAULT '',
+    status SMALLINT NOT NULL DEFAULT 0,
+    join_time BIGINT,
+    leave_time BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS rtc_participant_uk_room_uid ON rtc_participant (room_id, uid);
+CREATE INDEX IF NOT EXISTS rtc_participant_idx_room_id ON rtc_participant (room_id);
+CREATE INDEX IF NOT EXISTS rtc_participant_idx_status ON rtc_participant (status);
+CREATE INDEX IF NOT EXISTS rtc_participant_idx_uid ON rtc_participant (uid);
@@ -0,0 +1,20 @@
+-- Migration 20251030-04: Create business_webhook_log table (SQLite)
+-- Description: 创建业务 webhook 日志表
+
+CREATE TABLE IF NOT EXISTS business_webhook_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_type VARCHAR(100) NOT NULL DEFAULT '',
+    event_id VARCHAR(100) NOT NULL DEFAULT '',
+    url VARCHAR(500) NOT NULL DEFAULT '',
+    status INT NOT NULL DEFAULT 0,
+    request TEXT,
+    response TEXT,
+    error VARCHAR(500),
+    retry INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS business_webhook_log_idx_event_id ON business_webhook_log (event_id);
+CREATE INDEX IF NOT EXISTS business_webhook_log_idx_event_type ON business_webhook_log (event_type);
+CREATE INDEX IF NOT EXISTS business_webhook_log_idx_url ON business_webhook_log (url);
+CREATE INDEX IF NOT EXISTS business_webhook_log_idx_created_at ON business_webhook_log (created_at);
@@ -0,0 +1,4 @@
+-- Migration 20260104-05: Rollback (SQLite)
+-- Description: 删除 rtc_participant.device_type 字段
+
+ALTER TABLE rtc_participant DROP COLUMN device_type;
@@ -0,0 +1,5 @@
+-- Migration 20261018-06: Rollback (SQLite)
+-- Description: 删除 rtc_room.persistent、session_id 字段
+
+ALTER TABLE rtc_room DROP COLUMN persistent;
+ALTER TABLE rtc_room DROP COLUMN session_id;
@@ -0,0 +1,6 @@
+-- Migration 20261018-06: Add persistent room columns to rtc_room table (SQLite)
+-- Description: 添加持久房间标记和当前会话ID字段
+-- Created: 2026-10-18
+
+ALTER TABLE rtc_room ADD COLUMN persistent SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE rtc_room ADD COLUMN session_id VARCHAR(40) NOT NULL DEFAULT '';
@@ -0,0 +1,18 @@
+-- Migration 20261018-07: Create rtc_room_session table (SQLite)
+-- Description: 创建房间会话表，记录持久房间每一次开始到结束的会话
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_room_session (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id VARCHAR(40) NOT NULL DEFAULT '',
+    room_id VARCHAR(40) NOT NULL DEFAULT '',
+    creator VARCHAR(40) NOT NULL DEFAULT '',
+    rtc_type SMALLINT NOT NULL DEFAULT 0,
+    status SMALLINT NOT NULL DEFAULT 0,
+    duration BIGINT NOT NULL DEFAULT 0,
+    finished_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS rtc_room_session_uk_session_id ON rtc_room_session (session_id);
+CREATE INDEX IF NOT EXISTS rtc_room_session_idx_room_id ON rtc_room_session (room_id);
@@ -0,0 +1,4 @@
+-- Migration 20261018-08: Rollback (SQLite)
+-- Description: 删除 rtc_room_session_participant 表
+
+DROP TABLE IF EXISTS rtc_room_session_participant;
@@ -0,0 +1,6 @@
+-- Migration 20261018-09: Add channel_id to rtc_room table (SQLite)
+-- Description: 添加房间绑定的外部频道/群组ID字段
+-- Created: 2026-10-18
+
+ALTER TABLE rtc_room ADD COLUMN channel_id VARCHAR(64) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS rtc_room_idx_channel_id ON rtc_room (channel_id);
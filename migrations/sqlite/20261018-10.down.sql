@@ -0,0 +1,4 @@
+-- Migration 20261018-10: Rollback (SQLite)
+-- Description: 删除 rtc_invite_link 表
+
+DROP TABLE IF EXISTS rtc_invite_link;
@@ -0,0 +1,19 @@
+-- Migration 20261018-10: Create rtc_invite_link table (SQLite)
+-- Description: 创建房间邀请链接表，记录签名邀请码的有效期、使用次数和角色
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_invite_link (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    link_id VARCHAR(40) NOT NULL DEFAULT '',
+    room_id VARCHAR(40) NOT NULL DEFAULT '',
+    creator VARCHAR(40) NOT NULL DEFAULT '',
+    role VARCHAR(20) NOT NULL DEFAULT '',
+    max_uses INT NOT NULL DEFAULT 0,
+    used_count INT NOT NULL DEFAULT 0,
+    status SMALLINT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS rtc_invite_link_uk_link_id ON rtc_invite_link (link_id);
+CREATE INDEX IF NOT EXISTS rtc_invite_link_idx_room_id ON rtc_invite_link (room_id);
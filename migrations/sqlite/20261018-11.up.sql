@@ -0,0 +1,5 @@
+-- Migration 20261018-11: Add host to rtc_room table (SQLite)
+-- Description: 添加房间当前主持人字段（默认为创建者，可转移）
+-- Created: 2026-10-18
+
+ALTER TABLE rtc_room ADD COLUMN host VARCHAR(40) NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- Migration 20261018-12: Backfill rtc_room host (SQLite)
+-- Description: 历史房间的主持人设置为创建者
+-- Created: 2026-10-18
+
+UPDATE rtc_room SET host = creator WHERE host = '';
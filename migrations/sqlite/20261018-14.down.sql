@@ -0,0 +1,4 @@
+-- Migration 20261018-14: Rollback (SQLite)
+-- Description: 删除 rtc_user_block 表
+
+DROP TABLE IF EXISTS rtc_user_block;
@@ -0,0 +1,12 @@
+-- Migration 20261018-14: Create rtc_user_block table (SQLite)
+-- Description: 创建用户屏蔽列表，被屏蔽者的呼叫/邀请会被静默拦截
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_user_block (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    uid VARCHAR(40) NOT NULL DEFAULT '',
+    blocked_uid VARCHAR(40) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS rtc_user_block_uk_uid_blocked_uid ON rtc_user_block (uid, blocked_uid);
+CREATE INDEX IF NOT EXISTS rtc_user_block_idx_blocked_uid ON rtc_user_block (blocked_uid);
@@ -0,0 +1,16 @@
+-- Migration 20261018-15: Create rtc_call_policy_log table (SQLite)
+-- Description: 创建呼叫策略拦截记录表，记录被屏蔽列表或业务后端拦截的呼叫
+-- Created: 2026-10-18
+
+CREATE TABLE IF NOT EXISTS rtc_call_policy_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_id VARCHAR(40) NOT NULL DEFAULT '',
+    caller VARCHAR(40) NOT NULL DEFAULT '',
+    callee VARCHAR(40) NOT NULL DEFAULT '',
+    source VARCHAR(20) NOT NULL DEFAULT '',
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS rtc_call_policy_log_idx_room_id ON rtc_call_policy_log (room_id);
+CREATE INDEX IF NOT EXISTS rtc_call_policy_log_idx_caller ON rtc_call_policy_log (caller);
+CREATE INDEX IF NOT EXISTS rtc_call_policy_log_idx_callee ON rtc_call_policy_log (callee);
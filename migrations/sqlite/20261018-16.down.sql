@@ -0,0 +1,4 @@
+-- Migration 20261018-16: Rollback (SQLite)
+-- Description: 删除 rtc_user_presence 表
+
+DROP TABLE IF EXISTS rtc_user_presence;
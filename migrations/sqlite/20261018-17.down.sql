@@ -0,0 +1,2 @@
+-- Migration 20261018-17: Rollback (SQLite)
+-- Description: rtc_participant_idx_uid 属于 20251027-02 的基线结构，回滚无需执行任何语句
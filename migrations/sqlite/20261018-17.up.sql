@@ -0,0 +1,6 @@
+-- Migration 20261018-17: Reconcile rtc_participant indexes (SQLite)
+-- Description: 统一索引定义，迁移脚本作为唯一的表结构来源；
+--   SQLite 从未执行 AutoMigrate，rtc_participant_idx_uid 已在 20251027-02 中创建，此处仅保证存在
+-- Created: 2026-10-18
+
+CREATE INDEX IF NOT EXISTS rtc_participant_idx_uid ON rtc_participant (uid);
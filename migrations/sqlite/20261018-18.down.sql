@@ -0,0 +1,6 @@
+-- Migration 20261018-18: Rollback
+-- Description: 删除冷数据归档表（已归档的数据会一并删除）
+
+DROP TABLE IF EXISTS business_webhook_log_archive;
+DROP TABLE IF EXISTS rtc_participant_archive;
+DROP TABLE IF EXISTS rtc_room_archive;
@@ -0,0 +1,6 @@
+-- Migration 20261018-19: Rollback (SQLite)
+-- Description: 删除 rtc_room.last_event_at 及归档表对应字段
+
+ALTER TABLE rtc_room_archive DROP COLUMN last_event_at;
+
+ALTER TABLE rtc_room DROP COLUMN last_event_at;
@@ -0,0 +1,5 @@
+-- Migration 20261018-21: Rollback
+-- Description: 删除会话归档表（已归档的数据会一并删除）
+
+DROP TABLE IF EXISTS rtc_room_session_participant_archive;
+DROP TABLE IF EXISTS rtc_room_session_archive;